@@ -0,0 +1,162 @@
+package main
+
+//
+// Generation of Bazel BUILD files for the converted C++ packages
+//
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/raff/walkngo/walker"
+)
+
+//
+// bazelPackage is an output folder, containing the files of a Go package
+//
+type bazelPackage struct {
+	name     string
+	main     bool
+	srcs     []string
+	imports  map[string]bool
+	examples []string // example functions, with a main in the examples folder
+}
+
+//
+// BazelBuild collects the converted files and their imports, to write a BUILD.bazel file
+// (with a cc_library, or cc_binary for package main) in each output folder
+//
+type BazelBuild struct {
+	Runtime string // label of the runtime library
+
+	root     string                   // the output folder, assumed to be the workspace root
+	packages map[string]*bazelPackage // by output folder
+	module   *walkngo.Module
+}
+
+func NewBazelBuild(root, runtime string, module *walkngo.Module) *BazelBuild {
+	return &BazelBuild{Runtime: runtime, root: root, packages: map[string]*bazelPackage{}, module: module}
+}
+
+//
+// Add records a converted file
+//
+func (b *BazelBuild) Add(outpath, pkgname string, imports []string) {
+	dir := filepath.Dir(outpath)
+
+	pkg, ok := b.packages[dir]
+	if !ok {
+		pkg = &bazelPackage{name: pkgname, main: pkgname == "main", imports: map[string]bool{}}
+		if pkg.main {
+			pkg.name = filepath.Base(dir)
+		}
+
+		b.packages[dir] = pkg
+	}
+
+	pkg.srcs = appendUnique(pkg.srcs, filepath.Base(outpath))
+
+	for _, i := range imports {
+		pkg.imports[i] = true
+	}
+}
+
+//
+// AddExamples records the examples of an output folder, each one a cc_test linked with the package library
+//
+func (b *BazelBuild) AddExamples(dir string, funcs []string) {
+	if pkg, ok := b.packages[dir]; ok && !pkg.main {
+		pkg.examples = funcs
+	}
+}
+
+//
+// label returns the label of the target for an output folder
+//
+func (b *BazelBuild) label(dir string) string {
+	rel, err := filepath.Rel(b.root, dir)
+	if err != nil || rel == "." {
+		rel = ""
+	}
+
+	return fmt.Sprintf("//%s:%s", filepath.ToSlash(rel), b.packages[dir].name)
+}
+
+//
+// deps returns the labels of the dependencies of a package: the converted packages it imports
+// (found in the output folder corresponding to the module or vendor folder) and the runtime
+//
+func (b *BazelBuild) deps(pkg *bazelPackage) []string {
+	deps := []string{b.Runtime}
+
+	for ipath := range pkg.imports {
+		if b.module == nil || !(b.module.Contains(ipath) || b.module.Vendored(ipath)) {
+			continue
+		}
+
+		rel, err := filepath.Rel(b.module.Dir, b.module.PackageDir(ipath))
+		if err != nil {
+			continue
+		}
+
+		if dir := filepath.Join(b.root, rel); b.packages[dir] != nil {
+			deps = append(deps, b.label(dir))
+		}
+	}
+
+	sort.Strings(deps[1:])
+	return deps
+}
+
+//
+// Write writes the BUILD.bazel files
+//
+func (b *BazelBuild) Write() error {
+	for dir, pkg := range b.packages {
+		var buf bytes.Buffer
+
+		rule := "cc_library"
+		if pkg.main {
+			rule = "cc_binary"
+		}
+
+		sort.Strings(pkg.srcs)
+
+		fmt.Fprintln(&buf, "# generated by walkngo")
+		fmt.Fprintln(&buf)
+		fmt.Fprintf(&buf, "%s(\n", rule)
+		fmt.Fprintf(&buf, "    name = %q,\n", pkg.name)
+		fmt.Fprintf(&buf, "    srcs = [\n")
+		for _, s := range pkg.srcs {
+			fmt.Fprintf(&buf, "        %q,\n", s)
+		}
+		fmt.Fprintf(&buf, "    ],\n")
+		fmt.Fprintf(&buf, "    deps = [\n")
+		for _, d := range b.deps(pkg) {
+			fmt.Fprintf(&buf, "        %q,\n", d)
+		}
+		fmt.Fprintf(&buf, "    ],\n")
+		if !pkg.main {
+			fmt.Fprintln(&buf, `    visibility = ["//visibility:public"],`)
+		}
+		fmt.Fprintln(&buf, ")")
+
+		for _, ex := range pkg.examples {
+			fmt.Fprintln(&buf)
+			fmt.Fprintf(&buf, "cc_test(\n")
+			fmt.Fprintf(&buf, "    name = %q,\n", ex)
+			fmt.Fprintf(&buf, "    srcs = [%q],\n", "examples/"+ex+".cc")
+			fmt.Fprintf(&buf, "    deps = [%q, %q],\n", ":"+pkg.name, b.Runtime)
+			fmt.Fprintln(&buf, ")")
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "BUILD.bazel"), buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
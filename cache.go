@@ -0,0 +1,128 @@
+package main
+
+//
+// Cache of the converted files (--cache), to skip the files not changed since the previous run
+//
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/raff/walkngo/walker"
+)
+
+// folder of the cache, in the output folder
+const CACHE_DIR = ".walkngo-cache"
+
+//
+// CacheEntry is a converted file: its output and what's needed from the walker after the conversion
+// (for the build files, bindings and examples)
+//
+type CacheEntry struct {
+	Output    string              `json:"output"`
+	Package   string              `json:"package"`
+	Namespace string              `json:"namespace"`
+	Imports   []string            `json:"imports,omitempty"`
+	Exports   []walkngo.Export    `json:"exports,omitempty"`
+	Examples  []walkngo.Example   `json:"examples,omitempty"`
+	Mangled   map[string]string   `json:"mangled,omitempty"`
+	Externals map[string][]string `json:"externals,omitempty"`
+}
+
+//
+// Cache stores the converted files by hash of the source, its path and the options of the run,
+// so that the files not changed are not converted again. Files with conversion problems are not cached,
+// so that the problems are reported at each run
+//
+type Cache struct {
+	dir     string
+	options string // hash of the options (see cacheOptions)
+}
+
+func NewCache(outdir, options string) *Cache {
+	return &Cache{dir: filepath.Join(outdir, CACHE_DIR), options: options}
+}
+
+//
+// Key returns the key of a source file (rel is the path relative to the input folder)
+//
+func (c *Cache) Key(rel string, src []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", c.options, rel)
+	h.Write(src)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+//
+// Get returns the cached entry for key (nil if not found or not readable)
+//
+func (c *Cache) Get(key string) *CacheEntry {
+	data, err := os.ReadFile(filepath.Join(c.dir, key+".json"))
+	if err != nil {
+		return nil
+	}
+
+	var e CacheEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil
+	}
+
+	return &e
+}
+
+//
+// Put stores the entry for key
+//
+func (c *Cache) Put(key string, e *CacheEntry) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(c.dir, key+".json"), data, 0644)
+}
+
+// options naming files read by the conversion
+var cacheInputs = map[string]bool{"imports": true, "types": true}
+
+// options that don't change the output
+var cacheIgnored = map[string]bool{"cache": true, "q": true, "v": true, "vv": true, "color": true, "profile": true}
+
+//
+// cacheOptions returns the hash of what changes the output besides the source: the walkngo version,
+// the options set on the command line (except the ones only changing the messages), the content of the files they name (import and type maps)
+// and the module path
+//
+func cacheOptions(mod *walkngo.Module) string {
+	h := sha256.New()
+	fmt.Fprintln(h, version)
+
+	flag.Visit(func(f *flag.Flag) {
+		if cacheIgnored[f.Name] {
+			return
+		}
+
+		fmt.Fprintf(h, "%s=%s\n", f.Name, f.Value)
+
+		if cacheInputs[f.Name] {
+			if data, err := os.ReadFile(f.Value.String()); err == nil {
+				h.Write(data)
+			}
+		}
+	})
+
+	if mod != nil {
+		fmt.Fprintln(h, mod.Path)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
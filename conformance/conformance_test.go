@@ -0,0 +1,127 @@
+package conformance
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//
+// knownFailures are the programs that the converter doesn't handle yet (see history.tsv):
+// they are skipped when they fail, and reported when they pass
+//
+var knownFailures = map[string]bool{
+	"closures":  true,
+	"constants": true,
+	"methods":   true,
+	"structs":   true,
+	"values":    true,
+	"variables": true,
+}
+
+//
+// TestConformance converts each program in testdata to C++, compiles it with the runtime, runs it and compares
+// its output with the .out file, like run.sh. CXX and CXXFLAGS select the compiler (g++ by default)
+//
+func TestConformance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("conformance is not run in short mode")
+	}
+
+	cxx := os.Getenv("CXX")
+	if len(cxx) == 0 {
+		cxx = "g++"
+	}
+
+	if _, err := exec.LookPath(cxx); err != nil {
+		t.Skip("no C++ compiler:", err)
+	}
+
+	cxxflags := strings.Fields(os.Getenv("CXXFLAGS"))
+	if len(cxxflags) == 0 {
+		cxxflags = []string{"-std=c++17", "-w"}
+	}
+
+	root, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmp := t.TempDir()
+	walkngo := filepath.Join(tmp, "walkngo")
+
+	build := exec.Command("go", "build", "-o", walkngo, ".")
+	build.Dir = root
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("build walkngo: %v\n%s", err, out)
+	}
+
+	sources, err := filepath.Glob(filepath.Join("testdata", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, src := range sources {
+		src := src
+		name := strings.TrimSuffix(filepath.Base(src), ".go")
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fail := t.Fatalf
+			if knownFailures[name] {
+				fail = t.Skipf
+			}
+
+			expected, err := os.ReadFile(strings.TrimSuffix(src, ".go") + ".out")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			converted, err := exec.Command(walkngo, "--lang=c", src).Output()
+			if err != nil {
+				fail("convert: %v", err)
+			}
+
+			cc, exe := filepath.Join(tmp, name+".cc"), filepath.Join(tmp, name)
+			if err := os.WriteFile(cc, converted, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			args := append(append([]string{}, cxxflags...), "-I", filepath.Join(root, "runtime", "c"), "-o", exe, cc)
+			if out, err := exec.Command(cxx, args...).CombinedOutput(); err != nil {
+				fail("compile: %v\n%s", err, out)
+			}
+
+			output, err := exec.Command(exe).Output()
+			if err != nil {
+				fail("run: %v", err)
+			}
+
+			if normalize(output) != normalize(expected) {
+				fail("output:\n%s\nexpected:\n%s", output, expected)
+			}
+
+			if knownFailures[name] {
+				t.Errorf("%s passes now, remove it from knownFailures", name)
+			}
+		})
+	}
+}
+
+//
+// normalize ignores the differences in whitespace, like diff -b
+// (fmt.Println in the runtime adds a space after each value)
+//
+func normalize(out []byte) string {
+	var lines []string
+
+	for _, l := range strings.Split(string(bytes.TrimRight(out, "\n")), "\n") {
+		lines = append(lines, strings.Join(strings.Fields(l), " "))
+	}
+
+	return strings.Join(lines, "\n")
+}
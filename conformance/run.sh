@@ -0,0 +1,65 @@
+#!/bin/sh
+#
+# Conformance run: convert each program in conformance/testdata to C++,
+# compile it against runtime/c, run it and compare its output with the
+# expected output (generated with "go run" and stored in the .out files).
+#
+# Usage: conformance/run.sh [history-file]
+#
+# The pass rate is printed at the end, and appended to history-file
+# (with date and commit) if specified, to track it over time.
+#
+# CXX and CXXFLAGS can be used to select the compiler and its options.
+#
+
+ROOT=$(cd "$(dirname "$0")/.." && pwd)
+CORPUS="$ROOT/conformance/testdata"
+HISTORY="$1"
+
+CXX=${CXX:-c++}
+CXXFLAGS=${CXXFLAGS:--std=c++17 -w}
+
+TMP=$(mktemp -d)
+trap 'rm -rf "$TMP"' EXIT
+
+(cd "$ROOT" && go build -o "$TMP/walkngo" .) || exit 1
+
+total=0
+passed=0
+
+for src in "$CORPUS"/*.go; do
+	name=$(basename "$src" .go)
+	total=$((total + 1))
+
+	if ! "$TMP/walkngo" --lang=c "$src" > "$TMP/$name.cc" 2> "$TMP/$name.log"; then
+		echo "FAIL $name (convert)"
+		continue
+	fi
+
+	if ! $CXX $CXXFLAGS -I "$ROOT/runtime/c" -o "$TMP/$name" "$TMP/$name.cc" >> "$TMP/$name.log" 2>&1; then
+		echo "FAIL $name (compile)"
+		continue
+	fi
+
+	if ! "$TMP/$name" > "$TMP/$name.out" 2>> "$TMP/$name.log"; then
+		echo "FAIL $name (run)"
+		continue
+	fi
+
+	# fmt.Println in the runtime adds a space after each value, so ignore whitespace differences
+	if ! diff -b "$CORPUS/$name.out" "$TMP/$name.out" > /dev/null; then
+		echo "FAIL $name (output)"
+		continue
+	fi
+
+	echo "PASS $name"
+	passed=$((passed + 1))
+done
+
+rate=$((passed * 100 / total))
+echo "conformance: $passed/$total passed ($rate%)"
+
+if [ -n "$HISTORY" ]; then
+	commit=$(cd "$ROOT" && git rev-parse --short HEAD 2> /dev/null)
+	printf '%s\t%s\t%d\t%d\t%d%%\n' "$(date -u +%Y-%m-%d)" "${commit:-unknown}" "$passed" "$total" "$rate" >> "$HISTORY"
+fi
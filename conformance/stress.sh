@@ -0,0 +1,81 @@
+#!/bin/sh
+#
+# Stress run: generate a large program in the style of the code generated by
+# tools (lexers and parsers): a state machine with a label for each state and
+# goto between them, a switch with a case for each state and a long else if
+# chain (more nested scopes than go/parser resolves). Then convert it to C++,
+# compile it against runtime/c, run it and compare its output with "go run".
+#
+# Usage: conformance/stress.sh [states]
+#
+# CXX and CXXFLAGS can be used to select the compiler and its options.
+#
+
+ROOT=$(cd "$(dirname "$0")/.." && pwd)
+STATES=${1:-2000}
+
+CXX=${CXX:-c++}
+CXXFLAGS=${CXXFLAGS:--std=c++17 -w}
+
+TMP=$(mktemp -d)
+trap 'rm -rf "$TMP"' EXIT
+
+(cd "$ROOT" && go build -o "$TMP/walkngo" .) || exit 1
+
+awk -v n="$STATES" 'BEGIN {
+	print "package main\n\nimport \"fmt\"\n"
+
+	print "func run(data string) int {\n\tp := 0\n\tacc := 0\n\tend := len(data)\n"
+	for (i = 0; i < n; i++) {
+		printf "s%d:\n\tif p >= end {\n\t\tgoto done\n\t}\n", i
+		printf "\tswitch data[p] {\n\tcase \047a\047:\n\t\tacc += %d\n\t\tp++\n\t\tgoto s%d\n", i, (i + 1) % n
+		printf "\tcase \047b\047:\n\t\tp++\n\t\tgoto s%d\n\t}\n", (i * 7 + 3) % n
+		printf "\tp++\n\tgoto s%d\n", (i + 2) % n
+	}
+	print "done:\n\treturn acc\n}\n"
+
+	print "func table(state int) int {\n\tswitch state {"
+	for (i = 0; i < n; i++) {
+		printf "\tcase %d:\n\t\treturn %d\n", i, (i * 31 + 7) % n
+	}
+	print "\t}\n\treturn -1\n}\n"
+
+	printf "func chain(x int) int {\n\tif x == 0 {\n\t\treturn 0\n\t}"
+	for (i = 1; i < n; i++) {
+		printf " else if x == %d {\n\t\treturn %d\n\t}", i, n - i
+	}
+	print "\n\treturn -1\n}\n"
+
+	print "func main() {"
+	print "\tfmt.Println(run(\"abcabbaccabbbacab\"), table(5), table(-1), chain(1), chain(-1))"
+	print "}"
+}' > "$TMP/stress.go"
+
+go run "$TMP/stress.go" > "$TMP/stress.expected" || exit 1
+
+start=$(date +%s)
+if ! "$TMP/walkngo" --lang=c "$TMP/stress.go" > "$TMP/stress.cc" 2> "$TMP/stress.log"; then
+	cat "$TMP/stress.log"
+	echo "FAIL stress (convert)"
+	exit 1
+fi
+echo "converted $(wc -l < "$TMP/stress.go") lines in $(($(date +%s) - start))s"
+
+if ! $CXX $CXXFLAGS -I "$ROOT/runtime/c" -o "$TMP/stress" "$TMP/stress.cc" >> "$TMP/stress.log" 2>&1; then
+	cat "$TMP/stress.log"
+	echo "FAIL stress (compile)"
+	exit 1
+fi
+
+if ! "$TMP/stress" > "$TMP/stress.out" 2>> "$TMP/stress.log"; then
+	echo "FAIL stress (run)"
+	exit 1
+fi
+
+# fmt.Println in the runtime adds a space after each value, so ignore whitespace differences
+if ! diff -b "$TMP/stress.expected" "$TMP/stress.out"; then
+	echo "FAIL stress (output)"
+	exit 1
+fi
+
+echo "PASS stress ($STATES states)"
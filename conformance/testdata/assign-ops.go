@@ -0,0 +1,88 @@
+package main
+
+import "fmt"
+
+// The assignment operators follow the Go rules: &^= clears the bits, the shifts by the size
+// of the type or more give 0 (or -1 for a negative value shifted right) and the overflow wraps.
+
+var calls int
+
+// next returns the next index, counting the calls
+func next() int {
+	calls++
+	return calls - 1
+}
+
+func main() {
+	var flags uint32 = 0xff
+	flags &^= 0x0f | 0x30
+	fmt.Println(flags)
+
+	var n uint = 4
+	flags <<= n
+	fmt.Println(flags)
+	flags <<= n * 8
+	fmt.Println(flags)
+
+	var x int32 = -5
+	x >>= n
+	fmt.Println(x)
+	x >>= n * 10
+	fmt.Println(x)
+
+	x = 1
+	x <<= 31
+	fmt.Println(x)
+	x <<= n
+	fmt.Println(x)
+
+	var y int16 = 32767
+	y += 1
+	fmt.Println(y)
+	y -= 1
+	fmt.Println(y)
+	y *= 2
+	fmt.Println(y)
+
+	var i32 int32 = 2147483647
+	i32 += 1
+	fmt.Println(i32)
+	i32 -= 1
+	fmt.Println(i32)
+	i32 *= 3
+	fmt.Println(i32)
+
+	var i64 int64 = -9223372036854775808
+	i64 -= 1
+	fmt.Println(i64)
+	i64 += 1
+	fmt.Println(i64)
+	i64 *= -1
+	fmt.Println(i64)
+
+	var u16 uint16 = 65535
+	u16 *= u16
+	fmt.Println(u16)
+
+	total := 1.5
+	total += 2
+	total *= 3
+	fmt.Println(total)
+
+	var xs [3]int
+	xs[next()] += 5
+	xs[next()] <<= n
+	fmt.Println(xs[0], xs[1], xs[2], calls)
+
+	var s string = "go"
+	s += "lang"
+	fmt.Println(s)
+
+	z := 100
+	z /= 7
+	z %= 5
+	z |= 8
+	z ^= 3
+	z &= 0x1e
+	fmt.Println(z, z<<n, z>>n, z&^2)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// A closure assigned to a variable shares the variables of the function,
+// and the closures started by go use the local variables.
+
+func main() {
+	count := 0
+	add := func(n int) int {
+		count += n
+		return count
+	}
+
+	add(2)
+	total := add(3)
+	fmt.Println(total, count)
+
+	x := 1
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		fmt.Println("goroutine", x)
+		wg.Done()
+	}()
+	wg.Wait()
+
+	wg.Add(1)
+	go func(n int) {
+		fmt.Println("argument", n, x)
+		wg.Done()
+	}(x + 1)
+	wg.Wait()
+}
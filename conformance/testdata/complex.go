@@ -0,0 +1,18 @@
+package main
+
+import "fmt"
+
+// complex of untyped integer constants is a complex128, the other arguments select the type.
+
+func main() {
+	c := complex(1, 2)
+	fmt.Println(c, real(c), imag(c))
+
+	var f float32 = 1.5
+	d := complex(f, 2)
+	fmt.Println(d)
+
+	e := complex(0.5, 3)
+	fmt.Println(e * 2)
+	fmt.Printf("%T %T %T\n", c, d, e)
+}
@@ -0,0 +1,21 @@
+package main
+
+import "fmt"
+
+const s string = "constant"
+
+const (
+	A = iota
+	B
+	C
+)
+
+func main() {
+	fmt.Println(s)
+
+	const n = 500000000
+	const d = 3e20 / n
+	fmt.Println(d)
+
+	fmt.Println(A, B, C)
+}
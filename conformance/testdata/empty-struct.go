@@ -0,0 +1,32 @@
+package main
+
+import "fmt"
+
+// The empty struct (struct{}) is the value of the signals and of the sets:
+// all its values are equal and they are printed as {}.
+
+type token struct{}
+
+func (t token) name() string {
+	return "token"
+}
+
+func signal() struct{} {
+	return struct{}{}
+}
+
+func main() {
+	var s struct{}
+	fmt.Println(s, signal(), token{})
+
+	if signal() == s {
+		fmt.Println("equal")
+	}
+
+	var t token
+	if t == (token{}) {
+		fmt.Println("equal tokens", t.name())
+	}
+
+	fmt.Printf("%v %T\n", struct{}{}, s)
+}
@@ -0,0 +1,49 @@
+package main
+
+import "fmt"
+
+// Perm is a set of permissions
+type Perm uint8
+
+// The permissions, one bit each
+const (
+	Read Perm = 1 << iota
+	Write
+	_
+	Exec
+)
+
+type file struct {
+	name string
+	perm Perm
+}
+
+func has(p, q Perm) int {
+	if (p & q) == q {
+		return 1
+	}
+	return 0
+}
+
+func main() {
+	f := file{"notes.txt", Read}
+	f.perm |= Write | Exec
+	fmt.Println(f.name, f.perm, has(f.perm, Write), has(f.perm, Read|Exec))
+
+	p := f.perm
+	p &^= Write
+	p ^= Read
+	fmt.Println(p, has(p, Write), p&Exec, Read|Write|Exec)
+	fmt.Println(Perm(3)^Read, p|1)
+
+	var r Perm = 2
+	r |= 4
+	r &^= 2
+	r ^= 1
+	fmt.Println(r)
+
+	p = 0
+	p |= Read
+	f.perm = 6
+	fmt.Println(p, f.perm, has(f.perm, Exec))
+}
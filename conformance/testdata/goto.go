@@ -0,0 +1,119 @@
+package main
+
+import "fmt"
+
+// The scanners generated by tools (ragel, re2go) use a label for each state and goto between the states,
+// and the parsers (goyacc) switch on large state tables.
+
+const (
+	classSpace = iota
+	classDigit
+	classLetter
+	classOther
+)
+
+func class(c byte) int {
+	switch c {
+	case ' ', '\t', '\n':
+		return classSpace
+	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return classDigit
+	}
+
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' {
+		return classLetter
+	}
+
+	return classOther
+}
+
+func scan(data string) {
+	p := 0
+	ts := 0
+	n := len(data)
+
+st_start:
+	if p == n {
+		goto st_eof
+	}
+	ts = p
+	switch class(data[p]) {
+	case classSpace:
+		p++
+		goto st_start
+	case classDigit:
+		p++
+		goto st_number
+	case classLetter:
+		p++
+		goto st_ident
+	}
+	p++
+	fmt.Println("OP", data[ts:p])
+	goto st_start
+
+st_number:
+	if p < n && class(data[p]) == classDigit {
+		p++
+		goto st_number
+	}
+	fmt.Println("NUM", data[ts:p])
+	goto st_start
+
+st_ident:
+	if p < n && class(data[p]) != classSpace && class(data[p]) != classOther {
+		p++
+		goto st_ident
+	}
+	fmt.Println("ID", data[ts:p])
+	goto st_start
+
+st_eof:
+	fmt.Println("EOF")
+}
+
+// next is the transition table of a state machine recognizing identifiers and numbers (-1 is an error)
+func next(state, class int) int {
+	switch state*4 + class {
+	case 0*4 + classSpace:
+		return 0
+	case 0*4 + classDigit:
+		return 1
+	case 0*4 + classLetter:
+		return 2
+	case 1*4 + classDigit:
+		return 1
+	case 1*4 + classSpace:
+		return 0
+	case 2*4 + classDigit, 2*4 + classLetter:
+		return 2
+	case 2*4 + classSpace:
+		return 0
+	}
+	return -1
+}
+
+func accepts(data string) bool {
+	state := 0
+	for i := 0; i < len(data); i++ {
+		state = next(state, class(data[i]))
+		if state < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func check(data string) {
+	if accepts(data) {
+		fmt.Println("accepted", data)
+	} else {
+		fmt.Println("rejected", data)
+	}
+}
+
+func main() {
+	scan("x1 = 42 + y_2")
+	check("abc 123 d4")
+	check("4d")
+}
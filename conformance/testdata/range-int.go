@@ -0,0 +1,17 @@
+package main
+
+import "fmt"
+
+func main() {
+	for i := range 3 {
+		fmt.Println(i)
+	}
+
+	n := 2
+	for range n {
+		fmt.Println("tick")
+	}
+
+	fmt.Println(min(3, 1, 2))
+	fmt.Println(max(2, 7))
+}
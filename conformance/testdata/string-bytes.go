@@ -0,0 +1,34 @@
+package main
+
+import "fmt"
+
+// Indexing a string gives a byte (uint8), compared and printed as a number.
+
+type file struct {
+	name string
+	size int
+}
+
+func digits(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			n = n*10 + int(s[i]-'0')
+		}
+	}
+	return n
+}
+
+func main() {
+	var s string = "h\xc3\xa9llo"
+	var t string = "abc"
+	f := file{"x\xff", 1}
+	fmt.Println(s[0], s[1], t[2], f.name[1], "lit"[0], (s + t)[6])
+	if s[1] > 127 {
+		fmt.Println("high")
+	}
+	fmt.Printf("%c%c %d\n", s[0], t[1], s[1])
+	fmt.Println(digits("a1b2c3"))
+	var b byte = 200
+	fmt.Println(b, int8(-3))
+}
@@ -0,0 +1,16 @@
+package main
+
+import "fmt"
+
+type person struct {
+	name string
+	age  int
+}
+
+func main() {
+	p := person{name: "Sean", age: 50}
+	fmt.Println(p.name)
+
+	p.age = 51
+	fmt.Println(p.age)
+}
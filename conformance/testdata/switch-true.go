@@ -0,0 +1,44 @@
+package main
+
+import "fmt"
+
+// A switch without tag tests the cases in order, the default last (wherever it is),
+// and a case can list several conditions.
+
+func grade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	default:
+		return "F"
+	case score >= 80, score == 75:
+		return "B"
+	case score >= 60:
+		return "C"
+	}
+}
+
+func size(x int) int {
+	switch y := x * 2; true {
+	case y > 10:
+		return 3
+	case y > 4:
+		return 2
+	}
+	return 1
+}
+
+func main() {
+	fmt.Println(grade(95), grade(85), grade(75), grade(65), grade(10))
+
+	for i := 0; i < 10; i++ {
+		switch {
+		case i%2 == 0:
+			continue
+		case i > 6:
+			break
+		default:
+			fmt.Println("odd", i, size(i))
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import "fmt"
+
+// The comma-ok type assertion gives the zero value and false when the
+// value has another type, instead of panicking.
+
+func describe(v interface{}) string {
+	if n, ok := v.(int); ok {
+		return fmt.Sprintf("int %d", n)
+	}
+
+	if s, ok := v.(string); ok {
+		return "string " + s
+	}
+
+	return "other"
+}
+
+func main() {
+	fmt.Println(describe(3), describe("go"), describe(1.5))
+
+	var v interface{} = "text"
+	var n int
+	var ok bool
+	n, ok = v.(int)
+	if !ok {
+		fmt.Println("not an int", n)
+	}
+
+	s, found := v.(string)
+	if found {
+		fmt.Println("a string", s)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import "fmt"
+
+// %T of an interface value prints the Go type of the value it contains.
+
+type Celsius float64
+
+func (c Celsius) Fahrenheit() float64 {
+	return float64(c)*9/5 + 32
+}
+
+type Point struct {
+	X, Y int
+}
+
+func show(v interface{}) {
+	fmt.Printf("%T\n", v)
+}
+
+func main() {
+	var v interface{} = "text"
+	fmt.Printf("%T\n", v)
+
+	v = 3
+	fmt.Printf("%T\n", v)
+
+	show(Celsius(20))
+	show(Point{1, 2})
+	show(2.5)
+
+	var e interface{}
+	fmt.Printf("%T\n", e)
+}
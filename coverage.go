@@ -0,0 +1,146 @@
+package main
+
+//
+// "transpilation coverage" report of the converted files (--coverage)
+//
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/raff/walkngo/walker"
+)
+
+//
+// Coverage collects the coverage of the converted files, to plan the porting work
+//
+type Coverage []*walkngo.FileCoverage
+
+//
+// Totals returns the number of lines of all the files, by status
+//
+func (c Coverage) Totals() map[string]int {
+	totals := map[string]int{}
+
+	for _, f := range c {
+		for status, n := range f.Lines {
+			totals[status] += n
+		}
+	}
+
+	return totals
+}
+
+//
+// WriteFile writes the report as HTML (for a .html or .htm filename), with the source of each file
+// colored by status, or as JSON ({"totals": {"translated": 120, ...}, "files": [{"file": "foo.go", ...}]})
+//
+func (c Coverage) WriteFile(filename string) error {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".html", ".htm":
+		return os.WriteFile(filename, c.html(), 0644)
+	}
+
+	report := struct {
+		Totals map[string]int          `json:"totals"`
+		Files  []*walkngo.FileCoverage `json:"files"`
+	}{c.Totals(), c}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// background colors of the lines in the HTML report, by status
+var coverageColors = map[string]string{
+	walkngo.COVERAGE_TRANSLATED: "#d7f5d7",
+	walkngo.COVERAGE_PARTIAL:    "#fff3c4",
+	walkngo.COVERAGE_SKIPPED:    "#ffd6d6",
+}
+
+var coverageStatuses = []string{walkngo.COVERAGE_TRANSLATED, walkngo.COVERAGE_PARTIAL, walkngo.COVERAGE_SKIPPED}
+
+//
+// html returns the HTML report: the totals and, for each file, its source with the lines colored by status
+// (the constructs not converted are listed in the title of the lines)
+//
+func (c Coverage) html() []byte {
+	var b bytes.Buffer
+
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>walkngo coverage</title>\n<style>\n")
+	b.WriteString("body { font-family: sans-serif; }\ntable { border-collapse: collapse; }\n")
+	b.WriteString("td { font-family: monospace; white-space: pre; padding: 0 8px; }\ntd.n { color: #888; text-align: right; }\n")
+	for _, status := range coverageStatuses {
+		fmt.Fprintf(&b, ".%s { background: %s; }\n", status, coverageColors[status])
+	}
+	b.WriteString("</style>\n</head>\n<body>\n<h1>walkngo coverage</h1>\n")
+
+	b.WriteString(coverageLegend(c.Totals()))
+
+	for _, f := range c {
+		src, err := os.ReadFile(f.File)
+		if err != nil {
+			continue
+		}
+
+		// constructs not converted, by line
+		notes := map[int][]string{}
+		for _, r := range f.Ranges {
+			if len(r.Constructs) > 0 {
+				notes[r.StartLine] = append(notes[r.StartLine], r.Constructs...)
+			}
+		}
+
+		fmt.Fprintf(&b, "<h2>%s</h2>\n", html.EscapeString(f.File))
+		b.WriteString(coverageLegend(f.Lines))
+		b.WriteString("<table>\n")
+
+		for i, line := range strings.Split(strings.TrimSuffix(string(src), "\n"), "\n") {
+			status := f.Status(i + 1)
+
+			b.WriteString("<tr")
+			if len(status) > 0 {
+				fmt.Fprintf(&b, " class=%q", status)
+			}
+			if n := notes[i+1]; len(n) > 0 {
+				fmt.Fprintf(&b, " title=%q", html.EscapeString(strings.Join(n, ", ")))
+			}
+			fmt.Fprintf(&b, "><td class=\"n\">%d</td><td>%s</td></tr>\n", i+1, html.EscapeString(line))
+		}
+
+		b.WriteString("</table>\n")
+	}
+
+	b.WriteString("</body>\n</html>\n")
+	return b.Bytes()
+}
+
+//
+// coverageLegend returns the number of lines by status, as a colored HTML paragraph
+//
+func coverageLegend(lines map[string]int) string {
+	total := 0
+	for _, n := range lines {
+		total += n
+	}
+
+	parts := make([]string, 0, len(coverageStatuses))
+	for _, status := range coverageStatuses {
+		pct := 0.0
+		if total > 0 {
+			pct = 100 * float64(lines[status]) / float64(total)
+		}
+
+		parts = append(parts, fmt.Sprintf("<span class=%q>%s: %d lines (%.1f%%)</span>", status, status, lines[status], pct))
+	}
+
+	return "<p>" + strings.Join(parts, " ") + "</p>\n"
+}
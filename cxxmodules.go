@@ -0,0 +1,59 @@
+package main
+
+//
+// Primary module interface units for the C++20 modules output (--cxx-modules)
+//
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/raff/walkngo/printer"
+)
+
+//
+// ModuleUnits collects the module partitions (one for each converted file) by output folder
+//
+type ModuleUnits map[string]*moduleUnit
+
+type moduleUnit struct {
+	name       string
+	partitions []string
+}
+
+//
+// Add records the partition for a converted file (package main is not a module)
+//
+func (m ModuleUnits) Add(outpath, namespace, source string) {
+	if len(namespace) == 0 {
+		return
+	}
+
+	dir := filepath.Dir(outpath)
+
+	unit, ok := m[dir]
+	if !ok {
+		unit = &moduleUnit{name: namespace}
+		m[dir] = unit
+	}
+
+	unit.partitions = append(unit.partitions, printer.ModulePartition(source))
+}
+
+//
+// Write writes the primary module interface ({module}.cppm) in each output folder
+//
+func (m ModuleUnits) Write() error {
+	for dir, unit := range m {
+		sort.Strings(unit.partitions)
+
+		filename := filepath.Join(dir, unit.name+".cppm")
+		if err := os.WriteFile(filename, []byte(printer.ModuleInterface(unit.name, unit.partitions)), 0644); err != nil {
+			return fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+
+	return nil
+}
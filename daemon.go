@@ -0,0 +1,301 @@
+package main
+
+//
+// The "daemon" command: a JSON-RPC service to convert Go code, so that build systems and editor plugins
+// can keep a process running instead of starting walkngo for each file
+//
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/raff/walkngo/walker"
+)
+
+// name of the RPC service (the methods are Walkngo.Convert, Walkngo.ConvertPackage, Walkngo.ConvertRange and Walkngo.Capabilities)
+const RPC_SERVICE = "Walkngo"
+
+// target languages, as accepted by --lang
+var targets = []string{"c", "go", "rust", "swift"}
+
+//
+// FileRequest are the parameters of Walkngo.Convert: a Go file (Filename, read by the daemon) or its content (Source,
+// converted as if read from Filename), or a snippet as for the expr command, and the target language (default --lang)
+//
+type FileRequest struct {
+	Filename string `json:"filename"`
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+}
+
+//
+// PackageRequest are the parameters of Walkngo.ConvertPackage: a folder with the Go files to convert
+// and the target language (default --lang)
+//
+type PackageRequest struct {
+	Dir    string `json:"dir"`
+	Target string `json:"target"`
+}
+
+//
+// RangeRequest are the parameters of Walkngo.ConvertRange: a Go file (Filename, read by the daemon, or its content in Source),
+// the range (or the cursor) in the file and the target language (default --lang)
+//
+type RangeRequest struct {
+	Filename string        `json:"filename"`
+	Source   string        `json:"source"`
+	Range    walkngo.Range `json:"range"`
+	Target   string        `json:"target"`
+}
+
+//
+// FileResponse is a converted file: its name, the converted code and the problems found
+//
+type FileResponse struct {
+	Filename    string               `json:"filename,omitempty"`
+	Output      string               `json:"output"`
+	Diagnostics []walkngo.Diagnostic `json:"diagnostics"`
+}
+
+//
+// PackageResponse is the result of Walkngo.ConvertPackage: the files converted, sorted by name
+//
+type PackageResponse struct {
+	Files []FileResponse `json:"files"`
+}
+
+//
+// Capabilities is the result of Walkngo.Capabilities: what the daemon can do
+//
+type Capabilities struct {
+	Version string   `json:"version"`
+	Targets []string `json:"targets"`
+	Methods []string `json:"methods"`
+}
+
+//
+// ConvertService implements the RPC methods (newWalker returns a walker, configured as for the command line,
+// for a target language)
+//
+type ConvertService struct {
+	newWalker func(target string) (*walkngo.GoWalker, error)
+	target    string // default target
+}
+
+//
+// Convert converts a Go file or snippet
+//
+func (s *ConvertService) Convert(req FileRequest, resp *FileResponse) (err error) {
+	defer recoverRequest(&err)
+
+	w, err := s.walker(req.Target)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(req.Source) > 0 && len(req.Filename) == 0:
+		output, err := w.Convert(req.Source)
+		*resp = FileResponse{Output: output, Diagnostics: walkngo.Problems(err)}
+
+	case len(req.Filename) > 0:
+		src := []byte(req.Source)
+		if len(src) == 0 {
+			if src, err = os.ReadFile(req.Filename); err != nil {
+				return err
+			}
+		}
+
+		*resp = convertFile(w, req.Filename, src)
+
+	default:
+		return fmt.Errorf("missing filename or source")
+	}
+
+	return nil
+}
+
+//
+// ConvertPackage converts the Go files in a folder (not in the subfolders)
+//
+func (s *ConvertService) ConvertPackage(req PackageRequest, resp *PackageResponse) (err error) {
+	defer recoverRequest(&err)
+
+	w, err := s.walker(req.Target)
+	if err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(req.Dir, "*.go"))
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		return fmt.Errorf("no Go files in %s", req.Dir)
+	}
+
+	sort.Strings(files)
+
+	resp.Files = []FileResponse{}
+
+	for _, f := range files {
+		src, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+
+		resp.Files = append(resp.Files, convertFile(w, f, src))
+	}
+
+	return nil
+}
+
+//
+// ConvertRange converts the top level declaration containing a range of a Go file, for the editors
+// (i.e. to show the translation of the function at the cursor)
+//
+func (s *ConvertService) ConvertRange(req RangeRequest, resp *FileResponse) (err error) {
+	defer recoverRequest(&err)
+
+	w, err := s.walker(req.Target)
+	if err != nil {
+		return err
+	}
+
+	if len(req.Filename) == 0 {
+		req.Filename = "main.go"
+	}
+
+	src := []byte(req.Source)
+	if len(src) == 0 {
+		if src, err = os.ReadFile(req.Filename); err != nil {
+			return err
+		}
+	}
+
+	var out bytes.Buffer
+
+	old := w.SetWriter(&out)
+	err = w.WalkRange(req.Filename, src, req.Range)
+	w.SetWriter(old)
+
+	if _, ok := err.(walkngo.WalkErrors); err != nil && !ok {
+		return err // no declaration in the range, or the file can't be parsed
+	}
+
+	*resp = FileResponse{Filename: req.Filename, Output: strings.Trim(out.String(), "\n"), Diagnostics: walkngo.Problems(err)}
+	return nil
+}
+
+//
+// Capabilities returns the version, the target languages and the methods of the service
+//
+func (s *ConvertService) Capabilities(_ struct{}, resp *Capabilities) error {
+	*resp = Capabilities{
+		Version: version,
+		Targets: targets,
+		Methods: []string{RPC_SERVICE + ".Convert", RPC_SERVICE + ".ConvertPackage", RPC_SERVICE + ".ConvertRange",
+			RPC_SERVICE + ".Capabilities"},
+	}
+
+	return nil
+}
+
+func (s *ConvertService) walker(target string) (*walkngo.GoWalker, error) {
+	if len(target) == 0 {
+		target = s.target
+	}
+
+	return s.newWalker(target)
+}
+
+//
+// recoverRequest returns the panic of a request as its error (net/rpc doesn't recover the panics of the methods),
+// so that an input the converter can't handle doesn't stop the daemon
+//
+func recoverRequest(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("internal error: %v", r)
+	}
+}
+
+//
+// convertFile converts the Go source src, read from filename
+//
+func convertFile(w *walkngo.GoWalker, filename string, src []byte) FileResponse {
+	var out bytes.Buffer
+
+	old := w.SetWriter(&out)
+	err := w.WalkSource(filename, src)
+	w.SetWriter(old)
+
+	return FileResponse{Filename: filename, Output: strings.Trim(out.String(), "\n"), Diagnostics: walkngo.Problems(err)}
+}
+
+//
+// stdio is the connection of a daemon started without an address
+//
+type stdio struct {
+	io.Reader
+	io.Writer
+}
+
+func (stdio) Close() error {
+	return nil
+}
+
+//
+// daemonCommand implements "walkngo [flags] daemon [address]": it serves JSON-RPC requests (one JSON object for each request,
+// as for net/rpc/jsonrpc: {"method": "Walkngo.Convert", "params": [{...}], "id": 1}) on stdin and stdout or, with an address,
+// on the connections to a TCP address (host:port) or a unix socket (unix:path)
+//
+func daemonCommand(newWalker func(target string) (*walkngo.GoWalker, error), target string, args []string) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName(RPC_SERVICE, &ConvertService{newWalker: newWalker, target: target}); err != nil {
+		return err
+	}
+
+	switch len(args) {
+	case 0:
+		server.ServeCodec(jsonrpc.NewServerCodec(stdio{os.Stdin, os.Stdout}))
+		return nil
+
+	case 1:
+
+	default:
+		return fmt.Errorf("usage: walkngo [flags] daemon [host:port|unix:path]")
+	}
+
+	network, addr := "tcp", args[0]
+	if strings.HasPrefix(addr, "unix:") {
+		network, addr = "unix", strings.TrimPrefix(addr, "unix:")
+		if fi, err := os.Lstat(addr); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			os.Remove(addr) // left by a previous run
+		}
+	}
+
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "serving JSON-RPC on %s %s\n", network, addr)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/raff/walkngo/printer"
+	"github.com/raff/walkngo/walker"
+)
+
+//
+// failingPrinter panics on any call, as a printer that can't handle its input
+//
+type failingPrinter struct {
+	printer.Printer // nil
+}
+
+//
+// TestDaemonRecover checks that a request that makes the converter panic returns an error,
+// instead of stopping the daemon
+//
+func TestDaemonRecover(t *testing.T) {
+	s := &ConvertService{target: "c", newWalker: func(target string) (*walkngo.GoWalker, error) {
+		return walkngo.NewWalker(failingPrinter{}, io.Discard, false), nil
+	}}
+
+	var resp FileResponse
+	err := s.Convert(FileRequest{Source: "package p\n\nfunc f() {}\n"}, &resp)
+	if err == nil || !strings.HasPrefix(err.Error(), "internal error:") {
+		t.Errorf("Convert: expected an internal error, got %v", err)
+	}
+
+	err = s.ConvertRange(RangeRequest{Source: "package p\n\nfunc f() {}\n"}, &resp)
+	if err == nil || !strings.HasPrefix(err.Error(), "internal error:") {
+		t.Errorf("ConvertRange: expected an internal error, got %v", err)
+	}
+
+	var presp PackageResponse
+	err = s.ConvertPackage(PackageRequest{Dir: "conformance/testdata"}, &presp)
+	if err == nil || !strings.HasPrefix(err.Error(), "internal error:") {
+		t.Errorf("ConvertPackage: expected an internal error, got %v", err)
+	}
+
+	// the daemon still converts the next requests
+	s.newWalker = func(target string) (*walkngo.GoWalker, error) {
+		return walkngo.NewWalker(&printer.CPrinter{}, io.Discard, false), nil
+	}
+
+	if err := s.Convert(FileRequest{Source: "package p\n\ntype T int\n\nfunc (T) M() {}\n"}, &resp); err != nil {
+		t.Errorf("Convert: %v", err)
+	} else if !strings.Contains(resp.Output, "T::M()") {
+		t.Errorf("Convert: unexpected output %q", resp.Output)
+	}
+}
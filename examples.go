@@ -0,0 +1,109 @@
+package main
+
+//
+// Runnable programs for the testable examples of the converted tests (func ExampleXxx with an "// Output:" comment)
+//
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/raff/walkngo/walker"
+)
+
+//
+// ExampleMains collects the examples of the converted files by output folder, to write a main for each one
+// in the examples folder (see go_testing::example)
+//
+type ExampleMains map[string]*exampleFolder
+
+type exampleFolder struct {
+	namespace string
+	examples  []walkngo.Example
+}
+
+//
+// Add records the examples of a converted file (the examples of package main can't be linked to another main)
+//
+func (m ExampleMains) Add(outpath, namespace string, examples []walkngo.Example) {
+	if len(namespace) == 0 || len(examples) == 0 {
+		return
+	}
+
+	dir := filepath.Dir(outpath)
+
+	folder, ok := m[dir]
+	if !ok {
+		folder = &exampleFolder{namespace: namespace}
+		m[dir] = folder
+	}
+
+	folder.examples = append(folder.examples, examples...)
+}
+
+//
+// Write writes examples/{ExampleXxx}.cc in each output folder, running the example and checking its output
+//
+func (m ExampleMains) Write() error {
+	for dir, folder := range m {
+		if err := os.MkdirAll(filepath.Join(dir, "examples"), 0755); err != nil {
+			return err
+		}
+
+		for _, ex := range folder.examples {
+			filename := filepath.Join(dir, "examples", ex.Func+".cc")
+			if err := os.WriteFile(filename, []byte(exampleMain(folder.namespace, ex)), 0644); err != nil {
+				return fmt.Errorf("%s: %v", filename, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+//
+// Funcs returns the names of the examples in an output folder, sorted
+//
+func (m ExampleMains) Funcs(dir string) []string {
+	folder, ok := m[dir]
+	if !ok {
+		return nil
+	}
+
+	funcs := make([]string, 0, len(folder.examples))
+	for _, ex := range folder.examples {
+		funcs = append(funcs, ex.Func)
+	}
+
+	sort.Strings(funcs)
+	return funcs
+}
+
+//
+// exampleMain returns the main for an example
+//
+func exampleMain(namespace string, ex walkngo.Example) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "// generated by walkngo: runs %s.%s and checks its output\n", namespace, ex.Func)
+	b.WriteString("#include <go.h>\n#include <go_testing.h>\n\n")
+	fmt.Fprintf(&b, "namespace %s { void %s(); }\n\n", namespace, ex.Func)
+	b.WriteString("int main(int argc, char **argv) {\n")
+	fmt.Fprintf(&b, "    return go_testing::example(%q, %s::%s, %s, %v);\n", ex.Func, namespace, ex.Func, cppString(ex.Output), ex.Unordered)
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// escapes for a C++ string literal
+var cppEscapes = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "?", `\?`)
+
+//
+// cppString returns s as a C++ string literal (the ? is escaped to avoid trigraphs)
+//
+func cppString(s string) string {
+	return `"` + cppEscapes.Replace(s) + `"`
+}
@@ -0,0 +1,42 @@
+package main
+
+//
+// The "expr" command, to check how a snippet of Go code is converted
+//
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/raff/walkngo/walker"
+)
+
+//
+// exprCommand implements "walkngo [flags] expr 'code...'": it converts a snippet of Go code
+// (statements or declarations, see WalkSnippet) and prints only its translation.
+// Each argument is a line of the snippet
+//
+func exprCommand(w *walkngo.GoWalker, diag *walkngo.Diagnostics, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: walkngo [flags] expr 'code...'")
+	}
+
+	var out bytes.Buffer
+
+	old := w.SetWriter(&out)
+	err := w.WalkSnippet(strings.Join(args, "\n"))
+	w.SetWriter(old)
+
+	if _, ok := err.(walkngo.WalkErrors); err != nil && !ok {
+		return err
+	}
+
+	fmt.Println(strings.Trim(out.String(), "\n"))
+
+	if err != nil {
+		diag.PrintError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+//
+// report of the external symbols required by the converted files (--externals)
+//
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+)
+
+//
+// Externals collects the symbols referenced by the converted files and defined elsewhere
+// (import path -> name -> files referencing it), the functions that the runtime or the shims must provide
+//
+type Externals map[string]map[string][]string
+
+//
+// Add records the external symbols of a converted file (see GoWalker.Externals)
+//
+func (e Externals) Add(source string, externals map[string][]string) {
+	for ipath, names := range externals {
+		if e[ipath] == nil {
+			e[ipath] = map[string][]string{}
+		}
+
+		for _, name := range names {
+			e[ipath][name] = appendUnique(e[ipath][name], source)
+		}
+	}
+}
+
+//
+// WriteFile writes the report as JSON ({"fmt": {"Println": ["a.go", "b.go"]}, "builtin": {"len": ["a.go"]}})
+//
+func (e Externals) WriteFile(filename string) error {
+	for _, names := range e {
+		for _, files := range names {
+			sort.Strings(files)
+		}
+	}
+
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
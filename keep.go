@@ -0,0 +1,212 @@
+package main
+
+//
+// Hand-edited regions of the generated files, preserved when the files are generated again:
+//
+//	// walkngo:keep-begin name
+//	... hand-written code ...
+//	// walkngo:keep-end
+//
+// The markers are comments in the Go source (kept in the output), and the code between them in the existing
+// output file replaces the generated one. The regions are matched by name (or by position, for unnamed regions)
+//
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	KEEP_BEGIN = "walkngo:keep-begin"
+	KEEP_END   = "walkngo:keep-end"
+)
+
+//
+// KeepRegions are the hand-edited regions of a generated file, by name
+//
+type KeepRegions struct {
+	names   []string            // in file order
+	regions map[string][]string // lines between the markers
+}
+
+//
+// keepMarker returns the marker (KEEP_BEGIN or KEEP_END) in line and the name of the region, if line is a marker
+//
+func keepMarker(line string) (marker, name string) {
+	text, ok := strings.CutPrefix(strings.TrimSpace(line), "//")
+	if !ok {
+		return "", ""
+	}
+
+	text = strings.TrimSpace(text)
+
+	for _, m := range []string{KEEP_BEGIN, KEEP_END} {
+		if rest, ok := strings.CutPrefix(text, m); ok && (len(rest) == 0 || rest[0] == ' ' || rest[0] == '\t') {
+			return m, strings.TrimSpace(rest)
+		}
+	}
+
+	return "", ""
+}
+
+//
+// parseKeepRegions returns the regions in the lines of a file, and an error if the markers are not balanced
+//
+func parseKeepRegions(filename string, lines []string) (*KeepRegions, error) {
+	k := &KeepRegions{regions: map[string][]string{}}
+
+	var current string // the region being collected
+	inside, unnamed := false, 0
+
+	for i, line := range lines {
+		marker, name := keepMarker(line)
+
+		switch marker {
+		case KEEP_BEGIN:
+			if inside {
+				return nil, fmt.Errorf("%s:%d: %s inside region %q", filename, i+1, KEEP_BEGIN, current)
+			}
+
+			if len(name) == 0 {
+				unnamed++
+				name = fmt.Sprintf("#%d", unnamed)
+			}
+
+			if _, ok := k.regions[name]; ok {
+				return nil, fmt.Errorf("%s:%d: duplicate region %q", filename, i+1, name)
+			}
+
+			current, inside = name, true
+			k.names = append(k.names, name)
+			k.regions[name] = []string{}
+
+		case KEEP_END:
+			if !inside {
+				return nil, fmt.Errorf("%s:%d: %s without %s", filename, i+1, KEEP_END, KEEP_BEGIN)
+			}
+
+			inside = false
+
+		default:
+			if inside {
+				k.regions[current] = append(k.regions[current], line)
+			}
+		}
+	}
+
+	if inside {
+		return nil, fmt.Errorf("%s: region %q without %s", filename, current, KEEP_END)
+	}
+
+	return k, nil
+}
+
+//
+// readKeepRegions returns the hand-edited regions of an existing output file (nil if the file doesn't exist
+// or doesn't have regions)
+//
+func readKeepRegions(filename string) (*KeepRegions, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := parseKeepRegions(filename, strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"))
+	if err != nil || len(k.names) == 0 {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+//
+// Merge replaces the content of the regions in the generated lines with the hand-edited one.
+// The regions that are not in the generated code are appended at the end, so that they are not lost,
+// and their names are returned
+//
+func (k *KeepRegions) Merge(lines []string) (merged []string, missing []string) {
+	found := map[string]bool{}
+	skip, unnamed := false, 0
+
+	for _, line := range lines {
+		marker, name := keepMarker(line)
+
+		switch {
+		case marker == KEEP_BEGIN:
+			if len(name) == 0 {
+				unnamed++
+				name = fmt.Sprintf("#%d", unnamed)
+			}
+
+			merged = append(merged, line)
+
+			if kept, ok := k.regions[name]; ok && !found[name] {
+				found[name], skip = true, true
+				merged = append(merged, kept...)
+			}
+
+		case marker == KEEP_END:
+			skip = false
+			merged = append(merged, line)
+
+		case !skip:
+			merged = append(merged, line)
+		}
+	}
+
+	for _, name := range k.names {
+		if found[name] {
+			continue
+		}
+
+		missing = append(missing, name)
+
+		begin := "// " + KEEP_BEGIN
+		if !strings.HasPrefix(name, "#") {
+			begin += " " + name
+		}
+
+		merged = append(merged, begin)
+		merged = append(merged, k.regions[name]...)
+		merged = append(merged, "// "+KEEP_END)
+	}
+
+	return
+}
+
+//
+// mergeKeepRegions rewrites the output files that had hand-edited regions, once all the sources are converted
+// (with --output-name more sources can go in the same file)
+//
+func (w Walker) mergeKeepRegions() {
+	for outpath, k := range w.keeps {
+		if err := mergeKeepFile(outpath, k, w.newline); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+//
+// mergeKeepFile rewrites the generated file with the hand-edited regions of the previous version
+// (with newline as line terminator)
+//
+func mergeKeepFile(filename string, k *KeepRegions, newline string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	content := strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	merged, missing := k.Merge(strings.Split(content, "\n"))
+
+	for _, name := range missing {
+		fmt.Fprintf(os.Stderr, "%s: region %q not found in the generated code, moved to the end of the file\n", filename, name)
+	}
+
+	return os.WriteFile(filename, []byte(strings.Join(merged, newline)+newline), 0644)
+}
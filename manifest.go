@@ -0,0 +1,137 @@
+package main
+
+//
+// Generation of dependency manager manifests (conan, vcpkg) for the converted C++ code
+//
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/raff/walkngo/printer"
+)
+
+//
+// ImportSet collects the import paths used by the converted files
+//
+type ImportSet map[string]bool
+
+// the runtime package, see runtime/c/conanfile.py and runtime/c/vcpkg
+var runtimePackages = map[string]string{
+	"conan": "walkngo/0.1",
+	"vcpkg": "walkngo",
+}
+
+//
+// manifestDeps returns the packages for the dependency manager required by the imports:
+// the runtime and the packages listed in the import mappings
+//
+func manifestDeps(manager string, imports ImportSet, importMap printer.ImportMap) []string {
+	deps := map[string]bool{}
+
+	for ipath := range imports {
+		if p, ok := importMap[ipath].Packages[manager]; ok {
+			deps[p] = true
+		}
+	}
+
+	list := make([]string, 0, len(deps)+1)
+	for d := range deps {
+		list = append(list, d)
+	}
+
+	sort.Strings(list)
+	return append([]string{runtimePackages[manager]}, list...)
+}
+
+//
+// writeManifest writes conanfile.py or vcpkg.json, listing the dependencies of the converted code
+// and the hand-written overrides, in outdir
+//
+func writeManifest(manager, outdir string, imports ImportSet, importMap printer.ImportMap, overrides Overrides) error {
+	var buf bytes.Buffer
+	var filename string
+
+	deps := manifestDeps(manager, imports, importMap)
+
+	switch manager {
+	case "conan":
+		filename = "conanfile.py"
+
+		fmt.Fprintln(&buf, "# generated by walkngo")
+		if len(overrides) > 0 {
+			fmt.Fprintln(&buf, "#")
+			fmt.Fprintln(&buf, "# hand-written files (not converted):")
+			for _, out := range overrides.Files() {
+				fmt.Fprintf(&buf, "#   %s <- %s\n", out, overrides[out])
+			}
+		}
+		fmt.Fprintln(&buf, "from conan import ConanFile")
+		fmt.Fprintln(&buf)
+		fmt.Fprintln(&buf)
+		fmt.Fprintln(&buf, "class ConvertedConan(ConanFile):")
+		fmt.Fprintln(&buf, `    settings = "os", "compiler", "build_type", "arch"`)
+		fmt.Fprintln(&buf, `    generators = "PkgConfigDeps", "CMakeDeps"`)
+		fmt.Fprintln(&buf)
+		fmt.Fprintln(&buf, "    def requirements(self):")
+		for _, d := range deps {
+			fmt.Fprintf(&buf, "        self.requires(%q)\n", d)
+		}
+
+	case "vcpkg":
+		filename = "vcpkg.json"
+
+		// fields starting with $ are comments for vcpkg
+		manifest := struct {
+			Name         string    `json:"name"`
+			Version      string    `json:"version-string"`
+			Dependencies []string  `json:"dependencies"`
+			Overrides    Overrides `json:"$overrides,omitempty"`
+		}{vcpkgName(outdir), "0.1", deps, overrides}
+
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		buf.Write(data)
+		buf.WriteString("\n")
+
+	default:
+		return fmt.Errorf("unsupported dependency manager %q, use conan or vcpkg", manager)
+	}
+
+	return os.WriteFile(filepath.Join(outdir, filename), buf.Bytes(), 0644)
+}
+
+//
+// vcpkgName returns a valid vcpkg package name (lowercase letters, digits and dashes) for the output folder
+//
+func vcpkgName(outdir string) string {
+	name := outdir
+	if abs, err := filepath.Abs(outdir); err == nil {
+		name = filepath.Base(abs)
+	}
+
+	name = strings.Trim(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+
+		return '-'
+	}, name), "-")
+
+	if len(name) == 0 {
+		return "converted"
+	}
+
+	return name
+}
@@ -0,0 +1,44 @@
+package main
+
+//
+// map of the identifiers renamed in the converted files (--name-map)
+//
+
+import (
+	"encoding/json"
+	"os"
+)
+
+//
+// NameMap collects the identifiers renamed because they are reserved words in the target language
+// (Go name -> target name), by Go source file
+//
+type NameMap map[string]map[string]string
+
+//
+// Add records the renamed identifiers of a converted file (files without renamed identifiers are not listed)
+//
+func (m NameMap) Add(source string, mangled map[string]string) {
+	if len(mangled) == 0 {
+		return
+	}
+
+	names := map[string]string{}
+	for k, v := range mangled {
+		names[k] = v
+	}
+
+	m[source] = names
+}
+
+//
+// WriteFile writes the map as JSON ({"file.go": {"class": "class_"}})
+//
+func (m NameMap) WriteFile(filename string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
@@ -0,0 +1,65 @@
+package main
+
+//
+// Line endings and byte order mark of the output files (--line-endings, --bom)
+//
+
+import (
+	"fmt"
+	"io"
+)
+
+// UTF-8 byte order mark
+const BOM = "\uFEFF"
+
+//
+// newlines returns the line terminator for the --line-endings option (lf or crlf)
+//
+func newlines(endings string) (string, error) {
+	switch endings {
+	case "lf":
+		return "\n", nil
+	case "crlf":
+		return "\r\n", nil
+	}
+
+	return "", fmt.Errorf("unsupported line endings %s, use lf or crlf", endings)
+}
+
+//
+// crlfWriter is a writer that converts the line endings to CRLF (the lines already ending with CRLF are not changed)
+//
+type crlfWriter struct {
+	w  io.Writer
+	cr bool // the last byte written was '\r'
+}
+
+func (c *crlfWriter) Write(b []byte) (int, error) {
+	out := make([]byte, 0, len(b)+len(b)/16)
+
+	for _, ch := range b {
+		if ch == '\n' && !c.cr {
+			out = append(out, '\r')
+		}
+
+		out = append(out, ch)
+		c.cr = ch == '\r'
+	}
+
+	if _, err := c.w.Write(out); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+//
+// encode returns the writer for an output file, with the line endings of the run
+//
+func (w Walker) encode(f io.Writer) io.Writer {
+	if w.newline == "\r\n" {
+		return &crlfWriter{w: f}
+	}
+
+	return f
+}
@@ -0,0 +1,169 @@
+package main
+
+//
+// names of the output files (--output-name), as a template
+//
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+//
+// OutputName has the fields available to the --output-name template
+//
+type OutputName struct {
+	Dir     string // folder of the Go source, relative to the input folder ("." for the input folder)
+	Base    string // name of the Go source, without .go
+	Ext     string // extension of the target language (cc, rs, swift, ...)
+	Package string // package name
+}
+
+// output name for --layout=package: the sources of a package are converted to a single file
+const PACKAGE_LAYOUT = "{{.Dir}}/{{.Package}}.{{.Ext}}"
+
+// functions available to the --output-name template
+var outputNameFuncs = template.FuncMap{
+	"snake": snakeCase,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+//
+// OutputNames renders the paths of the output files, relative to the output folder,
+// from a template (i.e. "{{.Dir}}/{{snake .Base}}.gen.{{.Ext}}" or "{{.Package}}/{{.Base}}.{{.Ext}}")
+//
+type OutputNames struct {
+	t *template.Template
+}
+
+//
+// ParseOutputNames parses the template for the names of the output files
+//
+func ParseOutputNames(text string) (*OutputNames, error) {
+	t, err := template.New("output-name").Funcs(outputNameFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	// check the fields used, so that the errors are reported once and not for each file
+	if err := t.Execute(io.Discard, OutputName{}); err != nil {
+		return nil, err
+	}
+
+	return &OutputNames{t: t}, nil
+}
+
+//
+// Name returns the path of the output file, relative to the output folder
+//
+func (o *OutputNames) Name(n OutputName) (string, error) {
+	var b bytes.Buffer
+
+	if err := o.t.Execute(&b, n); err != nil {
+		return "", err
+	}
+
+	name := filepath.Clean(filepath.FromSlash(b.String()))
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("output name %q for %s is not in the output folder", b.String(), filepath.Join(n.Dir, n.Base+".go"))
+	}
+
+	return name, nil
+}
+
+//
+// outputPath returns the output file for the Go source path (rel is the path relative to the input folder)
+// with the target extension ext, creating its folder if needed
+//
+func (w Walker) outputPath(path, rel, ext string) (string, error) {
+	if w.outnames == nil {
+		return filepath.Join(w.outdir, strings.TrimSuffix(rel, "go")+ext), nil
+	}
+
+	pkgname, _ := sourceImports(path)
+
+	name, err := w.outnames.Name(OutputName{
+		Dir:     filepath.ToSlash(filepath.Dir(rel)),
+		Base:    strings.TrimSuffix(filepath.Base(rel), ".go"),
+		Ext:     ext,
+		Package: pkgname,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	outpath := filepath.Join(w.outdir, name)
+	return outpath, os.MkdirAll(filepath.Dir(outpath), 0755)
+}
+
+//
+// openOutput opens the output file for a source: the first time it's created (after reading its hand-edited regions,
+// see KeepRegions) starting with the byte order mark if requested, then the following sources with the same output file
+// are appended
+//
+func (w Walker) openOutput(outpath string) (*os.File, error) {
+	if w.written[outpath] {
+		f, err := os.OpenFile(outpath, os.O_WRONLY|os.O_APPEND, 0644)
+		if err == nil {
+			_, err = f.WriteString(w.newline) // separate the sources
+		}
+
+		return f, err
+	}
+
+	keep, err := readKeepRegions(outpath)
+	if err != nil {
+		// don't overwrite the hand-edited regions
+		return nil, err
+	}
+
+	if keep != nil {
+		w.keeps[outpath] = keep
+	}
+
+	w.written[outpath] = true
+
+	f, err := os.Create(outpath)
+	if err == nil && w.bom {
+		if _, err = f.WriteString(BOM); err != nil {
+			f.Close()
+		}
+	}
+
+	return f, err
+}
+
+//
+// snakeCase converts a name to snake_case (HTTPServer and httpServer to http_server, my-file to my_file)
+//
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ':
+			b.WriteRune('_')
+			continue
+
+		case unicode.IsUpper(r) && i > 0:
+			prev := runes[i-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				b.WriteRune('_')
+			}
+		}
+
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,107 @@
+package main
+
+//
+// Hand-written overrides: foo.go can be replaced by a hand-written foo.cpp.override (or foo.cc.override,
+// foo.rs.override, ...) in the same folder, that is copied to the output instead of converting foo.go
+//
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const OVERRIDE_SUFFIX = ".override"
+
+//
+// Overrides maps the output files (relative to the output folder) to the hand-written files replacing them
+//
+type Overrides map[string]string
+
+// target extensions for the override files, other than the output extension
+var overrideExts = map[string][]string{
+	"cc": {"cpp"},
+}
+
+//
+// findOverride returns the hand-written file replacing the Go source file and its target extension, if any
+//
+func findOverride(path, ext string) (string, string) {
+	base := strings.TrimSuffix(path, ".go")
+
+	for _, e := range append([]string{ext}, overrideExts[ext]...) {
+		override := base + "." + e + OVERRIDE_SUFFIX
+		if info, err := os.Stat(override); err == nil && !info.IsDir() {
+			return override, e
+		}
+	}
+
+	return "", ""
+}
+
+//
+// sourceImports returns the package name and the imports of a Go source file,
+// so that the overridden files are still part of the build files and manifests
+//
+func sourceImports(path string) (string, []string) {
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+	if err != nil {
+		return "", nil
+	}
+
+	imports := make([]string, 0, len(f.Imports))
+	for _, i := range f.Imports {
+		if ipath, err := strconv.Unquote(i.Path.Value); err == nil {
+			imports = append(imports, ipath)
+		}
+	}
+
+	return f.Name.Name, imports
+}
+
+//
+// writeOverride copies the hand-written file to outpath (or to stdout, with no output folder)
+// and records it
+//
+func (w Walker) writeOverride(override, outpath string) error {
+	data, err := os.ReadFile(override)
+	if err != nil {
+		return err
+	}
+
+	if len(outpath) == 0 {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	if rel, err := filepath.Rel(w.outdir, outpath); err == nil {
+		w.overrides[filepath.ToSlash(rel)] = override
+	}
+
+	f, err := w.openOutput(outpath)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	_, err = f.Write(data)
+	return err
+}
+
+//
+// Files returns the sorted list of the overridden output files
+//
+func (o Overrides) Files() []string {
+	files := make([]string, 0, len(o))
+	for f := range o {
+		files = append(files, f)
+	}
+
+	sort.Strings(files)
+	return files
+}
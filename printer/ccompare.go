@@ -0,0 +1,32 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+)
+
+//
+// PrintComparable prints operator== and operator!= (Go struct comparison) and operator< (for std::map)
+// comparing the fields in order
+//
+func (p *CPrinter) PrintComparable(name string, fields []string) {
+	if _, ok := p.Types[name]; ok {
+		return
+	}
+
+	tie := func(v string) string {
+		values := make([]string, len(fields))
+		for i, f := range fields {
+			values[i] = v + "." + f
+		}
+
+		return "std::tie(" + strings.Join(values, ", ") + ")"
+	}
+
+	params := fmt.Sprintf("(const %s& a, const %s& b)", name, name)
+
+	p.Print(p.terms().NL)
+	p.PrintLevel(p.terms().NL, fmt.Sprintf("inline bool operator==%s { return %s == %s; }", params, tie("a"), tie("b")))
+	p.PrintLevel(p.terms().NL, fmt.Sprintf("inline bool operator!=%s { return !(a == b); }", params))
+	p.PrintLevel(p.terms().NL, fmt.Sprintf("inline bool operator<%s { return %s < %s; }", params, tie("a"), tie("b")))
+}
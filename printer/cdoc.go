@@ -0,0 +1,88 @@
+package printer
+
+import (
+	"strings"
+)
+
+const DEPRECATED_PREFIX = "Deprecated:"
+
+//
+// docLines splits the text of a doc comment (as returned by ast.CommentGroup.Text) in lines,
+// without the trailing empty lines
+//
+func docLines(doc string) []string {
+	lines := strings.Split(strings.TrimRight(doc, "\n"), "\n")
+	if len(lines) == 1 && len(lines[0]) == 0 {
+		return nil
+	}
+
+	return lines
+}
+
+//
+// doxygenLines converts a Go doc comment to Doxygen: the indented blocks are wrapped in @code/@endcode
+// and a "Deprecated:" paragraph becomes @deprecated
+//
+func doxygenLines(doc string) []string {
+	var out []string
+
+	code := false
+	endCode := func() {
+		// the empty lines at the end of the block go after @endcode
+		n := len(out)
+		for n > 0 && len(out[n-1]) == 0 {
+			n--
+		}
+
+		out = append(out[:n], append([]string{"@endcode"}, out[n:]...)...)
+		code = false
+	}
+
+	for _, line := range docLines(doc) {
+		indented := strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+
+		if indented && !code {
+			out = append(out, "@code")
+			code = true
+		} else if !indented && code && len(line) > 0 {
+			endCode()
+		}
+
+		if !code && strings.HasPrefix(line, DEPRECATED_PREFIX) {
+			line = "@deprecated " + strings.TrimSpace(strings.TrimPrefix(line, DEPRECATED_PREFIX))
+		}
+
+		out = append(out, line)
+	}
+
+	if code {
+		endCode()
+	}
+
+	return out
+}
+
+//
+// PrintDoc prints the doc comment as a Doxygen comment block. The line directive (if any)
+// is left to the declaration, that follows the comment
+//
+func (p *CPrinter) PrintDoc(doc string) {
+	lines := doxygenLines(doc)
+	if len(lines) == 0 {
+		return
+	}
+
+	nl := p.terms().NL
+
+	p.out.write(p.w, p.indent(), "/**", nl)
+	for _, line := range lines {
+		p.out.write(p.w, p.indent(), strings.TrimRight(" * "+strings.ReplaceAll(line, "*/", "*\\/"), " "), nl)
+	}
+	p.out.write(p.w, p.indent(), " */", nl)
+}
+
+func (p *CPrinter) PrintComment(comment string) {
+	for _, line := range docLines(comment) {
+		p.out.write(p.w, p.indent(), strings.TrimRight("// "+line, " "), p.terms().NL)
+	}
+}
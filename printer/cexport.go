@@ -0,0 +1,118 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+)
+
+//
+// exportFunc is an exported function waiting for its extern "C" wrapper (see CPrinter.ExternC),
+// that is printed when the function context is closed
+//
+type exportFunc struct {
+	ctx     *Context
+	name    string
+	params  string
+	results string
+}
+
+//
+// printExport prints an extern "C" wrapper for an exported function.
+//
+// Slices and arrays are passed as pointer (slices also with length) and strings as pointer and length.
+// String results are returned as a copy allocated with strdup (to be freed by the caller).
+// Functions with multiple results or function parameters are not wrapped.
+//
+func (p *CPrinter) printExport(f *exportFunc) {
+	var params, args []string
+
+	p.Print(p.terms().NL)
+
+	skip := func(reason string) {
+		p.PrintLevel(p.terms().NL, "// no extern \"C\" wrapper for", f.name+":", reason)
+	}
+
+	if IsMultiValue(f.results) {
+		skip("multiple results")
+		return
+	}
+
+	for _, param := range splitList(f.params) {
+		param = strings.TrimSpace(param)
+
+		i := strings.LastIndex(param, " ")
+		if i < 0 || strings.ContainsAny(param, "(.") {
+			skip("unsupported parameter " + param)
+			return
+		}
+
+		ptype, name := param[:i], param[i+1:]
+
+		switch {
+		case strings.HasSuffix(name, "[]"):
+			name = strings.TrimSuffix(name, "[]")
+			params = append(params, fmt.Sprintf("%s* %s, size_t %s_len", ptype, name, name))
+
+		case strings.HasSuffix(name, "]"):
+			name = name[:strings.Index(name, "[")]
+			params = append(params, fmt.Sprintf("%s* %s", ptype, name))
+
+		case ptype == "std::string":
+			params = append(params, fmt.Sprintf("const char* %s, size_t %s_len", name, name))
+			name = fmt.Sprintf("std::string(%s, %s_len)", name, name)
+
+		default:
+			params = append(params, param)
+		}
+
+		args = append(args, name)
+	}
+
+	results := f.results
+	call := fmt.Sprintf("%s(%s)", f.name, strings.Join(args, ", "))
+
+	switch {
+	case len(results) == 0:
+		results = "void"
+
+	case strings.HasPrefix(results, "std::string"):
+		results = "char*"
+		call = fmt.Sprintf("return strdup(%s.c_str())", call)
+
+	default:
+		call = "return " + call
+	}
+
+	p.PrintLevel(p.terms().NL, fmt.Sprintf(`extern "C" %s %s(%s) {`, results, p.names.Unique("go_"+f.name), strings.Join(params, ", ")))
+	p.UpdateLevel(UP)
+	p.PrintLevel(p.terms().Semi, call)
+	p.UpdateLevel(DOWN)
+	p.PrintLevel(p.terms().NL, "}")
+}
+
+//
+// splitList splits a comma separated list, ignoring commas nested in parenthesis or brackets
+//
+func splitList(s string) (list []string) {
+	if len(s) == 0 {
+		return nil
+	}
+
+	depth, start := 0, 0
+
+	for i, c := range s {
+		switch c {
+		case '(', '[', '{', '<':
+			depth++
+		case ')', ']', '}', '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				list = append(list, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(list, s[start:])
+}
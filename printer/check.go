@@ -0,0 +1,159 @@
+package printer
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+)
+
+//
+// SyntaxProblem is a line of the generated output that can't be valid C++
+//
+type SyntaxProblem struct {
+	Pos  token.Position // source position the line was generated from (invalid if unknown)
+	Line int            // line of the output
+	Msg  string
+}
+
+// Go tokens that can't appear in C++ code (outside of comments and literals)
+var goOnlyTokens = []struct{ token, what string }{
+	{":=", "short variable declaration"},
+	{"<-", "channel operator"},
+	{".(", "type assertion"},
+}
+
+// brackets that must be balanced
+var closing = map[byte]byte{')': '(', ']': '[', '}': '{'}
+
+type opening struct {
+	bracket byte
+	pos     token.Position
+	line    int
+}
+
+//
+// SyntaxCheck is a sanity pass on the generated C++ code: it doesn't change the output but records
+// the lines that can't possibly be valid (unbalanced brackets, Go tokens left as they are),
+// so that the conversion problems are reported instead of producing code that doesn't compile
+//
+type SyntaxCheck struct {
+	problems []SyntaxProblem
+	pos      token.Position // source position of the current line (see SetPosition)
+	line     int            // output line
+	comment  bool           // inside a block comment
+	open     []opening      // brackets not closed yet
+}
+
+func NewSyntaxCheck() *SyntaxCheck {
+	return &SyntaxCheck{}
+}
+
+func (c *SyntaxCheck) SetPosition(pos token.Position) {
+	c.pos = pos
+}
+
+func (c *SyntaxCheck) Transform(line string) (string, bool) {
+	for _, l := range strings.Split(line, "\n") {
+		c.line++
+		c.check(l)
+	}
+
+	return line, true
+}
+
+func (c *SyntaxCheck) Reset() {
+	c.problems, c.line, c.comment, c.open = nil, 0, false, nil
+	c.pos = token.Position{}
+}
+
+//
+// Problems returns the problems found in the output of the current file, including the brackets not closed
+//
+func (c *SyntaxCheck) Problems() []SyntaxProblem {
+	problems := c.problems
+
+	for _, o := range c.open {
+		problems = append(problems, SyntaxProblem{o.pos, o.line, fmt.Sprintf("%q not closed", o.bracket)})
+	}
+
+	return problems
+}
+
+func (c *SyntaxCheck) report(format string, args ...interface{}) {
+	c.problems = append(c.problems, SyntaxProblem{c.pos, c.line, fmt.Sprintf(format, args...)})
+}
+
+//
+// check checks a line of the output
+//
+func (c *SyntaxCheck) check(line string) {
+	if !c.comment && strings.HasPrefix(strings.TrimSpace(line), "#") {
+		// preprocessor directive
+		return
+	}
+
+	code := c.stripComments(line)
+
+	for _, t := range goOnlyTokens {
+		if strings.Contains(code, t.token) {
+			c.report("Go %s (%s) in the output", t.what, t.token)
+		}
+	}
+
+	for i := 0; i < len(code); i++ {
+		switch b := code[i]; b {
+		case '(', '[', '{':
+			c.open = append(c.open, opening{b, c.pos, c.line})
+
+		case ')', ']', '}':
+			if n := len(c.open); n == 0 || c.open[n-1].bracket != closing[b] {
+				c.report("unbalanced %q", b)
+			} else {
+				c.open = c.open[:n-1]
+			}
+		}
+	}
+}
+
+//
+// stripComments returns the code in the line, without comments and string and character literals
+// (replaced by spaces), keeping track of the block comments spanning multiple lines
+//
+func (c *SyntaxCheck) stripComments(line string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(line); i++ {
+		if c.comment {
+			if strings.HasPrefix(line[i:], "*/") {
+				c.comment = false
+				i++
+			}
+
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line[i:], "//"):
+			return b.String()
+
+		case strings.HasPrefix(line[i:], "/*"):
+			c.comment = true
+			i++
+
+		case line[i] == '"' || line[i] == '\'':
+			quote := line[i]
+			for i++; i < len(line) && line[i] != quote; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+
+			b.WriteByte(' ')
+
+		default:
+			b.WriteByte(line[i])
+		}
+	}
+
+	return b.String()
+}
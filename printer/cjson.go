@@ -0,0 +1,65 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+)
+
+//
+// printJSONCodec prints the nlohmann::json conversion functions (to_json and from_json)
+// for a struct type, following the encoding/json rules:
+//
+//   - unexported fields and fields tagged `json:"-"` are skipped
+//   - the tag name (if present) replaces the field name
+//   - omitempty fields are not encoded when they have the zero value
+//   - untagged embedded structs are flattened
+//
+func (p *CPrinter) printJSONCodec(name string, fields []StructField) {
+	var encode, decode []string
+
+	for _, f := range fields {
+		tag, tagged := f.Tag.Lookup("json")
+		key, opts, _ := strings.Cut(tag, ",")
+
+		if tag == "-" || !IsPublic(f.Name) && !f.Embedded {
+			continue
+		}
+
+		if f.Embedded && !tagged {
+			encode = append(encode, fmt.Sprintf("j.update(nlohmann::json(v.%s))", f.Name))
+			decode = append(decode, fmt.Sprintf("j.get_to(v.%s)", f.Name))
+			continue
+		}
+
+		if len(key) == 0 {
+			key = f.Name
+		}
+
+		set := fmt.Sprintf("j[%q] = v.%s", key, f.Name)
+		if strings.Contains(","+opts+",", ",omitempty,") {
+			set = fmt.Sprintf("if (v.%s != decltype(v.%s){}) %s", f.Name, f.Name, set)
+		}
+
+		encode = append(encode, set)
+		decode = append(decode, fmt.Sprintf("if (j.contains(%q)) j.at(%q).get_to(v.%s)", key, key, f.Name))
+	}
+
+	p.Print(p.terms().NL)
+	p.PrintLevel(p.terms().NL, fmt.Sprintf("inline void to_json(nlohmann::json& j, const %s& v) {", name))
+	p.UpdateLevel(UP)
+	p.PrintLevel(p.terms().Semi, "j = nlohmann::json::object()")
+	for _, s := range encode {
+		p.PrintLevel(p.terms().Semi, s)
+	}
+	p.UpdateLevel(DOWN)
+	p.PrintLevel(p.terms().NL, "}")
+
+	p.Print(p.terms().NL)
+	p.PrintLevel(p.terms().NL, fmt.Sprintf("inline void from_json(const nlohmann::json& j, %s& v) {", name))
+	p.UpdateLevel(UP)
+	for _, s := range decode {
+		p.PrintLevel(p.terms().Semi, s)
+	}
+	p.UpdateLevel(DOWN)
+	p.PrintLevel(p.terms().NL, "}")
+}
@@ -0,0 +1,45 @@
+package printer
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+//
+// ModulePartition returns the name of the C++20 module partition for a Go source file
+// (the file name without extension, with invalid characters replaced by '_')
+//
+func ModulePartition(filename string) string {
+	name := strings.TrimSuffix(filepath.Base(filename), ".go")
+
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+
+		return '_'
+	}, name)
+
+	if len(name) == 0 || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+
+	return name
+}
+
+//
+// ModuleInterface returns the primary module interface unit for a package,
+// exporting its partitions (one for each converted file)
+//
+func ModuleInterface(name string, partitions []string) string {
+	var b strings.Builder
+
+	b.WriteString("// generated by walkngo: primary interface of module " + name + "\n")
+	b.WriteString("export module " + name + ";\n\n")
+
+	for _, p := range partitions {
+		b.WriteString("export import :" + p + ";\n")
+	}
+
+	return b.String()
+}
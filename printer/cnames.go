@@ -0,0 +1,58 @@
+package printer
+
+const (
+	MANGLE_SUFFIX = "_"
+)
+
+//
+// cppReserved are the names that can't be used as C++ identifiers: the keywords (up to C++20),
+// the alternative operator tokens and the macros defined by the C and C++ standard headers
+// included by the runtime
+//
+var cppReserved = map[string]bool{
+	// keywords
+	"alignas": true, "alignof": true, "asm": true, "auto": true, "bool": true, "catch": true,
+	"char": true, "char8_t": true, "char16_t": true, "char32_t": true, "class": true, "concept": true,
+	"const_cast": true, "consteval": true, "constexpr": true, "constinit": true, "co_await": true,
+	"co_return": true, "co_yield": true, "decltype": true, "delete": true, "do": true, "double": true,
+	"dynamic_cast": true, "enum": true, "explicit": true, "export": true, "extern": true, "false": true,
+	"float": true, "friend": true, "inline": true, "int": true, "long": true, "mutable": true,
+	"namespace": true, "new": true, "noexcept": true, "nullptr": true, "operator": true, "private": true,
+	"protected": true, "public": true, "register": true, "reinterpret_cast": true, "requires": true,
+	"short": true, "signed": true, "sizeof": true, "static": true, "static_assert": true,
+	"static_cast": true, "template": true, "this": true, "thread_local": true, "throw": true, "true": true,
+	"try": true, "typedef": true, "typeid": true, "typename": true, "union": true, "unsigned": true,
+	"using": true, "virtual": true, "void": true, "volatile": true, "wchar_t": true, "while": true,
+
+	// alternative tokens
+	"and": true, "and_eq": true, "bitand": true, "bitor": true, "compl": true, "not": true,
+	"not_eq": true, "or": true, "or_eq": true, "xor": true, "xor_eq": true,
+
+	// standard macros
+	"NULL": true, "EOF": true, "BUFSIZ": true, "FILENAME_MAX": true, "CHAR_BIT": true,
+	"INT_MAX": true, "INT_MIN": true, "SIZE_MAX": true, "EXIT_SUCCESS": true, "EXIT_FAILURE": true,
+	"SEEK_SET": true, "SEEK_CUR": true, "SEEK_END": true, "INFINITY": true, "NAN": true,
+	"assert": true, "errno": true, "stdin": true, "stdout": true, "stderr": true, "offsetof": true,
+	"setjmp": true, "va_start": true, "va_arg": true, "va_end": true, "va_copy": true,
+	"linux": true, "unix": true,
+}
+
+//
+// IsCReserved returns true if name is a C++ keyword or a standard macro
+//
+func IsCReserved(name string) bool {
+	return cppReserved[name]
+}
+
+func (p *CPrinter) ReservedWords() map[string]bool {
+	return cppReserved
+}
+
+//
+// MangleName returns the C++ name for a Go identifier: names that (without trailing underscores)
+// are C++ keywords or standard macros get an additional MANGLE_SUFFIX (class -> class_, class_ -> class__),
+// so that the mapping depends only on the name and never clashes with other identifiers
+//
+func (p *CPrinter) MangleName(name string) string {
+	return mangleReserved(name, cppReserved)
+}
@@ -0,0 +1,146 @@
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// NativeKind describes how a value is passed to a native (Go) function
+type NativeKind int
+
+const (
+	NATIVE_NUMBER NativeKind = iota // numeric types, passed as the corresponding C type
+	NATIVE_BOOL                     // bool, passed as int
+	NATIVE_STRING                   // string, passed as pointer and length
+	NATIVE_BYTES                    // []byte (or [N]byte results), passed as pointer and length
+)
+
+//
+// NativeValue is a parameter or result of a native function
+//
+type NativeValue struct {
+	Name   string
+	Kind   NativeKind
+	GoType string // i.e. "int64"
+	CType  string // for NATIVE_NUMBER, i.e. "long long"
+}
+
+//
+// NativeFunc is a function of a package that is kept as native Go code,
+// called through a cgo exported shim
+//
+type NativeFunc struct {
+	Package string // package name, used as namespace
+	Path    string // import path
+	Name    string
+	Params  []NativeValue
+	Result  *NativeValue // nil for no result
+}
+
+//
+// Symbol returns the name of the exported C function in the shim
+//
+func (f NativeFunc) Symbol() string {
+	return f.Package + "_" + f.Name
+}
+
+//
+// cppType returns the C++ type for a native value in the adapter
+//
+func (v NativeValue) cppType() string {
+	switch v.Kind {
+	case NATIVE_BOOL:
+		return "bool"
+
+	case NATIVE_STRING, NATIVE_BYTES:
+		return "std::string"
+	}
+
+	return v.CType
+}
+
+//
+// NativeAdapter returns a C++ header that wraps the functions exported by the shim library
+// (declared in header, generated by "go build -buildmode=c-shared") with the original
+// package and function names, so that the converted code can call them as pkg::Func(...).
+//
+// Strings and byte slices are passed as std::string.
+//
+func NativeAdapter(funcs []NativeFunc, header string) string {
+	var b bytes.Buffer
+
+	fmt.Fprintln(&b, "// generated by walkngo: adapters for packages kept as native Go code")
+	fmt.Fprintln(&b, "#pragma once")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "#include <cstdlib>")
+	fmt.Fprintln(&b, "#include <string>")
+	fmt.Fprintf(&b, "#include %q\n", header)
+
+	namespace := ""
+
+	for _, f := range funcs {
+		if f.Package != namespace {
+			if len(namespace) > 0 {
+				fmt.Fprintln(&b, "\n}")
+			}
+
+			namespace = f.Package
+			fmt.Fprintf(&b, "\nnamespace %s {\n", namespace)
+		}
+
+		var params, args []string
+
+		for _, p := range f.Params {
+			params = append(params, p.cppType()+" "+p.Name)
+
+			switch p.Kind {
+			case NATIVE_STRING, NATIVE_BYTES:
+				args = append(args, fmt.Sprintf("(char*)%s.data(), (int)%s.size()", p.Name, p.Name))
+
+			case NATIVE_BOOL:
+				args = append(args, fmt.Sprintf("%s ? 1 : 0", p.Name))
+
+			default:
+				args = append(args, p.Name)
+			}
+		}
+
+		result := "void"
+		if f.Result != nil {
+			result = f.Result.cppType()
+		}
+
+		fmt.Fprintf(&b, "\ninline %s %s(%s) {\n", result, f.Name, strings.Join(params, ", "))
+
+		call := fmt.Sprintf("%s(%s)", f.Symbol(), strings.Join(args, ", "))
+
+		switch {
+		case f.Result == nil:
+			fmt.Fprintf(&b, "    %s;\n", call)
+
+		case f.Result.Kind == NATIVE_STRING || f.Result.Kind == NATIVE_BYTES:
+			call = fmt.Sprintf("%s(%s)", f.Symbol(), strings.Join(append(args, "&rlen"), ", "))
+
+			fmt.Fprintln(&b, "    int rlen;")
+			fmt.Fprintf(&b, "    char* r = %s;\n", call)
+			fmt.Fprintln(&b, "    std::string ret(r, rlen);")
+			fmt.Fprintln(&b, "    free(r);")
+			fmt.Fprintln(&b, "    return ret;")
+
+		case f.Result.Kind == NATIVE_BOOL:
+			fmt.Fprintf(&b, "    return %s != 0;\n", call)
+
+		default:
+			fmt.Fprintf(&b, "    return %s;\n", call)
+		}
+
+		fmt.Fprintln(&b, "}")
+	}
+
+	if len(namespace) > 0 {
+		fmt.Fprintln(&b, "\n}")
+	}
+
+	return b.String()
+}
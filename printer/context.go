@@ -0,0 +1,80 @@
+package printer
+
+//
+// Context is the context for a (function) block.
+//
+// It keeps track of the information a printer may need while printing a scope
+// (receiver, named return values and iota) and it is shared by all printers via ContextStack.
+// Declared names are tracked by the walker in a SymbolTable.
+//
+type Context struct {
+	Iota int // incremented when 'const n = iota' or 'const n'
+
+	Receiver       string // the name of the receiver (i.e. to be converted to "this")
+	RetDefinitions string // used to define return variables
+	RetValues      string // used to "fill" empty returns
+
+	next *Context
+}
+
+//
+// Outer returns the enclosing context (nil for the outermost one)
+//
+func (ctx *Context) Outer() *Context {
+	if ctx == nil {
+		return nil
+	}
+
+	return ctx.next
+}
+
+//
+// IsReceiver returns true if name is the receiver of the current function
+//
+func (ctx *Context) IsReceiver(name string) bool {
+	for c := ctx; c != nil; c = c.next {
+		if len(c.Receiver) > 0 {
+			return c.Receiver == name
+		}
+	}
+
+	return false
+}
+
+//
+// ContextStack is a stack of contexts, one for each (function) block being printed.
+// Printers should call Push/Pop from their PushContext/PopContext methods.
+//
+type ContextStack struct {
+	top *Context
+}
+
+//
+// Push creates a new context, nested into the current one
+//
+func (s *ContextStack) Push() {
+	s.top = &Context{next: s.top}
+}
+
+//
+// Pop removes the current context, restoring the enclosing one
+//
+func (s *ContextStack) Pop() {
+	if s.top != nil {
+		s.top = s.top.next
+	}
+}
+
+//
+// Current returns the current context (nil if the stack is empty)
+//
+func (s *ContextStack) Current() *Context {
+	return s.top
+}
+
+//
+// Reset removes all contexts
+//
+func (s *ContextStack) Reset() {
+	s.top = nil
+}
@@ -2,15 +2,21 @@ package printer
 
 import (
 	"fmt"
+	"go/token"
 	"io"
+	"log/slog"
+	pathpkg "path"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 const (
-	NIL  = "nil"
-	NULL = "nullptr"
-	IOTA = "iota"
+	NIL   = "nil"
+	NULL  = "nullptr"
+	IOTA  = "iota"
+	ANY   = "Any"      // the empty interface (see go.h)
+	EMPTY = "go_empty" // the empty struct (see go.h)
 )
 
 //
@@ -19,30 +25,50 @@ const (
 type CPrinter struct {
 	Printer
 
+	LineDirectives bool      // emit #line directives pointing to the Go source
+	Imports        ImportMap // conversion of imported packages (nil for CImports)
+	Types          TypeMap   // user defined type mappings
+	Unsafe         bool      // translate unsafe operations to casts and raw pointer arithmetic
+	ExternC        bool      // add extern "C" wrappers for exported functions
+	Modules        bool      // emit C++20 module units (export module) instead of include based files
+
 	level    int
 	sameline bool
 	w        io.Writer
-
-	ctx *CContext
+	out      outBuffer
+	style    FormatStyle
+	indents  indentCache
+	log      *slog.Logger
+	symbols  *SymbolTable
+	pos      token.Position
+	line     int // source line of the last #line directive
+
+	ctx      ContextStack
+	names    Names
+	pkg      string            // package name
+	packages map[string]string // imported packages (local name -> import path)
+	named    map[string]bool   // named types with methods, that are not structs (see PrintNamedType)
+	export   *exportFunc       // exported function to be wrapped (ExternC)
+	test     *testFunc         // test function to be registered
+	modules  []string          // module imports, printed after the module declaration
+	includes []string          // includes required by the imports, printed by PrintImportsEnd
+
+	fallsThrough bool // the current case ends with fallthrough (see PrintEndCase)
 }
 
 //
-// CContext is the context for a (function) block
+// imports returns the import mapping for this printer
 //
-type CContext struct {
-	iota int // incremented when 'const n = iota' or 'const n'
-
-	deferred int // used to generate unique names for "defer" callbacks
-
-	receiver        string // the name of the receiver, to be converted to "this"
-	ret_definitions string // used to define return variables
-	ret_values      string // used to "fill" empty returns
+func (p *CPrinter) imports() ImportMap {
+	if p.Imports == nil {
+		return CImports
+	}
 
-	next *CContext
+	return p.Imports
 }
 
-func (ctx *CContext) Selector(s string) string {
-	if ctx != nil && ctx.receiver == s {
+func (p *CPrinter) selector(s string) string {
+	if p.ctx.Current().IsReceiver(s) {
 		return "this->"
 	}
 
@@ -52,22 +78,73 @@ func (ctx *CContext) Selector(s string) string {
 func (p *CPrinter) Reset() {
 	p.level = 0
 	p.sameline = false
+	p.line = 0
+
+	p.ctx.Reset()
+	p.names.Reset()
+	p.packages = nil
+	p.named = nil
+	p.fallsThrough = false
+	p.export = nil
+	p.test = nil
+}
 
-	p.ctx = nil
+func (p *CPrinter) Capabilities() Capability {
+	// goroutines (threads), defer and channels are implemented in the C++ runtime,
+	// and cgo code is already C
+	return CAP_GOROUTINES | CAP_DEFER | CAP_CHANNELS | CAP_CGO | CAP_GOTO
 }
 
 func (p *CPrinter) PushContext() {
-	p.ctx = &CContext{next: p.ctx}
+	p.ctx.Push()
 }
 
 func (p *CPrinter) PopContext() {
-	p.ctx = p.ctx.next
+	if p.export != nil && p.export.ctx == p.ctx.Current() {
+		p.printExport(p.export)
+		p.export = nil
+	}
+
+	if p.test != nil && p.test.ctx == p.ctx.Current() {
+		p.printTest(p.test)
+		p.test = nil
+	}
+
+	p.ctx.Pop()
+}
+
+func (p *CPrinter) ReserveNames(names ...string) {
+	p.names.Reserve(names...)
+}
+
+//
+// terms returns the terminators for the current style
+//
+func (p *CPrinter) terms() Terminators {
+	return p.style.Terms.Or(DefaultTerminators)
+}
+
+func (p *CPrinter) SetSymbols(symbols *SymbolTable) {
+	p.symbols = symbols
+}
+
+func (p *CPrinter) SetPosition(pos token.Position) {
+	p.pos = pos
 }
 
 func (p *CPrinter) SetWriter(w io.Writer) {
 	p.w = w
 }
 
+func (p *CPrinter) SetStyle(style FormatStyle) {
+	p.style = style
+	p.indents = nil
+}
+
+func (p *CPrinter) SetLogger(logger *slog.Logger) {
+	p.log = logger
+}
+
 func (p *CPrinter) UpdateLevel(delta int) {
 	p.level += delta
 }
@@ -81,29 +158,43 @@ func (p *CPrinter) IsSameLine() bool {
 }
 
 func (p *CPrinter) Chop(line string) string {
-	return strings.TrimRight(line, COMMA)
+	return strings.TrimRight(line, p.terms().Comma)
 }
 
 func (p *CPrinter) indent() string {
 	if p.sameline {
+		// continue the statement header (i.e. the opening brace or the if after else)
 		p.sameline = false
+		return " "
+	}
+
+	return p.indents.level(p.style, p.level)
+}
+
+//
+// lineDirective returns a #line directive if the source line changed since the last one
+// (it should only be called at the beginning of a line)
+//
+func (p *CPrinter) lineDirective() string {
+	if !p.LineDirectives || p.sameline || !p.pos.IsValid() || p.pos.Line == p.line {
 		return ""
 	}
 
-	return strings.Repeat("  ", p.level)
+	p.line = p.pos.Line
+	return fmt.Sprintf("#line %d %q\n", p.pos.Line, p.pos.Filename)
 }
 
 func (p *CPrinter) Print(values ...string) {
-	fmt.Fprint(p.w, strings.Join(values, " "))
+	p.out.writeValues(p.w, "", values, NONE)
 }
 
 func (p *CPrinter) PrintLevel(term string, values ...string) {
-	fmt.Fprint(p.w, p.indent(), strings.Join(values, " "), term)
+	p.out.writeValues(p.w, p.lineDirective()+p.indent(), values, term)
 }
 
 func (p *CPrinter) PrintLevelIn(term string, values ...string) {
 	p.level -= 1
-	fmt.Fprint(p.w, p.indent(), strings.Join(values, " "), term)
+	p.out.writeValues(p.w, p.indent(), values, term)
 	p.level += 1
 }
 
@@ -117,12 +208,17 @@ func (p *CPrinter) PrintBlockStart(b BlockType) {
 		open = "{"
 	}
 
-	p.PrintLevel(NL, open)
+	if b == CODE && p.sameline && p.style.Braces == BRACE_ALLMAN {
+		p.sameline = false
+		p.Print(p.terms().NL)
+	}
+
+	p.PrintLevel(p.terms().NL, open)
 	p.UpdateLevel(UP)
 
-	if b == CODE && len(p.ctx.ret_definitions) > 0 {
-		p.PrintLevel(NL, p.ctx.ret_definitions)
-		p.ctx.ret_definitions = "" // this gets printed only once
+	if ctx := p.ctx.Current(); b == CODE && ctx != nil && len(ctx.RetDefinitions) > 0 {
+		p.PrintLevel(p.terms().NL, ctx.RetDefinitions)
+		ctx.RetDefinitions = "" // this gets printed only once
 	}
 }
 
@@ -141,34 +237,234 @@ func (p *CPrinter) PrintBlockEnd(b BlockType) {
 }
 
 func (p *CPrinter) PrintPackage(name string) {
-	p.PrintLevel(NL, "//package", name)
-	p.PrintLevel(NL, "#include <go.h>")
+	p.pkg = name
+	p.modules = nil
+	p.includes = nil
+	p.PrintLevel(p.terms().NL, "//package", name)
+
+	if p.Modules && name != "main" {
+		// the includes go in the global module fragment
+		p.PrintLevel(p.terms().NL, "module;")
+	}
+
+	p.PrintLevel(p.terms().NL, "#include <go.h>")
 }
 
 func (p *CPrinter) PrintImport(name, path string) {
-	p.PrintLevel(NL, "//import", name, path)
+	p.PrintLevel(p.terms().NL, "//import", name, path)
+
+	ipath, err := strconv.Unquote(path)
+	if err != nil {
+		ipath = path
+	}
 
-	switch path {
-	case `"fmt"`:
-		p.PrintLevel(NL, "#include <fmt.h>")
+	if len(name) == 0 {
+		name = pathpkg.Base(ipath)
+	}
 
-	case `"sync"`:
-		p.PrintLevel(NL, "#include <sync.h>")
+	if p.packages == nil {
+		p.packages = map[string]string{}
+	}
 
-	case `"errors"`:
-		p.PrintLevel(NL, "#include <errors.h>")
+	p.packages[name] = ipath
 
-	case `"time"`:
-		p.PrintLevel(NL, "#include <go_time.h>")
+	m, ok := p.imports()[ipath]
+	if !ok {
+		return
+	}
+
+	if p.Modules && len(m.Module) > 0 {
+		if p.pkg == "main" {
+			p.PrintLevel(p.terms().NL, "import", m.Module+";")
+		} else {
+			p.modules = append(p.modules, m.Module)
+		}
+	} else if name != "_" {
+		// blank imports are only for their side effects, that don't apply to the converted code
+		for _, include := range append([]string{m.Include}, m.Includes...) {
+			if len(include) > 0 {
+				p.includes = append(p.includes, include)
+			}
+		}
 	}
 }
 
+//
+// PrintImportsEnd prints the includes collected by PrintImport, without duplicates and sorted
+// (system headers first), so that each file has a single include block
+//
+func (p *CPrinter) PrintImportsEnd() {
+	seen := map[string]bool{"<go.h>": true} // printed by PrintPackage
+	includes := []string{}
+
+	for _, include := range p.includes {
+		if !seen[include] {
+			seen[include] = true
+			includes = append(includes, include)
+		}
+	}
+
+	sort.Slice(includes, func(i, j int) bool {
+		si, sj := strings.HasPrefix(includes[i], "<"), strings.HasPrefix(includes[j], "<")
+		if si != sj {
+			return si
+		}
+
+		return includes[i] < includes[j]
+	})
+
+	for _, include := range includes {
+		p.PrintLevel(p.terms().NL, "#include", include)
+	}
+
+	p.includes = nil
+}
+
+func (p *CPrinter) PrintNamespace(name string) {
+	p.Print(p.terms().NL)
+
+	if p.Modules {
+		// each file is a partition of the package module (see ModulePartition)
+		p.PrintLevel(p.terms().NL, fmt.Sprintf("export module %s:%s;", name, ModulePartition(p.pos.Filename)))
+		for _, m := range p.modules {
+			p.PrintLevel(p.terms().NL, "import", m+";")
+		}
+
+		p.Print(p.terms().NL)
+		p.PrintLevel(p.terms().NL, "export namespace", name, "{")
+		return
+	}
+
+	p.PrintLevel(p.terms().NL, "namespace", name, "{")
+}
+
+func (p *CPrinter) PrintNamespaceEnd(name string) {
+	p.Print(p.terms().NL)
+	p.PrintLevel(p.terms().NL, "} // namespace", name)
+}
+
 func (p *CPrinter) PrintType(name, typedef string) {
-	if strings.Contains(typedef, "%") {
-		// FuncType
-		p.PrintLevel(SEMI, "typedef", fmt.Sprintf(typedef, "("+name+")"))
+	if t, ok := p.Types[name]; ok {
+		// replaced by a user defined type
+		p.PrintLevel(p.terms().NL, "//type", name, "->", t)
+		return
+	}
+
+	if typedef == EMPTY {
+		// a named empty struct is a type of its own, equal and printed like struct{}
+		p.PrintLevel(p.terms().Semi, fmt.Sprintf("struct %s : %s {}", name, EMPTY))
+		return
+	}
+
+	p.PrintLevel(p.terms().Semi, "typedef", typedef, name)
+}
+
+//
+// PrintNamedType prints a struct deriving from go_named<typedef> (see go.h), that converts to and from the underlying type,
+// with the declarations of the methods. In the methods the receiver is a copy of *this (or this, for pointer receivers)
+//
+func (p *CPrinter) PrintNamedType(name, typedef string, methods []Method) {
+	if _, ok := p.Types[name]; ok || strings.HasPrefix(typedef, "std::function<") || strings.HasSuffix(typedef, "]") {
+		// function types and C arrays (slices) are not wrapped
+		p.PrintType(name, typedef)
+		return
+	}
+
+	if p.named == nil {
+		p.named = map[string]bool{}
+	}
+
+	p.named[name] = true
+
+	base := fmt.Sprintf("go_named<%s>", typedef)
+
+	p.PrintLevel(p.terms().NL, fmt.Sprintf("struct %s : %s {", name, base))
+	p.UpdateLevel(UP)
+	p.PrintLevel(p.terms().Semi, fmt.Sprintf("using %s::go_named", base))
+	for _, m := range methods {
+		p.PrintLevel(p.terms().Semi, fmt.Sprintf("%s %s(%s)", resultType(m.Results), m.Name, m.Params))
+	}
+	p.UpdateLevel(DOWN)
+	p.PrintLevel(p.terms().Semi, "}")
+}
+
+//
+// PrintStruct prints a struct type with the fields and the declarations of the methods, that use this-> for the receiver.
+// A named empty struct derives from go_empty, as in PrintType
+//
+func (p *CPrinter) PrintStruct(name, fields string, methods []Method) {
+	if _, ok := p.Types[name]; ok {
+		p.PrintType(name, p.FormatStruct(fields))
+		return
+	}
+
+	if len(fields) == 0 {
+		p.PrintLevel(p.terms().NL, fmt.Sprintf("struct %s : %s {", name, EMPTY))
 	} else {
-		p.PrintLevel(SEMI, "typedef", typedef, name)
+		p.PrintLevel(p.terms().NL, fmt.Sprintf("struct %s {", name))
+		p.Print(fields)
+	}
+
+	p.UpdateLevel(UP)
+	for _, m := range methods {
+		p.PrintLevel(p.terms().Semi, fmt.Sprintf("%s %s(%s)", resultType(m.Results), m.Name, m.Params))
+	}
+	p.UpdateLevel(DOWN)
+	p.PrintLevel(p.terms().Semi, "}")
+}
+
+//
+// PrintTypeName prints go_type_name (see go.h), for the struct types and the named types with methods:
+// the other types are typedefs, without a name of their own
+//
+func (p *CPrinter) PrintTypeName(name, typedef, goname string) {
+	if _, ok := p.Types[name]; ok || !strings.HasPrefix(typedef, "struct") && typedef != EMPTY && !p.named[name] {
+		return
+	}
+
+	p.PrintLevel(p.terms().NL, fmt.Sprintf("inline std::string go_type_name(const %s*) { return %q; }", name, goname))
+}
+
+//
+// PrintEnum prints a type used as a set of flags as an enum, declared where the type is declared and defined with
+// the constants, and go_flags (see go.h) gives it the bitwise operators that keep the type, like in Go (Read | Write is a Perm)
+//
+func (p *CPrinter) PrintEnum(name, typedef string, names, values []string) {
+	if _, ok := p.Types[name]; ok {
+		p.PrintType(name, typedef)
+		return
+	}
+
+	if names == nil {
+		p.PrintLevel(p.terms().Semi, fmt.Sprintf("enum %s : %s", name, typedef))
+		p.PrintLevel(p.terms().Semi, fmt.Sprintf("template<> struct go_flags<%s> : std::true_type {}", name))
+		return
+	}
+
+	p.PrintLevel(p.terms().NL, fmt.Sprintf("enum %s : %s {", name, typedef))
+	p.UpdateLevel(UP)
+	for i, n := range names {
+		sep := ","
+		if i == len(names)-1 {
+			sep = ""
+		}
+
+		p.PrintLevel(p.terms().NL, fmt.Sprintf("%s = %s%s", n, values[i], sep))
+	}
+	p.UpdateLevel(DOWN)
+	p.PrintLevel(p.terms().Semi, "}")
+}
+
+func (p *CPrinter) PrintStructTags(name string, fields []StructField) {
+	if _, ok := p.Types[name]; ok {
+		return
+	}
+
+	for _, ipath := range p.packages {
+		if ipath == "encoding/json" {
+			p.printJSONCodec(name, fields)
+			break
+		}
 	}
 }
 
@@ -181,6 +477,7 @@ func (p *CPrinter) PrintValue(vtype, typedef, names, values string, ntuple, vtup
 
 	if len(typedef) == 0 {
 		typedef, values = GuessType(values)
+		logInfo(p.log, "guessed type", "names", names, "value", values, "type", typedef)
 	} else if strings.Contains(typedef, "[") {
 		i := strings.Index(typedef, "[")
 		names += typedef[i:]
@@ -188,14 +485,14 @@ func (p *CPrinter) PrintValue(vtype, typedef, names, values string, ntuple, vtup
 	}
 
 	if ntuple && len(values) > 0 {
-		names = fmt.Sprintf("tie(%s)", names)
+		names = fmt.Sprintf("std::tie(%s)", names)
 	}
 
 	p.PrintLevel(NONE, vtype, typedef, names)
 
 	if len(values) > 0 {
 		if vtuple {
-			values = fmt.Sprintf("make_tuple(%s)", values)
+			values = fmt.Sprintf("std::make_tuple(%s)", values)
 		}
 
 		p.Print(" =", values)
@@ -204,26 +501,51 @@ func (p *CPrinter) PrintValue(vtype, typedef, names, values string, ntuple, vtup
 }
 
 func (p *CPrinter) PrintStmt(stmt, expr string) {
-	if stmt == "go" {
-		// start a goroutine (or a thread)
-		p.PrintLevel(SEMI, fmt.Sprintf("Goroutine([](){ %s; })", expr))
+	if stmt == "fallthrough" {
+		// the last statement of a case (see PrintEndCase)
+		p.PrintLevel(p.terms().Semi, "[[fallthrough]]")
+		p.fallsThrough = true
+	} else if stmt == "go" {
+		// start a goroutine (or a thread): the arguments are evaluated by the go statement, so a function literal
+		// (sharing the variables like a Go closure) is bound to them and the other calls capture by value
+		p.PrintLevel(p.terms().Semi, fmt.Sprintf("Goroutine(%s)", formatGoroutine(expr)))
 	} else if stmt == "defer" {
-		p.PrintLevel(SEMI, fmt.Sprintf("Deferred defer%d([](){ %s; })", p.ctx.deferred, expr))
-		p.ctx.deferred++
+		p.PrintLevel(p.terms().Semi, fmt.Sprintf("Deferred %s([](){ %s; })", p.names.New("defer"), expr))
 	} else if len(stmt) > 0 {
-		p.PrintLevel(SEMI, stmt, expr)
+		p.PrintLevel(p.terms().Semi, stmt, expr)
 	} else {
-		p.PrintLevel(SEMI, expr)
+		p.PrintLevel(p.terms().Semi, expr)
+	}
+}
+
+func (p *CPrinter) PrintLabel(name string) {
+	p.PrintLevel(p.terms().NL, name+":")
+}
+
+//
+// PrintGuard prints a std::lock_guard adopting the mutex locked by the previous statement,
+// or a go_scope_exit (see go.h) calling release at the end of the scope
+//
+func (p *CPrinter) PrintGuard(mutex, release string) {
+	if len(mutex) > 0 {
+		p.PrintLevel(p.terms().Semi, fmt.Sprintf("std::lock_guard %s(%s, std::adopt_lock)", p.names.New("guard"), mutex))
+	} else {
+		p.PrintLevel(p.terms().Semi, fmt.Sprintf("go_scope_exit %s([&]{ %s; })", p.names.New("guard"), release))
 	}
 }
 
 func (p *CPrinter) PrintReturn(expr string, tuple bool) {
-	if len(expr) == 0 && len(p.ctx.ret_values) > 0 {
-		expr = p.Chop(p.ctx.ret_values)
+	if ctx := p.ctx.Current(); len(expr) == 0 && ctx != nil && len(ctx.RetValues) > 0 {
+		expr = p.Chop(ctx.RetValues)
 	}
 
 	if tuple {
-		expr = fmt.Sprintf("make_tuple(%s)", expr)
+		expr = fmt.Sprintf("std::make_tuple(%s)", expr)
+	}
+
+	if strings.HasPrefix(expr, "[&](") {
+		// a returned closure outlives the local variables: capture by value
+		expr = "[=]" + expr[3:]
 	}
 
 	p.PrintStmt("return", expr)
@@ -234,27 +556,68 @@ func (p *CPrinter) PrintFunc(receiver, name, params, results string) {
 		// the "main"
 		results = "int"
 		params = "int argc, char **argv"
+
+		if ns, ok := p.packageNamespace("flag"); ok {
+			// pass the command line to the flag package, for flag.Parse (see go_flag.h)
+			p.ctx.Current().RetDefinitions = ns + "::Init(argc, argv);"
+		}
 	} else {
-		if len(results) == 0 {
-			results = "void"
-		} else if IsMultiValue(results) {
-			results = fmt.Sprintf("tuple<%s>", results)
+		if p.ExternC && len(receiver) == 0 && IsPublic(name) {
+			p.export = &exportFunc{ctx: p.ctx.Current(), name: name, params: params, results: results}
 		}
 
+		if len(receiver) == 0 {
+			p.checkTest(name, params, results)
+		}
+
+		results = resultType(results)
+
 		if len(receiver) > 0 {
 			parts := strings.SplitN(receiver, " ", 2)
-			receiver = "/* " + parts[1] + " */ " + strings.TrimRight(parts[0], "*") + "::"
+			if len(parts) == 1 {
+				parts = append(parts, "_") // unnamed receiver
+			}
+
+			rtype := strings.TrimRight(parts[0], "*")
+			receiver = "/* " + parts[1] + " */ " + rtype + "::"
 
-			p.ctx.receiver = parts[1]
+			if p.named[rtype] {
+				// the receiver is a variable (see PrintNamedType)
+				this := "*this"
+				if rtype != parts[0] {
+					this = "this"
+				}
+
+				p.ctx.Current().RetDefinitions = fmt.Sprintf("%s %s = %s;", parts[0], parts[1], this) + p.ctx.Current().RetDefinitions
+			} else {
+				p.ctx.Current().Receiver = parts[1]
+			}
 		}
 	}
 
-	fmt.Fprintf(p.w, "%s %s%s(%s) ", results, receiver, name, params)
+	fmt.Fprintf(p.w, "%s%s %s%s(%s)", p.lineDirective(), results, receiver, name, params)
+}
+
+func (p *CPrinter) PrintPrototype(name, params, results string) {
+	p.PrintLevel(p.terms().Semi, fmt.Sprintf("%s %s(%s)", resultType(results), name, params))
+}
+
+//
+// resultType returns the return type of a function: void, the type of the only result or a tuple
+//
+func resultType(results string) string {
+	if len(results) == 0 {
+		return "void"
+	} else if IsMultiValue(results) {
+		return fmt.Sprintf("std::tuple<%s>", results)
+	}
+
+	return results
 }
 
 func (p *CPrinter) PrintFor(init, cond, post string) {
-	init = strings.TrimRight(init, SEMI)
-	post = strings.TrimRight(post, SEMI)
+	init = strings.TrimRight(init, p.terms().Semi)
+	post = strings.TrimRight(post, p.terms().Semi)
 
 	onlycond := len(init) == 0 && len(post) == 0
 
@@ -276,12 +639,14 @@ func (p *CPrinter) PrintFor(init, cond, post string) {
 		}
 
 	}
-	p.Print(") ")
+	p.Print(")")
 }
 
 func (p *CPrinter) PrintRange(key, value, expr string) {
 	// for maps a std::pair is returned where key is p.first and value is p.second
-	if key == "_" {
+	if len(key) == 0 {
+		key = "_" // for range x
+	} else if key == "_" {
 		key, value = value, ""
 	}
 
@@ -291,80 +656,138 @@ func (p *CPrinter) PrintRange(key, value, expr string) {
 		p.Print(",", value)
 	}
 
-	p.Print(":", expr, ") ")
+	p.Print(":", expr, ")")
 }
 
 func (p *CPrinter) PrintSwitch(init, expr string) {
 	if len(init) > 0 {
-		p.PrintLevel(SEMI, init)
+		p.PrintLevel(p.terms().Semi, init)
 	}
 	p.PrintLevel(NONE, "switch (", expr, ")")
 }
 
 func (p *CPrinter) PrintCase(expr string) {
 	if len(expr) > 0 {
-		p.PrintLevel(COLON, "case", expr)
+		p.PrintLevel(p.terms().Colon, "case", expr)
 	} else {
-		p.PrintLevel(NL, "default:")
+		p.PrintLevel(p.terms().NL, "default:")
 	}
 }
 
 func (p *CPrinter) PrintEndCase() {
-	p.PrintLevel(SEMI, "break") // XXX: need to check for previous fallthrough
+	if p.fallsThrough {
+		// the case continues in the next one
+		p.fallsThrough = false
+		return
+	}
+
+	p.PrintLevel(p.terms().Semi, "break")
 }
 
 func (p *CPrinter) PrintIf(init, cond string) {
+	p.PrintLevel(NONE, "if ")
 	if len(init) > 0 {
-		p.PrintLevel(NONE, init+" if ")
-	} else {
-		p.PrintLevel(NONE, "if ")
+		// the if with initializer (C++17), so that the variables are scoped to the if like in Go
+		p.Print("(", strings.TrimSpace(init), cond, ")")
+		return
 	}
-	p.Print("(", cond, ") ")
+	p.Print("(", cond, ")")
 }
 
 func (p *CPrinter) PrintElse() {
-	p.Print(" else ")
+	if p.style.Braces == BRACE_ALLMAN {
+		p.sameline = false
+		p.Print(p.terms().NL)
+		p.PrintLevel(NONE, "else")
+		return
+	}
+
+	p.Print(" else")
 }
 
 func (p *CPrinter) PrintEmpty() {
-	p.PrintLevel(SEMI, "")
+	p.PrintLevel(p.terms().Semi, "")
 }
 
 func (p *CPrinter) PrintAssignment(lhs, op, rhs string, ltuple, rtuple bool) {
-	if op == ":=" {
+	if ltuple && !rtuple && strings.HasPrefix(rhs, "go_type_assert<") {
+		// v, ok = x.(T) (see FormatTypeAssert)
+		rhs = "go_type_assert_ok<" + strings.TrimPrefix(rhs, "go_type_assert<")
+	}
+
+	if op == ":=" && ltuple {
+		// the new variables are declared by a structured binding
+		lhs = fmt.Sprintf("auto [%s]", lhs)
+		ltuple = false
+		op = "="
+	} else if op == ":=" {
 		// := means there are new variables to be declared (but of course I don't know the real type)
 		rtype, rvalue := GuessType(rhs)
+		logInfo(p.log, "guessed type", "names", lhs, "value", rhs, "type", rtype)
 		lhs = rtype + " " + lhs
 		rhs = rvalue
 		op = "="
 	}
 
 	if ltuple {
-		lhs = fmt.Sprintf("tie(%s)", lhs)
+		lhs = fmt.Sprintf("std::tie(%s)", lhs)
 	}
 
 	if rtuple {
-		rhs = fmt.Sprintf("make_tuple(%s)", rhs)
+		rhs = fmt.Sprintf("std::make_tuple(%s)", rhs)
 	}
 
-	p.PrintLevel(SEMI, lhs, op, rhs)
+	switch op {
+	case "&^=":
+		// AND NOT
+		op = "&="
+		rhs = fmt.Sprintf("~(%s)", rhs)
+
+	case "<<=", ">>=":
+		if _, ok := formatShift(lhs, strings.TrimSuffix(op, "="), rhs); ok {
+			p.PrintLevel(p.terms().Semi, fmt.Sprintf("%s(%s, %s)", assignFuncs[op], lhs, rhs))
+			return
+		}
+
+	case "+=", "-=", "*=":
+		// the overflow wraps around (see go_add_assign in go.h)
+		p.PrintLevel(p.terms().Semi, fmt.Sprintf("%s(%s, %s)", assignFuncs[op], lhs, rhs))
+		return
+	}
+
+	p.PrintLevel(p.terms().Semi, lhs, op, rhs)
 }
 
 func (p *CPrinter) PrintSend(ch, value string) {
-	p.PrintLevel(SEMI, fmt.Sprintf("%s.Send(%s)", ch, value))
+	p.PrintLevel(p.terms().Semi, fmt.Sprintf("%s.Send(%s)", ch, value))
 }
 
 func (p *CPrinter) FormatIdent(id string) (ret string) {
+	if t, ok := p.Types[id]; ok {
+		// unless it's a variable or function with the same name
+		if sym := p.symbols.Lookup(id); sym == nil || sym.Kind == SYM_TYPE {
+			return t
+		}
+	}
+
 	switch id {
 	case NIL:
 		return NULL
 
 	case IOTA:
-		ret = strconv.Itoa(p.ctx.iota)
-		p.ctx.iota += 1
+		ctx := p.ctx.Current()
+		ret = strconv.Itoa(ctx.Iota)
+		ctx.Iota += 1
 
 	case "string":
 		ret = "std::string"
+
+	case "any":
+		if sym := p.symbols.Lookup(id); sym == nil || sym.Kind == SYM_TYPE {
+			ret = ANY // the empty interface, boxed by the runtime
+		} else {
+			ret = id
+		}
 	default:
 		ret = id
 	}
@@ -378,11 +801,17 @@ func (p *CPrinter) FormatLiteral(lit string) string {
 	}
 
 	if lit[0] == '`' {
-		lit = strings.Replace(lit[1:len(lit)-1], `"`, `\\"`, -1)
-		lit = strings.Replace(lit, "\n", "\\n", -1)
+		// raw string: escape backslashes (i.e. in regular expressions), quotes and newlines
+		// (carriage returns are discarded from raw strings)
+		lit = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "").Replace(lit[1 : len(lit)-1])
 		lit = `"` + lit + `"`
 	}
 
+	if lit[len(lit)-1] == 'i' && !strings.HasPrefix(lit, "'") {
+		// imaginary literal
+		return fmt.Sprintf("complex128(0, %s)", lit[:len(lit)-1])
+	}
+
 	return lit
 }
 
@@ -410,15 +839,75 @@ func (p *CPrinter) FormatUnary(op, operand string) string {
 	return fmt.Sprintf("%s%s", op, operand)
 }
 
+func (p *CPrinter) FormatIncDec(operand, op string) string {
+	return fmt.Sprintf("%s%s", operand, op)
+}
+
 func (p *CPrinter) FormatBinary(lhs, op, rhs string) string {
 	if op == "&^" {
 		// AND NOT
 		op = "&"
 		rhs = "~" + rhs
 	}
+
+	if shift, ok := formatShift(lhs, op, rhs); ok {
+		return shift
+	}
+
 	return fmt.Sprintf("%s %s %s", lhs, op, rhs)
 }
 
+// the functions of the arithmetic and shift assignments, that follow the Go rules and evaluate the left side once
+var assignFuncs = map[string]string{
+	"+=":  "go_add_assign",
+	"-=":  "go_sub_assign",
+	"*=":  "go_mul_assign",
+	"<<=": "go_shl_assign",
+	">>=": "go_shr_assign",
+}
+
+//
+// formatShift converts a shift by a count that isn't an integer literal to go_shl or go_shr,
+// that follow the Go rules for the counts larger than the size of the type (see go.h)
+//
+//
+// formatGoroutine returns the function run by a go statement for the call expr (see PrintStmt)
+//
+func formatGoroutine(expr string) string {
+	if strings.HasPrefix(expr, "[&]") {
+		// a lambda called in place (see FormatFuncLit)
+		if end, ok := findMatch(expr, '{'); ok && strings.HasPrefix(expr[end+1:], "(") && strings.HasSuffix(expr, ")") {
+			lambda, args := expr[:end+1], expr[end+2:len(expr)-1]
+			if len(args) == 0 {
+				return lambda
+			}
+
+			return fmt.Sprintf("std::bind(%s, %s)", lambda, args)
+		}
+	}
+
+	return fmt.Sprintf("[=](){ %s; }", expr)
+}
+
+func formatShift(lhs, op, rhs string) (string, bool) {
+	var fun string
+
+	switch op {
+	case "<<":
+		fun = "go_shl"
+	case ">>":
+		fun = "go_shr"
+	default:
+		return "", false
+	}
+
+	if n, err := strconv.ParseUint(rhs, 0, 64); err == nil && n < 64 {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s(%s, %s)", fun, lhs, rhs), true
+}
+
 func (p *CPrinter) FormatPair(v Pair, t FieldType) (ret string) {
 	name, value := v.Name(), v.Value()
 
@@ -444,6 +933,10 @@ func (p *CPrinter) FormatPair(v Pair, t FieldType) (ret string) {
 		value = value[i:] + value[:i]
 	}
 
+	if t == PARAM {
+		value = p.testingParam(value)
+	}
+
 	if t == METHOD {
 		if len(name) == 0 {
 			ret = fmt.Sprintf("// extends %s", value)
@@ -452,12 +945,10 @@ func (p *CPrinter) FormatPair(v Pair, t FieldType) (ret string) {
 		}
 	} else if t == RESULT && len(name) > 0 {
 		ret = fmt.Sprintf("%s /* %s */", value, name)
-		if p.ctx != nil {
-			p.ctx.ret_definitions += fmt.Sprintf("%s %s;", value, name)
-			p.ctx.ret_values += fmt.Sprintf("%s, ", name)
+		if ctx := p.ctx.Current(); ctx != nil {
+			ctx.RetDefinitions += fmt.Sprintf("%s %s;", value, name)
+			ctx.RetValues += fmt.Sprintf("%s, ", name)
 		}
-	} else if t == PARAM && strings.Contains(value, "%s") {
-		ret = fmt.Sprintf(value, name)
 	} else if t == FIELD && len(name) == 0 {
 		ret = getIdentifier(value) + " " + value
 	} else if len(name) > 0 && len(value) > 0 {
@@ -467,28 +958,62 @@ func (p *CPrinter) FormatPair(v Pair, t FieldType) (ret string) {
 	}
 
 	if t == METHOD || t == FIELD {
-		ret = p.indent() + ret + SEMI
+		ret = p.indent() + ret + p.terms().Semi
 	} else {
-		ret += COMMA
+		ret += p.terms().Comma
 	}
 
 	return
 }
 
+//
+// FormatArray returns std::array<elt, len> for the arrays (values, copied like in Go), elt[] for the slices
+//
 func (p *CPrinter) FormatArray(len, elt string) string {
-	return fmt.Sprintf("%s[%s]", elt, len)
+	if len == "" || len == "..." {
+		return fmt.Sprintf("%s[%s]", elt, len)
+	}
+
+	return fmt.Sprintf("std::array<%s, %s>", elt, len)
 }
 
 func (p *CPrinter) FormatArrayIndex(array, index string) string {
 	return fmt.Sprintf("%s[%s]", array, index)
 }
 
+//
+// FormatStringIndex returns the byte at index as uint8, like in Go, instead of the (signed) char of std::string
+//
+func (p *CPrinter) FormatStringIndex(str, index string) string {
+	return fmt.Sprintf("uint8(%s[%s])", str, index)
+}
+
+func (p *CPrinter) FormatGeneric(name, types string) string {
+	return fmt.Sprintf("%s<%s>", name, types)
+}
+
+//
+// FormatSlice returns go_slice(slice, low, high, max) (see go.h), with -1 for the length or capacity
+//
 func (p *CPrinter) FormatSlice(slice, low, high, max string) string {
-	if max == "" {
-		return fmt.Sprintf("%s[%s:%s]", slice, low, high)
-	} else {
-		return fmt.Sprintf("%s[%s:%s:%s]", slice, low, high, max)
+	args := []string{slice, low, high, max}
+	for len(args) > 1 && args[len(args)-1] == "" {
+		args = args[:len(args)-1]
+	}
+
+	for i, arg := range args {
+		if arg != "" {
+			continue
+		}
+
+		if i == 1 {
+			args[i] = "0"
+		} else {
+			args[i] = "-1"
+		}
 	}
+
+	return fmt.Sprintf("go_slice(%s)", strings.Join(args, ", "))
 }
 
 func (p *CPrinter) FormatMap(key, elt string) string {
@@ -503,7 +1028,7 @@ func (p *CPrinter) FormatStruct(fields string) string {
 	if len(fields) > 0 {
 		return fmt.Sprintf("struct {\n%s}", fields)
 	} else {
-		return "struct{}"
+		return EMPTY
 	}
 }
 
@@ -511,7 +1036,7 @@ func (p *CPrinter) FormatInterface(methods string) string {
 	if len(methods) > 0 {
 		return fmt.Sprintf("struct {\n%s}", methods)
 	} else {
-		return "struct{}"
+		return ANY
 	}
 }
 
@@ -531,45 +1056,189 @@ func (p *CPrinter) FormatChan(chdir, mtype string) string {
 }
 
 func (p *CPrinter) FormatCall(fun, args string, isFuncLit bool) string {
-	if strings.HasPrefix(fun, "time::") {
-		// need to rename, to avoid conflicts with C/C++ "time" :(
-		fun = "go_" + fun
+	if p.Unsafe {
+		if call, ok := formatUnsafe(fun, args); ok {
+			return call
+		}
 	}
 
-	if isFuncLit {
-		return fmt.Sprintf("[](%s)->%s", args, fun)
-		//} else if fun == "make" {
-		//	return FormatMake(args)
-	} else {
-		return fmt.Sprintf("%s(%s)", fun, args)
+	// a function literal (lambda) is called in place
+	return fmt.Sprintf("%s(%s)", fun, args)
+}
+
+//
+// formatUnsafe converts unsafe operations and pointer conversions to casts, sizeof/alignof/offsetof
+// and char* arithmetic
+//
+func formatUnsafe(fun, args string) (string, bool) {
+	switch fun {
+	case "void*":
+		return fmt.Sprintf("reinterpret_cast<void*>(%s)", args), true
+
+	case "unsafe::Sizeof":
+		return fmt.Sprintf("sizeof(%s)", args), true
+
+	case "unsafe::Alignof":
+		return fmt.Sprintf("alignof(decltype(%s))", args), true
+
+	case "unsafe::Offsetof":
+		// s.f or s->f
+		if i := strings.LastIndex(args, "->"); i > 0 && i > strings.LastIndex(args, ".") {
+			return fmt.Sprintf("offsetof(std::remove_pointer_t<decltype(%s)>, %s)", args[:i], args[i+2:]), true
+		}
+
+		if i := strings.LastIndex(args, "."); i > 0 {
+			return fmt.Sprintf("offsetof(decltype(%s), %s)", args[:i], args[i+1:]), true
+		}
+
+	case "unsafe::Add":
+		if list := splitList(args); len(list) == 2 {
+			return fmt.Sprintf("reinterpret_cast<void*>(reinterpret_cast<char*>(%s) + (%s))",
+				list[0], strings.TrimSpace(list[1])), true
+		}
+	}
+
+	if strings.HasPrefix(fun, "(*") && strings.HasSuffix(fun, ")") {
+		// (*T)(ptr)
+		return fmt.Sprintf("reinterpret_cast<%s*>(%s)", fun[2:len(fun)-1], args), true
 	}
+
+	return "", false
 }
 
+func (p *CPrinter) FormatSpread(arg string) string {
+	return fmt.Sprintf("%s...", arg)
+}
+
+//
+// FormatFuncType returns std::function<results(params)> for the function values (variables, fields, parameters,
+// results and function types), that can hold functions, lambdas and closures,
+// and "results %s(params)" for the interface methods (without func)
+//
 func (p *CPrinter) FormatFuncType(params, results string, withFunc bool) string {
-	if len(results) == 0 {
-		results = "void"
-	} else if IsMultiValue(results) {
-		results = fmt.Sprintf("tuple<%s>", results)
+	results = resultType(results)
+
+	if withFunc {
+		return fmt.Sprintf("std::function<%s(%s)>", results, params)
 	}
 
-	// add %%s only if withFunc ?
 	return fmt.Sprintf("%s %%s(%s)", results, params)
 }
 
+//
+// splitFuncType returns results and params of std::function<results(params)> (see FormatFuncType)
+//
+func splitFuncType(ftype string) (results, params string, ok bool) {
+	if !strings.HasPrefix(ftype, "std::function<") || !strings.HasSuffix(ftype, ")>") {
+		return "", "", false
+	}
+
+	ftype = ftype[len("std::function<") : len(ftype)-1]
+
+	// the params start at the parenthesis matching the last one (results can be function types)
+	level := 0
+	for i := len(ftype) - 1; i >= 0; i-- {
+		switch ftype[i] {
+		case ')':
+			level++
+		case '(':
+			level--
+			if level == 0 {
+				return ftype[:i], ftype[i+1 : len(ftype)-1], true
+			}
+		}
+	}
+
+	return "", "", false
+}
+
+//
+// FormatFuncLit returns a lambda capturing by reference, like a Go closure, that converts to std::function
+//
 func (p *CPrinter) FormatFuncLit(ftype, body string) string {
-	return fmt.Sprintf(ftype+"%s", "", body)
+	if results, params, ok := splitFuncType(ftype); ok {
+		return fmt.Sprintf("[&](%s) -> %s %s", params, results, body)
+	}
+
+	return fmt.Sprintf("[&]() %s", body)
 }
 
 func (p *CPrinter) FormatSelector(pname, sel string, isObject bool) string {
 	if isObject {
-		return fmt.Sprintf("%s%s", p.ctx.Selector(pname), sel)
-	} else {
-		return fmt.Sprintf("%s::%s", pname, sel)
+		return fmt.Sprintf("%s%s", p.selector(pname), sel)
 	}
+
+	if ipath, ok := p.packages[pname]; ok {
+		if ipath == "unsafe" && p.Unsafe {
+			if sel == "Pointer" {
+				return "void*"
+			}
+
+			// see formatUnsafe
+			return "unsafe::" + sel
+		}
+
+		if t, ok := p.Types[ipath+"."+sel]; ok {
+			return t
+		}
+
+		if m, ok := p.imports()[ipath]; ok {
+			if s, ok := m.Symbols[sel]; ok {
+				return s
+			}
+
+			if len(m.Namespace) > 0 {
+				pname = m.Namespace
+			} else {
+				pname = pathpkg.Base(ipath)
+			}
+		} else if base := pathpkg.Base(ipath); base != pname && token.IsIdentifier(base) {
+			// imported with a different name, use the namespace of the package
+			pname = base
+		}
+	}
+
+	return fmt.Sprintf("%s::%s", p.MangleName(pname), sel)
+}
+
+//
+// packageNamespace returns the namespace of a package (by import path), if the file imports it
+//
+func (p *CPrinter) packageNamespace(ipath string) (string, bool) {
+	for _, path := range p.packages {
+		if path == ipath {
+			if ns := p.imports()[ipath].Namespace; len(ns) > 0 {
+				return ns, true
+			}
+
+			return p.MangleName(pathpkg.Base(ipath)), true
+		}
+	}
+
+	return "", false
+}
+
+func (p *CPrinter) FormatAtomic(mtype string) string {
+	if strings.HasPrefix(mtype, "*") {
+		// pointers are *T in the parsed types, see FormatPair
+		i := strings.LastIndex(mtype, "*") + 1
+		mtype = mtype[i:] + mtype[:i]
+	}
+
+	return fmt.Sprintf("std::atomic<%s>", mtype)
+}
+
+func (p *CPrinter) FormatUnsupported(what string) string {
+	// see go_unsupported in go.h
+	return fmt.Sprintf("go_unsupported(%q)", what)
 }
 
 func (p *CPrinter) FormatTypeAssert(orig, assert string) string {
-	return fmt.Sprintf("%s.(%s)", orig, assert)
+	if len(assert) == 0 {
+		return fmt.Sprintf("%s.(type)", orig)
+	}
+
+	return fmt.Sprintf("go_type_assert<%s>(%s)", assert, orig)
 }
 
 //
@@ -595,6 +1264,10 @@ func GuessType(value string) (string, string) {
 		return "string", value
 
 	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9', '.':
+		if strings.Contains(value, "complex128(") {
+			// with an imaginary literal
+			return "complex128", value
+		}
 		if strings.Contains(value, ".") || strings.Contains(value, "E") {
 			return "float64", value
 		}
@@ -631,6 +1304,13 @@ func GuessType(value string) (string, string) {
 		}
 	}
 
+	//
+	// a lambda (see FormatFuncLit), not an array
+	//
+	if strings.HasPrefix(value, "[") {
+		return vtype, value
+	}
+
 	//
 	// an array
 	//
@@ -0,0 +1,70 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//
+// testFunc is a test function (func TestXxx(t *testing.T)) waiting to be registered
+// with the test framework, when the function context is closed
+//
+type testFunc struct {
+	ctx  *Context
+	name string
+}
+
+//
+// testingNamespace returns the namespace of the testing package, if the file imports it
+//
+func (p *CPrinter) testingNamespace() (string, bool) {
+	return p.packageNamespace("testing")
+}
+
+//
+// testingParam passes *testing.T and *testing.B parameters by reference,
+// so that t.Errorf(...) is valid C++
+//
+func (p *CPrinter) testingParam(ptype string) string {
+	if ns, ok := p.testingNamespace(); ok && (ptype == ns+"::T*" || ptype == ns+"::B*") {
+		return strings.TrimSuffix(ptype, "*") + "&"
+	}
+
+	return ptype
+}
+
+//
+// isTest returns true for the name of a test function (TestXxx, but not TestMain)
+//
+func isTest(name string) bool {
+	if !strings.HasPrefix(name, "Test") || name == "TestMain" {
+		return false
+	}
+
+	r, _ := utf8.DecodeRuneInString(name[4:])
+	return len(name) == 4 || !unicode.IsLower(r)
+}
+
+//
+// checkTest records a test function, to be registered by printTest
+//
+func (p *CPrinter) checkTest(name, params, results string) {
+	ns, ok := p.testingNamespace()
+	if !ok || !isTest(name) || len(results) > 0 {
+		return
+	}
+
+	if strings.HasPrefix(params, ns+"::T& ") && !strings.Contains(params, ",") {
+		p.test = &testFunc{ctx: p.ctx.Current(), name: name}
+	}
+}
+
+//
+// printTest registers a test function with the test framework (see WALKNGO_TEST in go_testing.h)
+//
+func (p *CPrinter) printTest(f *testFunc) {
+	p.Print(p.terms().NL)
+	p.PrintLevel(p.terms().NL, fmt.Sprintf("WALKNGO_TEST(%s, %s)", p.pkg, f.name))
+}
@@ -2,7 +2,10 @@ package printer
 
 import (
 	"fmt"
+	"go/token"
 	"io"
+	"log/slog"
+	"strconv"
 )
 
 //
@@ -16,6 +19,18 @@ func (d *DebugPrinter) Reset() {
 	d.P.Reset()
 }
 
+func (d *DebugPrinter) Capabilities() Capability {
+	return d.P.Capabilities()
+}
+
+func (d *DebugPrinter) ReservedWords() map[string]bool {
+	return d.P.ReservedWords()
+}
+
+func (d *DebugPrinter) MangleName(name string) string {
+	return d.P.MangleName(name)
+}
+
 func (d *DebugPrinter) PushContext() {
 	d.P.PushContext()
 }
@@ -24,10 +39,30 @@ func (d *DebugPrinter) PopContext() {
 	d.P.PopContext()
 }
 
+func (d *DebugPrinter) ReserveNames(names ...string) {
+	d.P.ReserveNames(names...)
+}
+
+func (d *DebugPrinter) SetSymbols(symbols *SymbolTable) {
+	d.P.SetSymbols(symbols)
+}
+
+func (d *DebugPrinter) SetPosition(pos token.Position) {
+	d.P.SetPosition(pos)
+}
+
 func (d *DebugPrinter) SetWriter(w io.Writer) {
 	d.P.SetWriter(w)
 }
 
+func (d *DebugPrinter) SetStyle(style FormatStyle) {
+	d.P.SetStyle(style)
+}
+
+func (d *DebugPrinter) SetLogger(logger *slog.Logger) {
+	d.P.SetLogger(logger)
+}
+
 func (d *DebugPrinter) UpdateLevel(delta int) {
 	d.P.UpdateLevel(delta)
 }
@@ -70,11 +105,66 @@ func (d *DebugPrinter) PrintImport(name, path string) {
 	d.P.PrintImport(name, path)
 }
 
+func (d *DebugPrinter) PrintImportsEnd() {
+	fmt.Println("/* PrintImportsEnd */")
+	d.P.PrintImportsEnd()
+}
+
+func (d *DebugPrinter) PrintNamespace(name string) {
+	fmt.Println("/* PrintNamespace", name, "*/")
+	d.P.PrintNamespace(name)
+}
+
+func (d *DebugPrinter) PrintNamespaceEnd(name string) {
+	fmt.Println("/* PrintNamespaceEnd", name, "*/")
+	d.P.PrintNamespaceEnd(name)
+}
+
 func (d *DebugPrinter) PrintType(name, typedef string) {
 	fmt.Println("/* PrintType", name, typedef, "*/")
 	d.P.PrintType(name, typedef)
 }
 
+func (d *DebugPrinter) PrintNamedType(name, typedef string, methods []Method) {
+	fmt.Println("/* PrintNamedType", name, typedef, methods, "*/")
+	d.P.PrintNamedType(name, typedef, methods)
+}
+
+func (d *DebugPrinter) PrintStruct(name, fields string, methods []Method) {
+	fmt.Println("/* PrintStruct", name, fields, methods, "*/")
+	d.P.PrintStruct(name, fields, methods)
+}
+
+func (d *DebugPrinter) PrintEnum(name, typedef string, names, values []string) {
+	fmt.Println("/* PrintEnum", name, typedef, names, values, "*/")
+	d.P.PrintEnum(name, typedef, names, values)
+}
+
+func (d *DebugPrinter) PrintStructTags(name string, fields []StructField) {
+	fmt.Println("/* PrintStructTags", name, fields, "*/")
+	d.P.PrintStructTags(name, fields)
+}
+
+func (d *DebugPrinter) PrintComparable(name string, fields []string) {
+	fmt.Println("/* PrintComparable", name, fields, "*/")
+	d.P.PrintComparable(name, fields)
+}
+
+func (d *DebugPrinter) PrintTypeName(name, typedef, goname string) {
+	fmt.Println("/* PrintTypeName", name, typedef, goname, "*/")
+	d.P.PrintTypeName(name, typedef, goname)
+}
+
+func (d *DebugPrinter) PrintDoc(doc string) {
+	fmt.Println("/* PrintDoc", strconv.Quote(doc), "*/")
+	d.P.PrintDoc(doc)
+}
+
+func (d *DebugPrinter) PrintComment(comment string) {
+	fmt.Println("/* PrintComment", strconv.Quote(comment), "*/")
+	d.P.PrintComment(comment)
+}
+
 func (d *DebugPrinter) PrintValue(vtype, typedef, names, values string, ntuple, vtuple bool) {
 	fmt.Println("/* PrintValue", vtype, typedef, names, values, ntuple, vtuple, "*/")
 	d.P.PrintValue(vtype, typedef, names, values, ntuple, vtuple)
@@ -85,6 +175,16 @@ func (d *DebugPrinter) PrintStmt(stmt, expr string) {
 	d.P.PrintStmt(stmt, expr)
 }
 
+func (d *DebugPrinter) PrintLabel(name string) {
+	fmt.Println("/* PrintLabel", name, "*/")
+	d.P.PrintLabel(name)
+}
+
+func (d *DebugPrinter) PrintGuard(mutex, release string) {
+	fmt.Println("/* PrintGuard", mutex, release, "*/")
+	d.P.PrintGuard(mutex, release)
+}
+
 func (d *DebugPrinter) PrintReturn(expr string, tuple bool) {
 	fmt.Println("/* PrintReturn", expr, tuple, "*/")
 	d.P.PrintReturn(expr, tuple)
@@ -95,6 +195,11 @@ func (d *DebugPrinter) PrintFunc(receiver, name, params, results string) {
 	d.P.PrintFunc(receiver, name, params, results)
 }
 
+func (d *DebugPrinter) PrintPrototype(name, params, results string) {
+	fmt.Println("/* PrintPrototype", name, params, results, "*/")
+	d.P.PrintPrototype(name, params, results)
+}
+
 func (d *DebugPrinter) PrintFor(init, cond, post string) {
 	fmt.Println("/* PrintFor", init, cond, post, "*/")
 	d.P.PrintFor(init, cond, post)
@@ -180,6 +285,11 @@ func (d *DebugPrinter) FormatUnary(op, operand string) string {
 	return d.P.FormatUnary(op, operand)
 }
 
+func (d *DebugPrinter) FormatIncDec(operand, op string) string {
+	fmt.Println("/* FormatIncDec", operand, op, "*/")
+	return d.P.FormatIncDec(operand, op)
+}
+
 func (d *DebugPrinter) FormatBinary(lhs, op, rhs string) string {
 	fmt.Println("/* FormatBinary", lhs, op, rhs, "*/")
 	return d.P.FormatBinary(lhs, op, rhs)
@@ -200,6 +310,16 @@ func (d *DebugPrinter) FormatArrayIndex(array, index string) string {
 	return d.P.FormatArrayIndex(array, index)
 }
 
+func (d *DebugPrinter) FormatStringIndex(str, index string) string {
+	fmt.Println("/* FormatStringIndex", str, index, "*/")
+	return d.P.FormatStringIndex(str, index)
+}
+
+func (d *DebugPrinter) FormatGeneric(name, types string) string {
+	fmt.Println("/* FormatGeneric", name, types, "*/")
+	return d.P.FormatGeneric(name, types)
+}
+
 func (d *DebugPrinter) FormatSlice(slice, low, high, max string) string {
 	fmt.Println("/* FormatSlice", low, high, max, "*/")
 	return d.P.FormatSlice(slice, low, high, max)
@@ -235,6 +355,11 @@ func (d *DebugPrinter) FormatCall(fun, args string, isFuncLit bool) string {
 	return d.P.FormatCall(fun, args, isFuncLit)
 }
 
+func (d *DebugPrinter) FormatSpread(arg string) string {
+	fmt.Println("/* FormatSpread", arg, "*/")
+	return d.P.FormatSpread(arg)
+}
+
 func (d *DebugPrinter) FormatFuncType(params, results string, withFunc bool) string {
 	fmt.Println("/* FormatFuncType", params, results, withFunc, "*/")
 	return d.P.FormatFuncType(params, results, withFunc)
@@ -254,3 +379,13 @@ func (d *DebugPrinter) FormatTypeAssert(orig, assert string) string {
 	fmt.Println("/* FormatTypeAssert", orig, assert, "*/")
 	return d.P.FormatTypeAssert(orig, assert)
 }
+
+func (d *DebugPrinter) FormatAtomic(mtype string) string {
+	fmt.Println("/* FormatAtomic", mtype, "*/")
+	return d.P.FormatAtomic(mtype)
+}
+
+func (d *DebugPrinter) FormatUnsupported(what string) string {
+	fmt.Println("/* FormatUnsupported", what, "*/")
+	return d.P.FormatUnsupported(what)
+}
@@ -2,7 +2,9 @@ package printer
 
 import (
 	"fmt"
+	"go/token"
 	"io"
+	"log/slog"
 	"strings"
 )
 
@@ -15,23 +17,78 @@ type GoPrinter struct {
 	level    int
 	sameline bool
 	w        io.Writer
+	out      outBuffer
+	style    FormatStyle
+	indents  indentCache
+	log      *slog.Logger
+	symbols  *SymbolTable
+	pos      token.Position
+
+	ctx   ContextStack
+	names Names
 }
 
 func (p *GoPrinter) Reset() {
 	p.level = 0
 	p.sameline = false
+
+	p.ctx.Reset()
+	p.names.Reset()
+}
+
+func (p *GoPrinter) Capabilities() Capability {
+	// type parameters are not passed to the printer (yet)
+	return CAP_GOROUTINES | CAP_DEFER | CAP_CHANNELS | CAP_RANGE_INT | CAP_LOOPVAR | CAP_ANON_TYPES | CAP_SWITCH_ANY | CAP_GOTO
+}
+
+func (p *GoPrinter) ReservedWords() map[string]bool {
+	return nil // the Go identifiers are never keywords
+}
+
+func (p *GoPrinter) MangleName(name string) string {
+	return name
 }
 
 func (p *GoPrinter) PushContext() {
+	p.ctx.Push()
 }
 
 func (p *GoPrinter) PopContext() {
+	p.ctx.Pop()
+}
+
+func (p *GoPrinter) ReserveNames(names ...string) {
+	p.names.Reserve(names...)
+}
+
+//
+// terms returns the terminators for the current style
+//
+func (p *GoPrinter) terms() Terminators {
+	return p.style.Terms.Or(DefaultTerminators)
+}
+
+func (p *GoPrinter) SetSymbols(symbols *SymbolTable) {
+	p.symbols = symbols
+}
+
+func (p *GoPrinter) SetPosition(pos token.Position) {
+	p.pos = pos
 }
 
 func (p *GoPrinter) SetWriter(w io.Writer) {
 	p.w = w
 }
 
+func (p *GoPrinter) SetStyle(style FormatStyle) {
+	p.style = style
+	p.indents = nil
+}
+
+func (p *GoPrinter) SetLogger(logger *slog.Logger) {
+	p.log = logger
+}
+
 func (p *GoPrinter) UpdateLevel(delta int) {
 	p.level += delta
 }
@@ -45,24 +102,25 @@ func (p *GoPrinter) IsSameLine() bool {
 }
 
 func (p *GoPrinter) Chop(line string) string {
-	return strings.TrimRight(line, COMMA)
+	return strings.TrimRight(line, p.terms().Comma)
 }
 
 func (p *GoPrinter) indent() string {
 	if p.sameline {
+		// continue the statement header (i.e. the opening brace or the if after else)
 		p.sameline = false
-		return ""
+		return " "
 	}
 
-	return strings.Repeat("  ", p.level)
+	return p.indents.level(p.style, p.level)
 }
 
 func (p *GoPrinter) Print(values ...string) {
-	fmt.Fprint(p.w, strings.Join(values, " "))
+	p.out.writeValues(p.w, "", values, NONE)
 }
 
 func (p *GoPrinter) PrintLevel(term string, values ...string) {
-	fmt.Fprint(p.w, p.indent(), strings.Join(values, " "), term)
+	p.out.writeValues(p.w, p.indent(), values, term)
 }
 
 func (p *GoPrinter) PrintBlockStart(b BlockType) {
@@ -75,7 +133,12 @@ func (p *GoPrinter) PrintBlockStart(b BlockType) {
 		open = "{"
 	}
 
-	p.PrintLevel(NL, open)
+	if b == CODE && p.sameline && p.style.Braces == BRACE_ALLMAN {
+		p.sameline = false
+		p.Print(p.terms().NL)
+	}
+
+	p.PrintLevel(p.terms().NL, open)
 	p.UpdateLevel(UP)
 }
 
@@ -94,15 +157,68 @@ func (p *GoPrinter) PrintBlockEnd(b BlockType) {
 }
 
 func (p *GoPrinter) PrintPackage(name string) {
-	p.PrintLevel(NL, "package", name)
+	p.PrintLevel(p.terms().NL, "package", name)
 }
 
 func (p *GoPrinter) PrintImport(name, path string) {
-	p.PrintLevel(NL, "import", name, path)
+	p.PrintLevel(p.terms().NL, "import", name, path)
+}
+
+func (p *GoPrinter) PrintImportsEnd() {
+	// the imports are printed as they are
+}
+
+func (p *GoPrinter) PrintNamespace(name string) {
+	// the package is the namespace
+}
+
+func (p *GoPrinter) PrintNamespaceEnd(name string) {
 }
 
 func (p *GoPrinter) PrintType(name, typedef string) {
-	p.PrintLevel(NL, "type", name, typedef)
+	p.PrintLevel(p.terms().NL, "type", name, typedef)
+}
+
+func (p *GoPrinter) PrintNamedType(name, typedef string, methods []Method) {
+	p.PrintType(name, typedef)
+}
+
+func (p *GoPrinter) PrintStruct(name, fields string, methods []Method) {
+	p.PrintType(name, p.FormatStruct(fields))
+}
+
+func (p *GoPrinter) PrintEnum(name, typedef string, names, values []string) {
+	if names == nil {
+		p.PrintType(name, typedef)
+		return
+	}
+
+	for i, n := range names {
+		p.PrintValue("const", name, n, values[i], false, false)
+	}
+}
+
+func (p *GoPrinter) PrintStructTags(name string, fields []StructField) {
+}
+
+func (p *GoPrinter) PrintComparable(name string, fields []string) {
+}
+
+func (p *GoPrinter) PrintTypeName(name, typedef, goname string) {
+}
+
+func (p *GoPrinter) PrintDoc(doc string) {
+	p.PrintComment(doc)
+}
+
+func (p *GoPrinter) PrintComment(comment string) {
+	for _, line := range docLines(comment) {
+		if !strings.HasPrefix(line, "\t") {
+			line = " " + line // as gofmt does, no space before a tab
+		}
+
+		p.PrintLevel(p.terms().NL, strings.TrimRight("//"+line, " "))
+	}
 }
 
 func (p *GoPrinter) PrintValue(vtype, typedef, names, values string, ntuple, vtuple bool) {
@@ -118,12 +234,20 @@ func (p *GoPrinter) PrintValue(vtype, typedef, names, values string, ntuple, vtu
 
 func (p *GoPrinter) PrintStmt(stmt, expr string) {
 	if len(stmt) > 0 {
-		p.PrintLevel(NL, stmt, expr)
+		p.PrintLevel(p.terms().NL, stmt, expr)
 	} else {
-		p.PrintLevel(NL, expr)
+		p.PrintLevel(p.terms().NL, expr)
 	}
 }
 
+func (p *GoPrinter) PrintLabel(name string) {
+	p.PrintLevel(p.terms().NL, name+":")
+}
+
+func (p *GoPrinter) PrintGuard(mutex, release string) {
+	p.PrintStmt("defer", release)
+}
+
 func (p *GoPrinter) PrintReturn(expr string, tuple bool) {
 	p.PrintStmt("return", expr)
 }
@@ -133,36 +257,36 @@ func (p *GoPrinter) PrintFunc(receiver, name, params, results string) {
 	if len(receiver) > 0 {
 		fmt.Fprintf(p.w, "(%s) ", receiver)
 	}
-	fmt.Fprintf(p.w, "%s(%s) ", name, params)
+	fmt.Fprintf(p.w, "%s(%s)", name, params)
 	if len(results) > 0 {
 		if strings.ContainsAny(results, " ,") {
 			// name type or multiple types
-			fmt.Fprintf(p.w, "(%s) ", results)
+			fmt.Fprintf(p.w, " (%s)", results)
 		} else {
-			p.Print(results, "")
+			p.Print("", results)
 		}
 	}
 }
 
+func (p *GoPrinter) PrintPrototype(name, params, results string) {
+	// functions can be used before their definition
+}
+
 func (p *GoPrinter) PrintFor(init, cond, post string) {
-	p.PrintLevel(NONE, "for ")
-	if len(init) > 0 {
-		p.Print(init)
-	}
+	header := cond
 	if len(init) > 0 || len(post) > 0 {
-		p.Print("; ")
-	}
-
-	p.Print(cond)
-
-	if len(post) > 0 {
-		p.Print(";", post)
+		header = init + "; " + cond + "; " + post
 	}
 
-	p.Print("")
+	p.PrintLevel(NONE, strings.TrimSpace("for "+header))
 }
 
 func (p *GoPrinter) PrintRange(key, value, expr string) {
+	if len(key) == 0 {
+		p.PrintLevel(NONE, "for range", expr)
+		return
+	}
+
 	p.PrintLevel(NONE, "for", key)
 
 	if len(value) > 0 {
@@ -174,18 +298,19 @@ func (p *GoPrinter) PrintRange(key, value, expr string) {
 }
 
 func (p *GoPrinter) PrintSwitch(init, expr string) {
-	p.PrintLevel(NONE, "switch ")
+	header := expr
 	if len(init) > 0 {
-		p.Print(init + "; ")
+		header = init + "; " + expr
 	}
-	p.Print(expr)
+
+	p.PrintLevel(NONE, strings.TrimSpace("switch "+header))
 }
 
 func (p *GoPrinter) PrintCase(expr string) {
 	if len(expr) > 0 {
-		p.PrintLevel(COLON, "case", expr)
+		p.PrintLevel(p.terms().Colon, "case", expr)
 	} else {
-		p.PrintLevel(NL, "default:")
+		p.PrintLevel(p.terms().NL, "default:")
 	}
 }
 
@@ -194,27 +319,34 @@ func (p *GoPrinter) PrintEndCase() {
 }
 
 func (p *GoPrinter) PrintIf(init, cond string) {
-	p.PrintLevel(NONE, "if ")
 	if len(init) > 0 {
-		p.Print(init + "; ")
+		cond = init + "; " + cond
 	}
-	p.Print(cond, "")
+
+	p.PrintLevel(NONE, "if", cond)
 }
 
 func (p *GoPrinter) PrintElse() {
-	p.Print(" else ")
+	if p.style.Braces == BRACE_ALLMAN {
+		p.sameline = false
+		p.Print(p.terms().NL)
+		p.PrintLevel(NONE, "else")
+		return
+	}
+
+	p.Print(" else")
 }
 
 func (p *GoPrinter) PrintEmpty() {
-	p.PrintLevel(SEMI, "")
+	p.PrintLevel(p.terms().Semi, "")
 }
 
 func (p *GoPrinter) PrintAssignment(lhs, op, rhs string, ltuple, rtuple bool) {
-	p.PrintLevel(NL, lhs, op, rhs)
+	p.PrintLevel(p.terms().NL, lhs, op, rhs)
 }
 
 func (p *GoPrinter) PrintSend(ch, value string) {
-	p.PrintLevel(SEMI, ch, "<-", value)
+	p.PrintLevel(p.terms().Semi, ch, "<-", value)
 }
 
 func (p *GoPrinter) FormatIdent(id string) string {
@@ -245,6 +377,10 @@ func (p *GoPrinter) FormatUnary(op, operand string) string {
 	return fmt.Sprintf("%s%s", op, operand)
 }
 
+func (p *GoPrinter) FormatIncDec(operand, op string) string {
+	return fmt.Sprintf("%s%s", operand, op)
+}
+
 func (p *GoPrinter) FormatBinary(lhs, op, rhs string) string {
 	return fmt.Sprintf("%s %s %s", lhs, op, rhs)
 }
@@ -252,11 +388,11 @@ func (p *GoPrinter) FormatBinary(lhs, op, rhs string) string {
 func (p *GoPrinter) FormatPair(v Pair, t FieldType) string {
 	switch t {
 	case METHOD:
-		return p.indent() + v.Name() + v.Value() + NL
+		return p.indent() + v.Name() + v.Value() + p.terms().NL
 	case FIELD:
-		return p.indent() + v.String() + NL
+		return p.indent() + v.String() + p.terms().NL
 	default:
-		return v.String() + COMMA
+		return v.String() + p.terms().Comma
 	}
 }
 
@@ -268,6 +404,14 @@ func (p *GoPrinter) FormatArrayIndex(array, index string) string {
 	return fmt.Sprintf("%s[%s]", array, index)
 }
 
+func (p *GoPrinter) FormatStringIndex(str, index string) string {
+	return p.FormatArrayIndex(str, index)
+}
+
+func (p *GoPrinter) FormatGeneric(name, types string) string {
+	return fmt.Sprintf("%s[%s]", name, types)
+}
+
 func (p *GoPrinter) FormatSlice(slice, low, high, max string) string {
 	if max == "" {
 		return fmt.Sprintf("%s[%s:%s]", slice, low, high)
@@ -277,7 +421,7 @@ func (p *GoPrinter) FormatSlice(slice, low, high, max string) string {
 }
 
 func (p *GoPrinter) FormatMap(key, elt string) string {
-	return fmt.Sprintf("[%s]%s", key, elt)
+	return fmt.Sprintf("map[%s]%s", key, elt)
 }
 
 func (p *GoPrinter) FormatKeyValue(key, value string) string {
@@ -308,6 +452,10 @@ func (p *GoPrinter) FormatCall(fun, args string, isFuncLit bool) string {
 	return fmt.Sprintf("%s(%s)", fun, args)
 }
 
+func (p *GoPrinter) FormatSpread(arg string) string {
+	return fmt.Sprintf("%s...", arg)
+}
+
 func (p *GoPrinter) FormatFuncType(params, results string, withFunc bool) string {
 	prefix := ""
 	if withFunc {
@@ -329,7 +477,7 @@ func (p *GoPrinter) FormatFuncType(params, results string, withFunc bool) string
 }
 
 func (p *GoPrinter) FormatFuncLit(ftype, body string) string {
-	return fmt.Sprintf("func%s %s", ftype, body)
+	return fmt.Sprintf("%s %s", ftype, body)
 }
 
 func (p *GoPrinter) FormatSelector(pname, sel string, isObject bool) string {
@@ -337,5 +485,17 @@ func (p *GoPrinter) FormatSelector(pname, sel string, isObject bool) string {
 }
 
 func (p *GoPrinter) FormatTypeAssert(orig, assert string) string {
+	if len(assert) == 0 {
+		assert = "type"
+	}
+
 	return fmt.Sprintf("%s.(%s)", orig, assert)
 }
+
+func (p *GoPrinter) FormatAtomic(mtype string) string {
+	return mtype
+}
+
+func (p *GoPrinter) FormatUnsupported(what string) string {
+	return fmt.Sprintf("panic(%q)", "unsupported "+what)
+}
@@ -0,0 +1,99 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+//
+// ImportMapping describes how an imported Go package is converted
+//
+type ImportMapping struct {
+	Include   string            `json:"include,omitempty"`   // target include or module (i.e. "<fmt.h>"), empty for none
+	Includes  []string          `json:"includes,omitempty"`  // additional target includes (i.e. a header for each .proto file)
+	Namespace string            `json:"namespace,omitempty"` // target namespace for the package symbols (empty for the package name)
+	Symbols   map[string]string `json:"symbols,omitempty"`   // renamed package symbols (i.e. "Get": "http_shim::get")
+	Packages  map[string]string `json:"packages,omitempty"`  // dependency manager packages providing the include (i.e. "conan": "cpr/1.10.5", "vcpkg": "cpr")
+	Module    string            `json:"module,omitempty"`    // C++20 module to import, with CPrinter.Modules (i.e. "a_util")
+}
+
+//
+// ImportMap maps Go import paths to their conversion
+//
+type ImportMap map[string]ImportMapping
+
+//
+// CImports are the packages implemented in the C++ runtime
+//
+var CImports = ImportMap{
+	"fmt":    {Include: "<fmt.h>"},
+	"sync":   {Include: "<sync.h>"},
+	"errors": {Include: "<errors.h>"},
+	"flag":   {Include: "<go_flag.h>"},
+	"io":     {Include: "<go_io.h>"},
+	"time":   {Include: "<go_time.h>", Namespace: "go_time"}, // "time" conflicts with C/C++ time()
+	"log":    {Include: "<go_log.h>", Namespace: "go_log"},   // "log" conflicts with C/C++ log()
+
+	"log/slog":      {Include: "<slog.h>"},
+	"math/bits":     {Include: "<math_bits.h>"},
+	"reflect":       {Include: "<reflect.h>"},
+	"regexp":        {Include: "<regexp.h>"},
+	"sync/atomic":   {Include: "<sync_atomic.h>"},
+	"encoding/json": {Include: "<encoding_json.h>", Packages: map[string]string{"conan": "nlohmann_json/3.11.3", "vcpkg": "nlohmann-json"}},
+	"net/http":      {Include: "<net_http.h>", Packages: map[string]string{"conan": "libcurl/8.6.0", "vcpkg": "curl"}}, // or cpr, see net_http.h
+
+	// "testing" conflicts with GoogleTest
+	"testing": {Include: "<go_testing.h>", Namespace: "go_testing", Packages: map[string]string{"conan": "gtest/1.14.0", "vcpkg": "gtest"}},
+}
+
+//
+// RE2Imports replace the std::regex implementation of regexp with RE2 (that has the same syntax as Go)
+//
+var RE2Imports = ImportMap{
+	"regexp": {Include: "<regexp_re2.h>", Packages: map[string]string{"conan": "re2/20230301", "vcpkg": "re2"}},
+}
+
+//
+// LoadImportMap reads an ImportMap from a JSON file in the form:
+//
+//	{
+//	  "net/http": {
+//	    "include": "\"http_shim.h\"",
+//	    "namespace": "http_shim",
+//	    "symbols": { "StatusOK": "200" },
+//	    "packages": { "conan": "cpr/1.10.5", "vcpkg": "cpr" }
+//	  }
+//	}
+//
+func LoadImportMap(filename string) (ImportMap, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var m ImportMap
+
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	return m, nil
+}
+
+//
+// With returns a new map with the entries of m, replaced or extended by the ones in other
+//
+func (m ImportMap) With(other ImportMap) ImportMap {
+	merged := make(ImportMap, len(m)+len(other))
+
+	for k, v := range m {
+		merged[k] = v
+	}
+
+	for k, v := range other {
+		merged[k] = v
+	}
+
+	return merged
+}
@@ -0,0 +1,75 @@
+package printer
+
+import (
+	"strconv"
+)
+
+//
+// Names generates synthetic identifiers (deferred callbacks, temporaries, renamed variables)
+// making sure they never clash with the identifiers used in the source or with each other.
+//
+// The zero value is ready to use.
+//
+type Names struct {
+	used     map[string]bool
+	counters map[string]int
+}
+
+//
+// Reset forgets all reserved and generated names
+//
+func (n *Names) Reset() {
+	n.used = nil
+	n.counters = nil
+}
+
+//
+// Reserve marks names as used (i.e. identifiers declared in the source)
+//
+func (n *Names) Reserve(names ...string) {
+	if n.used == nil {
+		n.used = map[string]bool{}
+	}
+
+	for _, name := range names {
+		n.used[name] = true
+	}
+}
+
+//
+// IsUsed returns true if name was reserved or generated
+//
+func (n *Names) IsUsed(name string) bool {
+	return n.used[name]
+}
+
+//
+// New returns a new name in the form prefix + counter (i.e. defer0, defer1, ...)
+//
+func (n *Names) New(prefix string) string {
+	if n.counters == nil {
+		n.counters = map[string]int{}
+	}
+
+	for {
+		name := prefix + strconv.Itoa(n.counters[prefix])
+		n.counters[prefix]++
+
+		if !n.IsUsed(name) {
+			n.Reserve(name)
+			return name
+		}
+	}
+}
+
+//
+// Unique returns name if it's not used yet, otherwise a new name in the form name_N
+//
+func (n *Names) Unique(name string) string {
+	if !n.IsUsed(name) {
+		n.Reserve(name)
+		return name
+	}
+
+	return n.New(name + "_")
+}
@@ -0,0 +1,43 @@
+package printer
+
+import (
+	"io"
+)
+
+//
+// outBuffer builds the fragments written by a printer, so that each one is written with a single Write
+// and without allocations (the buffer is reused, the writers copy what they keep)
+//
+type outBuffer []byte
+
+//
+// write writes the concatenation of parts to w
+//
+func (b *outBuffer) write(w io.Writer, parts ...string) {
+	buf := (*b)[:0]
+	for _, s := range parts {
+		buf = append(buf, s...)
+	}
+
+	w.Write(buf)
+	*b = buf
+}
+
+//
+// writeValues writes head, the values separated by spaces and term to w
+//
+func (b *outBuffer) writeValues(w io.Writer, head string, values []string, term string) {
+	buf := append((*b)[:0], head...)
+	for i, v := range values {
+		if i > 0 {
+			buf = append(buf, ' ')
+		}
+
+		buf = append(buf, v...)
+	}
+
+	buf = append(buf, term...)
+
+	w.Write(buf)
+	*b = buf
+}
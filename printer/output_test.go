@@ -0,0 +1,75 @@
+package printer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type benchLine struct {
+	level  int
+	values []string
+}
+
+//
+// benchInput returns a large input, the sources of this package: the lines split in the fragments printed by PrintLevel,
+// with the indentation level of each line
+//
+func benchInput(b *testing.B) (lines []benchLine) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, f := range files {
+		src, err := os.ReadFile(f)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for _, l := range strings.Split(string(src), "\n") {
+			level := len(l) - len(strings.TrimLeft(l, "\t"))
+			lines = append(lines, benchLine{level: level, values: strings.Fields(l)})
+		}
+	}
+
+	return
+}
+
+//
+// BenchmarkOutput prints a large input with the buffered output (outBuffer) and with fmt.Fprint,
+// as PrintLevel did before, to the buffered writer used by the walker
+//
+func BenchmarkOutput(b *testing.B) {
+	lines := benchInput(b)
+
+	b.Run("outBuffer", func(b *testing.B) {
+		p := &CPrinter{}
+		p.SetWriter(bufio.NewWriter(io.Discard))
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			for _, l := range lines {
+				p.level = l.level
+				p.PrintLevel(p.terms().NL, l.values...)
+			}
+		}
+	})
+
+	b.Run("fmt.Fprint", func(b *testing.B) {
+		p := &CPrinter{}
+		w := bufio.NewWriter(io.Discard)
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			for _, l := range lines {
+				p.level = l.level
+				fmt.Fprint(w, p.lineDirective(), p.indent(), strings.Join(l.values, " "), p.terms().NL)
+			}
+		}
+	})
+}
@@ -1,7 +1,12 @@
 package printer
 
 import (
+	"fmt"
+	"go/token"
 	"io"
+	"log/slog"
+	"reflect"
+	"strings"
 	"unicode"
 )
 
@@ -32,21 +37,84 @@ const (
 	CHAN_SEND = "chan<-"
 	CHAN_RECV = "<-chan"
 
-	NONE    = ""
-	NL      = "\n"
-	SEMI    = ";\n"
-	COLON   = ":\n"
-	COMMA   = ", "
-	COMMANL = ",\n"
+	NONE = "" // no terminator (see Terminators for the others)
 )
 
+// Capability describes a Go construct that a printer may or may not be able to convert
+type Capability int
+
+const (
+	CAP_GOROUTINES Capability = 1 << iota
+	CAP_DEFER
+	CAP_CHANNELS
+	CAP_GENERICS
+	CAP_UNIONS
+	CAP_CGO        // C preamble and C.xxx names passed through as they are
+	CAP_RANGE_INT  // for i := range n (Go 1.22), converted to a counting loop otherwise
+	CAP_LOOPVAR    // a new loop variable for each iteration (Go 1.22), that closures can capture
+	CAP_ANON_TYPES // struct and interface types declared in the function signatures, named by the walker otherwise
+	CAP_SWITCH_ANY // switch on values of any type, without tag, and lists of values in a case (switch on the index of the matching case otherwise)
+	CAP_GOTO       // goto statements
+
+	CAP_NONE Capability = 0
+	CAP_ALL             = CAP_GOROUTINES | CAP_DEFER | CAP_CHANNELS | CAP_GENERICS | CAP_UNIONS | CAP_CGO | CAP_RANGE_INT | CAP_LOOPVAR | CAP_ANON_TYPES | CAP_SWITCH_ANY | CAP_GOTO
+)
+
+var capabilityNames = []string{
+	"goroutines",
+	"defer",
+	"channels",
+	"generics",
+	"type unions",
+	"cgo",
+	"range over integers",
+	"per-iteration loop variables",
+	"anonymous types in signatures",
+	"switch on any value",
+	"goto",
+}
+
+//
+// Has returns true if all the capabilities in c are available
+//
+func (caps Capability) Has(c Capability) bool {
+	return caps&c == c
+}
+
+func (caps Capability) String() string {
+	names := []string{}
+
+	for i, n := range capabilityNames {
+		if caps&(1<<uint(i)) != 0 {
+			names = append(names, n)
+		}
+	}
+
+	if len(names) == 0 {
+		return "none"
+	}
+
+	return strings.Join(names, ", ")
+}
+
 //
 // Printer is the interface to be implemented to print a program
 //
 type Printer interface {
 	Reset()
 
+	// return the constructs this printer is able to convert
+	Capabilities() Capability
+
+	// return the names that can't be used as identifiers in the target language (keywords, macros...)
+	ReservedWords() map[string]bool
+
+	// return the target name for a Go identifier (i.e. renamed if it's one of the ReservedWords)
+	MangleName(name string) string
+
 	SetWriter(w io.Writer)
+	SetStyle(style FormatStyle)
+	SetLogger(logger *slog.Logger)
 	UpdateLevel(delta int)
 	SameLine()
 	IsSameLine() bool
@@ -57,6 +125,15 @@ type Printer interface {
 	PushContext()
 	PopContext()
 
+	// set the symbol table maintained by the walker
+	SetSymbols(symbols *SymbolTable)
+
+	// set the position in the Go source of the node being printed
+	SetPosition(pos token.Position)
+
+	// reserve names used in the source, so that generated names don't clash with them
+	ReserveNames(names ...string)
+
 	// print start block "{"
 	PrintBlockStart(b BlockType)
 
@@ -69,21 +146,65 @@ type Printer interface {
 	// print a single import
 	PrintImport(name, path string)
 
+	// print what the imports require, once after all the imports of a file (i.e. the C includes)
+	PrintImportsEnd()
+
+	// print the opening of the namespace containing the package declarations
+	PrintNamespace(name string)
+
+	// print the closing of the namespace
+	PrintNamespaceEnd(name string)
+
 	// print a type definition
 	PrintType(name, typedef string)
 
+	// print the definition of a named type that is not a struct (type MyInt int), declaring its methods
+	PrintNamedType(name, typedef string, methods []Method)
+
+	// print the definition of a struct type with methods (type Point struct{ X, Y int }), where fields is the field list,
+	// declaring its methods
+	PrintStruct(name, fields string, methods []Method)
+
+	// print an integer type used as a set of flags (type Perm uint8), where names is nil,
+	// or its constants (const ( Read Perm = 1 << iota; Write )), with their values
+	PrintEnum(name, typedef string, names, values []string)
+
+	// print code derived from the tags of a struct type (i.e. JSON serialization), after its definition
+	PrintStructTags(name string, fields []StructField)
+
+	// print the comparison operators of a struct type used as a map key (fields are the fields compared), after its definition
+	PrintComparable(name string, fields []string)
+
+	// print the Go name of a type (goname, i.e. main.Point), for %T and reflect.TypeOf, after its definition
+	PrintTypeName(name, typedef, goname string)
+
+	// print the doc comment of an exported declaration, before the declaration
+	PrintDoc(doc string)
+
+	// print a comment of the source (one line comment for each line)
+	PrintComment(comment string)
+
 	// print a const/var definition
 	PrintValue(vtype, typedef, names, values string, ntuple, vtuple bool)
 
 	// print a 'special' statement (goto, break, continue, ...)
 	PrintStmt(stmt, expr string)
 
+	// print a label (the labeled statement follows)
+	PrintLabel(name string)
+
+	// print a deferred call releasing the resource acquired by the previous statement (mutex is the mutex locked, if any)
+	PrintGuard(mutex, release string)
+
 	// print return statemement
 	PrintReturn(expr string, tuple bool)
 
 	// print a function definition
 	PrintFunc(receiver, name, params, results string)
 
+	// print a function declaration (prototype), for functions defined later
+	PrintPrototype(name, params, results string)
+
 	// print a "for" opening statement
 	PrintFor(init, cond, post string)
 
@@ -130,6 +251,8 @@ type Printer interface {
 
 	FormatUnary(op, operand string) string
 
+	FormatIncDec(operand, op string) string
+
 	FormatBinary(lhs, op, rhs string) string
 
 	FormatPair(p Pair, t FieldType) string
@@ -138,6 +261,10 @@ type Printer interface {
 
 	FormatArrayIndex(array, index string) string
 
+	FormatStringIndex(str, index string) string
+
+	FormatGeneric(name, types string) string
+
 	FormatSlice(slice, low, high, max string) string
 
 	FormatMap(key, elt string) string
@@ -152,13 +279,41 @@ type Printer interface {
 
 	FormatCall(fun, args string, isFuncLit bool) string
 
+	FormatSpread(arg string) string
+
 	FormatFuncType(params, results string, withFunc bool) string
 
 	FormatFuncLit(ftype, body string) string
 
 	FormatSelector(pname, sel string, isObject bool) string
 
+	// format a type assertion (assert is empty for a type switch guard, x.(type))
 	FormatTypeAssert(orig, assert string) string
+
+	// format the type of a variable accessed with sync/atomic operations
+	FormatAtomic(mtype string) string
+
+	// format a placeholder for a construct that couldn't be converted, that compiles and fails at run time
+	// (what describes the construct and its position, i.e. "select statement at foo.go:42:2")
+	FormatUnsupported(what string) string
+}
+
+//
+// New returns the printer for a target language (c or cc, go, rust or rs, swift), with the default options
+//
+func New(lang string) (Printer, error) {
+	switch lang {
+	case "c", "cc":
+		return &CPrinter{}, nil
+	case "go":
+		return &GoPrinter{}, nil
+	case "rust", "rs":
+		return &RustPrinter{}, nil
+	case "swift":
+		return &SwiftPrinter{}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported language %s, use c, go, rust or swift", lang)
 }
 
 //
@@ -166,6 +321,24 @@ type Printer interface {
 //
 type Pair [2]string
 
+//
+// StructField is a field of a struct type, with its tag
+//
+type StructField struct {
+	Name     string
+	Tag      reflect.StructTag
+	Embedded bool // for embedded fields Name is the type name
+}
+
+//
+// Method is the signature of a method declared on a named type
+//
+type Method struct {
+	Name    string
+	Params  string
+	Results string
+}
+
 //
 // PairList is a list/slice of pair
 //
@@ -207,6 +380,15 @@ func IsPublic(name string) bool {
 	return false
 }
 
+//
+// logInfo logs a note about the conversion, if logger is not nil
+//
+func logInfo(logger *slog.Logger, msg string, args ...interface{}) {
+	if logger != nil {
+		logger.Info(msg, args...)
+	}
+}
+
 //
 // IfTrue returns the input value if the condition is true, an empty string otherwise
 //
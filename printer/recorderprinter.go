@@ -0,0 +1,694 @@
+package printer
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+//
+// Call is a recorded call to a Printer method
+//
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+func (c Call) String() string {
+	args := make([]string, len(c.Args))
+
+	for i, a := range c.Args {
+		if s, ok := a.(string); ok {
+			args[i] = fmt.Sprintf("%q", s)
+		} else {
+			args[i] = fmt.Sprintf("%v", a)
+		}
+	}
+
+	return fmt.Sprintf("%s(%s)", c.Method, strings.Join(args, ", "))
+}
+
+//
+// RecorderPrinter records the sequence of calls to the Printer methods, with their arguments.
+//
+// If P is set the calls are forwarded to it, otherwise the Format methods return a canonical
+// representation of the call (i.e. FormatBinary("a", "+", "b")) so that the call log
+// doesn't depend on any target syntax.
+//
+type RecorderPrinter struct {
+	P     Printer
+	Calls []Call
+}
+
+func (r *RecorderPrinter) record(method string, args ...interface{}) {
+	r.Calls = append(r.Calls, Call{Method: method, Args: args})
+}
+
+//
+// Log returns the recorded calls, one per line
+//
+func (r *RecorderPrinter) Log() string {
+	var log strings.Builder
+
+	for _, c := range r.Calls {
+		log.WriteString(c.String())
+		log.WriteString("\n")
+	}
+
+	return log.String()
+}
+
+//
+// Clear removes all recorded calls
+//
+func (r *RecorderPrinter) Clear() {
+	r.Calls = nil
+}
+
+//
+// canonical returns the target independent representation of a Format call
+//
+func canonical(method string, args ...interface{}) string {
+	sargs := make([]string, len(args))
+
+	for i, a := range args {
+		sargs[i] = fmt.Sprint(a)
+	}
+
+	return fmt.Sprintf("%s(%s)", method, strings.Join(sargs, ", "))
+}
+
+func (r *RecorderPrinter) Reset() {
+	r.record("Reset")
+	if r.P != nil {
+		r.P.Reset()
+	}
+}
+
+func (r *RecorderPrinter) Capabilities() Capability {
+	if r.P != nil {
+		return r.P.Capabilities()
+	}
+
+	return CAP_ALL
+}
+
+func (r *RecorderPrinter) ReservedWords() map[string]bool {
+	if r.P != nil {
+		return r.P.ReservedWords()
+	}
+
+	return nil
+}
+
+func (r *RecorderPrinter) MangleName(name string) string {
+	if r.P != nil {
+		return r.P.MangleName(name)
+	}
+
+	return name
+}
+
+func (r *RecorderPrinter) SetWriter(w io.Writer) {
+	// not recorded, since the writer changes with buffering
+	if r.P != nil {
+		r.P.SetWriter(w)
+	}
+}
+
+func (r *RecorderPrinter) SetStyle(style FormatStyle) {
+	r.record("SetStyle", style)
+	if r.P != nil {
+		r.P.SetStyle(style)
+	}
+}
+
+func (r *RecorderPrinter) SetLogger(logger *slog.Logger) {
+	// not recorded
+	if r.P != nil {
+		r.P.SetLogger(logger)
+	}
+}
+
+func (r *RecorderPrinter) UpdateLevel(delta int) {
+	r.record("UpdateLevel", delta)
+	if r.P != nil {
+		r.P.UpdateLevel(delta)
+	}
+}
+
+func (r *RecorderPrinter) SameLine() {
+	r.record("SameLine")
+	if r.P != nil {
+		r.P.SameLine()
+	}
+}
+
+func (r *RecorderPrinter) IsSameLine() bool {
+	if r.P != nil {
+		return r.P.IsSameLine()
+	}
+
+	return false
+}
+
+func (r *RecorderPrinter) Print(values ...string) {
+	r.record("Print", strings.Join(values, " "))
+	if r.P != nil {
+		r.P.Print(values...)
+	}
+}
+
+func (r *RecorderPrinter) PrintLevel(term string, values ...string) {
+	r.record("PrintLevel", term, strings.Join(values, " "))
+	if r.P != nil {
+		r.P.PrintLevel(term, values...)
+	}
+}
+
+func (r *RecorderPrinter) Chop(line string) string {
+	if r.P != nil {
+		return r.P.Chop(line)
+	}
+
+	return strings.TrimRight(line, DefaultTerminators.Comma)
+}
+
+func (r *RecorderPrinter) PushContext() {
+	r.record("PushContext")
+	if r.P != nil {
+		r.P.PushContext()
+	}
+}
+
+func (r *RecorderPrinter) PopContext() {
+	r.record("PopContext")
+	if r.P != nil {
+		r.P.PopContext()
+	}
+}
+
+func (r *RecorderPrinter) ReserveNames(names ...string) {
+	// not recorded, since it depends on all the identifiers in the file
+	if r.P != nil {
+		r.P.ReserveNames(names...)
+	}
+}
+
+func (r *RecorderPrinter) SetSymbols(symbols *SymbolTable) {
+	if r.P != nil {
+		r.P.SetSymbols(symbols)
+	}
+}
+
+func (r *RecorderPrinter) SetPosition(pos token.Position) {
+	// not recorded, to keep the log independent of the source layout
+	if r.P != nil {
+		r.P.SetPosition(pos)
+	}
+}
+
+func (r *RecorderPrinter) PrintBlockStart(b BlockType) {
+	r.record("PrintBlockStart", b)
+	if r.P != nil {
+		r.P.PrintBlockStart(b)
+	}
+}
+
+func (r *RecorderPrinter) PrintBlockEnd(b BlockType) {
+	r.record("PrintBlockEnd", b)
+	if r.P != nil {
+		r.P.PrintBlockEnd(b)
+	}
+}
+
+func (r *RecorderPrinter) PrintPackage(name string) {
+	r.record("PrintPackage", name)
+	if r.P != nil {
+		r.P.PrintPackage(name)
+	}
+}
+
+func (r *RecorderPrinter) PrintImport(name, path string) {
+	r.record("PrintImport", name, path)
+	if r.P != nil {
+		r.P.PrintImport(name, path)
+	}
+}
+
+func (r *RecorderPrinter) PrintImportsEnd() {
+	r.record("PrintImportsEnd")
+	if r.P != nil {
+		r.P.PrintImportsEnd()
+	}
+}
+
+func (r *RecorderPrinter) PrintNamespace(name string) {
+	r.record("PrintNamespace", name)
+	if r.P != nil {
+		r.P.PrintNamespace(name)
+	}
+}
+
+func (r *RecorderPrinter) PrintNamespaceEnd(name string) {
+	r.record("PrintNamespaceEnd", name)
+	if r.P != nil {
+		r.P.PrintNamespaceEnd(name)
+	}
+}
+
+func (r *RecorderPrinter) PrintType(name, typedef string) {
+	r.record("PrintType", name, typedef)
+	if r.P != nil {
+		r.P.PrintType(name, typedef)
+	}
+}
+
+func (r *RecorderPrinter) PrintNamedType(name, typedef string, methods []Method) {
+	r.record("PrintNamedType", name, typedef, methods)
+	if r.P != nil {
+		r.P.PrintNamedType(name, typedef, methods)
+	}
+}
+
+func (r *RecorderPrinter) PrintStruct(name, fields string, methods []Method) {
+	r.record("PrintStruct", name, fields, methods)
+	if r.P != nil {
+		r.P.PrintStruct(name, fields, methods)
+	}
+}
+
+func (r *RecorderPrinter) PrintEnum(name, typedef string, names, values []string) {
+	r.record("PrintEnum", name, typedef, names, values)
+	if r.P != nil {
+		r.P.PrintEnum(name, typedef, names, values)
+	}
+}
+
+func (r *RecorderPrinter) PrintStructTags(name string, fields []StructField) {
+	r.record("PrintStructTags", name, fields)
+	if r.P != nil {
+		r.P.PrintStructTags(name, fields)
+	}
+}
+
+func (r *RecorderPrinter) PrintComparable(name string, fields []string) {
+	r.record("PrintComparable", name, fields)
+	if r.P != nil {
+		r.P.PrintComparable(name, fields)
+	}
+}
+
+func (r *RecorderPrinter) PrintTypeName(name, typedef, goname string) {
+	r.record("PrintTypeName", name, typedef, goname)
+	if r.P != nil {
+		r.P.PrintTypeName(name, typedef, goname)
+	}
+}
+
+func (r *RecorderPrinter) PrintDoc(doc string) {
+	r.record("PrintDoc", doc)
+	if r.P != nil {
+		r.P.PrintDoc(doc)
+	}
+}
+
+func (r *RecorderPrinter) PrintComment(comment string) {
+	r.record("PrintComment", comment)
+	if r.P != nil {
+		r.P.PrintComment(comment)
+	}
+}
+
+func (r *RecorderPrinter) PrintValue(vtype, typedef, names, values string, ntuple, vtuple bool) {
+	r.record("PrintValue", vtype, typedef, names, values, ntuple, vtuple)
+	if r.P != nil {
+		r.P.PrintValue(vtype, typedef, names, values, ntuple, vtuple)
+	}
+}
+
+func (r *RecorderPrinter) PrintStmt(stmt, expr string) {
+	r.record("PrintStmt", stmt, expr)
+	if r.P != nil {
+		r.P.PrintStmt(stmt, expr)
+	}
+}
+
+func (r *RecorderPrinter) PrintLabel(name string) {
+	r.record("PrintLabel", name)
+	if r.P != nil {
+		r.P.PrintLabel(name)
+	}
+}
+
+func (r *RecorderPrinter) PrintGuard(mutex, release string) {
+	r.record("PrintGuard", mutex, release)
+	if r.P != nil {
+		r.P.PrintGuard(mutex, release)
+	}
+}
+
+func (r *RecorderPrinter) PrintReturn(expr string, tuple bool) {
+	r.record("PrintReturn", expr, tuple)
+	if r.P != nil {
+		r.P.PrintReturn(expr, tuple)
+	}
+}
+
+func (r *RecorderPrinter) PrintFunc(receiver, name, params, results string) {
+	r.record("PrintFunc", receiver, name, params, results)
+	if r.P != nil {
+		r.P.PrintFunc(receiver, name, params, results)
+	}
+}
+
+func (r *RecorderPrinter) PrintPrototype(name, params, results string) {
+	r.record("PrintPrototype", name, params, results)
+	if r.P != nil {
+		r.P.PrintPrototype(name, params, results)
+	}
+}
+
+func (r *RecorderPrinter) PrintFor(init, cond, post string) {
+	r.record("PrintFor", init, cond, post)
+	if r.P != nil {
+		r.P.PrintFor(init, cond, post)
+	}
+}
+
+func (r *RecorderPrinter) PrintRange(key, value, expr string) {
+	r.record("PrintRange", key, value, expr)
+	if r.P != nil {
+		r.P.PrintRange(key, value, expr)
+	}
+}
+
+func (r *RecorderPrinter) PrintSwitch(init, expr string) {
+	r.record("PrintSwitch", init, expr)
+	if r.P != nil {
+		r.P.PrintSwitch(init, expr)
+	}
+}
+
+func (r *RecorderPrinter) PrintCase(expr string) {
+	r.record("PrintCase", expr)
+	if r.P != nil {
+		r.P.PrintCase(expr)
+	}
+}
+
+func (r *RecorderPrinter) PrintEndCase() {
+	r.record("PrintEndCase")
+	if r.P != nil {
+		r.P.PrintEndCase()
+	}
+}
+
+func (r *RecorderPrinter) PrintIf(init, cond string) {
+	r.record("PrintIf", init, cond)
+	if r.P != nil {
+		r.P.PrintIf(init, cond)
+	}
+}
+
+func (r *RecorderPrinter) PrintElse() {
+	r.record("PrintElse")
+	if r.P != nil {
+		r.P.PrintElse()
+	}
+}
+
+func (r *RecorderPrinter) PrintEmpty() {
+	r.record("PrintEmpty")
+	if r.P != nil {
+		r.P.PrintEmpty()
+	}
+}
+
+func (r *RecorderPrinter) PrintAssignment(lhs, op, rhs string, ltuple, rtuple bool) {
+	r.record("PrintAssignment", lhs, op, rhs, ltuple, rtuple)
+	if r.P != nil {
+		r.P.PrintAssignment(lhs, op, rhs, ltuple, rtuple)
+	}
+}
+
+func (r *RecorderPrinter) PrintSend(ch, value string) {
+	r.record("PrintSend", ch, value)
+	if r.P != nil {
+		r.P.PrintSend(ch, value)
+	}
+}
+
+func (r *RecorderPrinter) FormatIdent(id string) string {
+	r.record("FormatIdent", id)
+	if r.P != nil {
+		return r.P.FormatIdent(id)
+	}
+
+	return id
+}
+
+func (r *RecorderPrinter) FormatLiteral(lit string) string {
+	r.record("FormatLiteral", lit)
+	if r.P != nil {
+		return r.P.FormatLiteral(lit)
+	}
+
+	return lit
+}
+
+func (r *RecorderPrinter) FormatCompositeLit(typedef, elt string) string {
+	r.record("FormatCompositeLit", typedef, elt)
+	if r.P != nil {
+		return r.P.FormatCompositeLit(typedef, elt)
+	}
+
+	return canonical("FormatCompositeLit", typedef, elt)
+}
+
+func (r *RecorderPrinter) FormatStar(expr string) string {
+	r.record("FormatStar", expr)
+	if r.P != nil {
+		return r.P.FormatStar(expr)
+	}
+
+	return canonical("FormatStar", expr)
+}
+
+func (r *RecorderPrinter) FormatEllipsis(expr string) string {
+	r.record("FormatEllipsis", expr)
+	if r.P != nil {
+		return r.P.FormatEllipsis(expr)
+	}
+
+	return canonical("FormatEllipsis", expr)
+}
+
+func (r *RecorderPrinter) FormatParen(expr string) string {
+	r.record("FormatParen", expr)
+	if r.P != nil {
+		return r.P.FormatParen(expr)
+	}
+
+	return canonical("FormatParen", expr)
+}
+
+func (r *RecorderPrinter) FormatUnary(op, operand string) string {
+	r.record("FormatUnary", op, operand)
+	if r.P != nil {
+		return r.P.FormatUnary(op, operand)
+	}
+
+	return canonical("FormatUnary", op, operand)
+}
+
+func (r *RecorderPrinter) FormatIncDec(operand, op string) string {
+	r.record("FormatIncDec", operand, op)
+	if r.P != nil {
+		return r.P.FormatIncDec(operand, op)
+	}
+
+	return canonical("FormatIncDec", operand, op)
+}
+
+func (r *RecorderPrinter) FormatBinary(lhs, op, rhs string) string {
+	r.record("FormatBinary", lhs, op, rhs)
+	if r.P != nil {
+		return r.P.FormatBinary(lhs, op, rhs)
+	}
+
+	return canonical("FormatBinary", lhs, op, rhs)
+}
+
+func (r *RecorderPrinter) FormatPair(v Pair, t FieldType) string {
+	r.record("FormatPair", v.Name(), v.Value(), t)
+	if r.P != nil {
+		return r.P.FormatPair(v, t)
+	}
+
+	return canonical("FormatPair", v.Name(), v.Value(), t) + DefaultTerminators.Comma
+}
+
+func (r *RecorderPrinter) FormatArray(len, elt string) string {
+	r.record("FormatArray", len, elt)
+	if r.P != nil {
+		return r.P.FormatArray(len, elt)
+	}
+
+	return canonical("FormatArray", len, elt)
+}
+
+func (r *RecorderPrinter) FormatArrayIndex(array, index string) string {
+	r.record("FormatArrayIndex", array, index)
+	if r.P != nil {
+		return r.P.FormatArrayIndex(array, index)
+	}
+
+	return canonical("FormatArrayIndex", array, index)
+}
+
+func (r *RecorderPrinter) FormatStringIndex(str, index string) string {
+	r.record("FormatStringIndex", str, index)
+	if r.P != nil {
+		return r.P.FormatStringIndex(str, index)
+	}
+
+	return canonical("FormatStringIndex", str, index)
+}
+
+func (r *RecorderPrinter) FormatGeneric(name, types string) string {
+	r.record("FormatGeneric", name, types)
+	if r.P != nil {
+		return r.P.FormatGeneric(name, types)
+	}
+
+	return canonical("FormatGeneric", name, types)
+}
+
+func (r *RecorderPrinter) FormatSlice(slice, low, high, max string) string {
+	r.record("FormatSlice", slice, low, high, max)
+	if r.P != nil {
+		return r.P.FormatSlice(slice, low, high, max)
+	}
+
+	return canonical("FormatSlice", slice, low, high, max)
+}
+
+func (r *RecorderPrinter) FormatMap(key, elt string) string {
+	r.record("FormatMap", key, elt)
+	if r.P != nil {
+		return r.P.FormatMap(key, elt)
+	}
+
+	return canonical("FormatMap", key, elt)
+}
+
+func (r *RecorderPrinter) FormatKeyValue(key, value string) string {
+	r.record("FormatKeyValue", key, value)
+	if r.P != nil {
+		return r.P.FormatKeyValue(key, value)
+	}
+
+	return canonical("FormatKeyValue", key, value)
+}
+
+func (r *RecorderPrinter) FormatStruct(fields string) string {
+	r.record("FormatStruct", fields)
+	if r.P != nil {
+		return r.P.FormatStruct(fields)
+	}
+
+	return canonical("FormatStruct", fields)
+}
+
+func (r *RecorderPrinter) FormatInterface(methods string) string {
+	r.record("FormatInterface", methods)
+	if r.P != nil {
+		return r.P.FormatInterface(methods)
+	}
+
+	return canonical("FormatInterface", methods)
+}
+
+func (r *RecorderPrinter) FormatChan(chdir, mtype string) string {
+	r.record("FormatChan", chdir, mtype)
+	if r.P != nil {
+		return r.P.FormatChan(chdir, mtype)
+	}
+
+	return canonical("FormatChan", chdir, mtype)
+}
+
+func (r *RecorderPrinter) FormatCall(fun, args string, isFuncLit bool) string {
+	r.record("FormatCall", fun, args, isFuncLit)
+	if r.P != nil {
+		return r.P.FormatCall(fun, args, isFuncLit)
+	}
+
+	return canonical("FormatCall", fun, args, isFuncLit)
+}
+
+func (r *RecorderPrinter) FormatSpread(arg string) string {
+	r.record("FormatSpread", arg)
+	if r.P != nil {
+		return r.P.FormatSpread(arg)
+	}
+
+	return canonical("FormatSpread", arg)
+}
+
+func (r *RecorderPrinter) FormatFuncType(params, results string, withFunc bool) string {
+	r.record("FormatFuncType", params, results, withFunc)
+	if r.P != nil {
+		return r.P.FormatFuncType(params, results, withFunc)
+	}
+
+	return canonical("FormatFuncType", params, results, withFunc)
+}
+
+func (r *RecorderPrinter) FormatFuncLit(ftype, body string) string {
+	r.record("FormatFuncLit", ftype, body)
+	if r.P != nil {
+		return r.P.FormatFuncLit(ftype, body)
+	}
+
+	return canonical("FormatFuncLit", ftype, body)
+}
+
+func (r *RecorderPrinter) FormatSelector(pname, sel string, isObject bool) string {
+	r.record("FormatSelector", pname, sel, isObject)
+	if r.P != nil {
+		return r.P.FormatSelector(pname, sel, isObject)
+	}
+
+	return canonical("FormatSelector", pname, sel, isObject)
+}
+
+func (r *RecorderPrinter) FormatTypeAssert(orig, assert string) string {
+	r.record("FormatTypeAssert", orig, assert)
+	if r.P != nil {
+		return r.P.FormatTypeAssert(orig, assert)
+	}
+
+	return canonical("FormatTypeAssert", orig, assert)
+}
+
+func (r *RecorderPrinter) FormatAtomic(mtype string) string {
+	r.record("FormatAtomic", mtype)
+	if r.P != nil {
+		return r.P.FormatAtomic(mtype)
+	}
+
+	return canonical("FormatAtomic", mtype)
+}
+
+func (r *RecorderPrinter) FormatUnsupported(what string) string {
+	r.record("FormatUnsupported", what)
+	if r.P != nil {
+		return r.P.FormatUnsupported(what)
+	}
+
+	return canonical("FormatUnsupported", what)
+}
@@ -0,0 +1,47 @@
+package printer
+
+import (
+	"strings"
+)
+
+//
+// rustReserved are the Rust keywords (strict, reserved for future use and the weak ones that can't be
+// used as identifiers in all positions), that are valid Go identifiers
+//
+var rustReserved = map[string]bool{
+	"as": true, "crate": true, "enum": true, "extern": true, "false": true, "fn": true, "impl": true,
+	"in": true, "let": true, "loop": true, "match": true, "mod": true, "move": true, "mut": true,
+	"pub": true, "ref": true, "self": true, "Self": true, "static": true, "super": true, "trait": true,
+	"true": true, "unsafe": true, "use": true, "where": true, "while": true, "async": true, "await": true,
+	"dyn": true, "abstract": true, "become": true, "box": true, "do": true, "final": true, "macro": true,
+	"override": true, "priv": true, "typeof": true, "unsized": true, "virtual": true, "yield": true,
+	"try": true, "gen": true, "union": true,
+}
+
+//
+// swiftReserved are the Swift keywords used in declarations, statements, expressions and types,
+// that are valid Go identifiers
+//
+var swiftReserved = map[string]bool{
+	"associatedtype": true, "class": true, "deinit": true, "enum": true, "extension": true,
+	"fileprivate": true, "init": true, "inout": true, "internal": true, "let": true,
+	"operator": true, "private": true, "precedencegroup": true, "protocol": true, "public": true,
+	"rethrows": true, "static": true, "struct": true, "subscript": true, "typealias": true,
+	"catch": true, "do": true, "guard": true, "in": true, "repeat": true, "throw": true, "where": true,
+	"while": true, "Any": true, "as": true, "await": true, "false": true, "is": true, "nil": true,
+	"self": true, "Self": true, "super": true, "throws": true, "true": true, "try": true,
+}
+
+//
+// mangleReserved returns the name for a Go identifier in a target with the reserved words in reserved:
+// names that (without trailing underscores) are reserved get an additional MANGLE_SUFFIX
+// (class -> class_, class_ -> class__), so that the mapping depends only on the name and never clashes
+// with other identifiers
+//
+func mangleReserved(name string, reserved map[string]bool) string {
+	if reserved[strings.TrimRight(name, MANGLE_SUFFIX)] {
+		return name + MANGLE_SUFFIX
+	}
+
+	return name
+}
@@ -2,7 +2,9 @@ package printer
 
 import (
 	"fmt"
+	"go/token"
 	"io"
+	"log/slog"
 	"strings"
 )
 
@@ -15,23 +17,77 @@ type RustPrinter struct {
 	level    int
 	sameline bool
 	w        io.Writer
+	out      outBuffer
+	style    FormatStyle
+	indents  indentCache
+	log      *slog.Logger
+	symbols  *SymbolTable
+	pos      token.Position
+
+	ctx   ContextStack
+	names Names
 }
 
 func (p *RustPrinter) Reset() {
 	p.level = 0
 	p.sameline = false
+
+	p.ctx.Reset()
+	p.names.Reset()
+}
+
+func (p *RustPrinter) Capabilities() Capability {
+	return CAP_SWITCH_ANY // match
+}
+
+func (p *RustPrinter) ReservedWords() map[string]bool {
+	return rustReserved
+}
+
+func (p *RustPrinter) MangleName(name string) string {
+	return mangleReserved(name, rustReserved)
 }
 
 func (p *RustPrinter) PushContext() {
+	p.ctx.Push()
 }
 
 func (p *RustPrinter) PopContext() {
+	p.ctx.Pop()
+}
+
+func (p *RustPrinter) ReserveNames(names ...string) {
+	p.names.Reserve(names...)
+}
+
+//
+// terms returns the terminators for the current style
+//
+func (p *RustPrinter) terms() Terminators {
+	return p.style.Terms.Or(DefaultTerminators)
+}
+
+func (p *RustPrinter) SetSymbols(symbols *SymbolTable) {
+	p.symbols = symbols
+}
+
+func (p *RustPrinter) SetPosition(pos token.Position) {
+	p.pos = pos
 }
 
 func (p *RustPrinter) SetWriter(w io.Writer) {
 	p.w = w
 }
 
+func (p *RustPrinter) SetStyle(style FormatStyle) {
+	p.style = style
+	p.indents = nil
+}
+
+func (p *RustPrinter) SetLogger(logger *slog.Logger) {
+	p.log = logger
+}
+
 func (p *RustPrinter) UpdateLevel(delta int) {
 	p.level += delta
 }
@@ -50,19 +106,20 @@ func (p *RustPrinter) Chop(line string) string {
 
 func (p *RustPrinter) indent() string {
 	if p.sameline {
+		// continue the statement header (i.e. the opening brace or the if after else)
 		p.sameline = false
-		return ""
+		return " "
 	}
 
-	return strings.Repeat("  ", p.level)
+	return p.indents.level(p.style, p.level)
 }
 
 func (p *RustPrinter) Print(values ...string) {
-	fmt.Fprint(p.w, strings.Join(values, " "))
+	p.out.writeValues(p.w, "", values, NONE)
 }
 
 func (p *RustPrinter) PrintLevel(term string, values ...string) {
-	fmt.Fprint(p.w, p.indent(), strings.Join(values, " "), term)
+	p.out.writeValues(p.w, p.indent(), values, term)
 }
 
 func (p *RustPrinter) PrintfLevel(term string, format string, values ...interface{}) {
@@ -79,7 +136,12 @@ func (p *RustPrinter) PrintBlockStart(b BlockType) {
 		open = "{"
 	}
 
-	p.PrintLevel(NL, open)
+	if b == CODE && p.sameline && p.style.Braces == BRACE_ALLMAN {
+		p.sameline = false
+		p.Print(p.terms().NL)
+	}
+
+	p.PrintLevel(p.terms().NL, open)
 	p.UpdateLevel(UP)
 }
 
@@ -98,18 +160,69 @@ func (p *RustPrinter) PrintBlockEnd(b BlockType) {
 }
 
 func (p *RustPrinter) PrintPackage(name string) {
-	p.PrintLevel(NL, "package", name)
+	p.PrintLevel(p.terms().NL, "package", name)
 }
 
 func (p *RustPrinter) PrintImport(name, path string) {
-	p.PrintLevel(NL, "import", name, path)
+	p.PrintLevel(p.terms().NL, "import", name, path)
+}
+
+func (p *RustPrinter) PrintImportsEnd() {
+	// the imports are printed as they are
+}
+
+func (p *RustPrinter) PrintNamespace(name string) {
+	// the package is the namespace
+}
+
+func (p *RustPrinter) PrintNamespaceEnd(name string) {
 }
 
 func (p *RustPrinter) PrintType(name, typedef string) {
 	if strings.Contains(typedef, "%") {
-		p.PrintfLevel(NL, typedef, name)
+		p.PrintfLevel(p.terms().NL, typedef, name)
 	} else {
-		p.PrintLevel(NL, "type", name, typedef)
+		p.PrintLevel(p.terms().NL, "type", name, typedef)
+	}
+}
+
+func (p *RustPrinter) PrintNamedType(name, typedef string, methods []Method) {
+	p.PrintType(name, typedef)
+}
+
+func (p *RustPrinter) PrintStruct(name, fields string, methods []Method) {
+	p.PrintType(name, p.FormatStruct(fields))
+}
+
+func (p *RustPrinter) PrintEnum(name, typedef string, names, values []string) {
+	if names == nil {
+		p.PrintType(name, typedef)
+		return
+	}
+
+	for i, n := range names {
+		p.PrintValue("const", name, n, values[i], false, false)
+	}
+}
+
+func (p *RustPrinter) PrintStructTags(name string, fields []StructField) {
+}
+
+func (p *RustPrinter) PrintComparable(name string, fields []string) {
+}
+
+func (p *RustPrinter) PrintTypeName(name, typedef, goname string) {
+}
+
+func (p *RustPrinter) PrintDoc(doc string) {
+	for _, line := range docLines(doc) {
+		p.PrintLevel(p.terms().NL, strings.TrimRight("/// "+line, " "))
+	}
+}
+
+func (p *RustPrinter) PrintComment(comment string) {
+	for _, line := range docLines(comment) {
+		p.PrintLevel(p.terms().NL, strings.TrimRight("// "+line, " "))
 	}
 }
 
@@ -135,12 +248,20 @@ func (p *RustPrinter) PrintValue(vtype, typedef, names, values string, ntuple, v
 
 func (p *RustPrinter) PrintStmt(stmt, expr string) {
 	if len(stmt) > 0 {
-		p.PrintLevel(SEMI, stmt, expr)
+		p.PrintLevel(p.terms().Semi, stmt, expr)
 	} else {
-		p.PrintLevel(SEMI, expr)
+		p.PrintLevel(p.terms().Semi, expr)
 	}
 }
 
+func (p *RustPrinter) PrintLabel(name string) {
+	p.PrintLevel(p.terms().NL, name+":")
+}
+
+func (p *RustPrinter) PrintGuard(mutex, release string) {
+	p.PrintStmt("defer", release)
+}
+
 func (p *RustPrinter) PrintReturn(expr string, tuple bool) {
 	if tuple {
 		expr = "(" + expr + ")"
@@ -154,38 +275,37 @@ func (p *RustPrinter) PrintFunc(receiver, name, params, results string) {
 	if len(receiver) > 0 {
 		fmt.Fprintf(p.w, "(%s) ", receiver)
 	}
-	fmt.Fprintf(p.w, "%s(%s) ", name, params)
+	fmt.Fprintf(p.w, "%s(%s)", name, params)
 	if len(results) > 0 {
-		p.Print("->", "")
+		p.Print("", "->")
 
 		if strings.ContainsAny(results, " ,") {
 			// name type or multiple types
-			fmt.Fprintf(p.w, "(%s) ", results)
+			fmt.Fprintf(p.w, " (%s)", results)
 		} else {
-			p.Print(results, "")
+			p.Print("", results)
 		}
 	}
 }
 
+func (p *RustPrinter) PrintPrototype(name, params, results string) {
+	// functions can be used before their definition
+}
+
 func (p *RustPrinter) PrintFor(init, cond, post string) {
-	p.PrintLevel(NONE, "for ")
-	if len(init) > 0 {
-		p.Print(init)
-	}
+	header := cond
 	if len(init) > 0 || len(post) > 0 {
-		p.Print("; ")
+		header = init + "; " + cond + "; " + post
 	}
 
-	p.Print(cond)
-
-	if len(post) > 0 {
-		p.Print(";", post)
-	}
-
-	p.Print("")
+	p.PrintLevel(NONE, strings.TrimSpace("for "+header))
 }
 
 func (p *RustPrinter) PrintRange(key, value, expr string) {
+	if len(key) == 0 {
+		key = "_" // for range x
+	}
+
 	p.PrintLevel(NONE, "for", key)
 
 	if len(value) > 0 {
@@ -197,18 +317,19 @@ func (p *RustPrinter) PrintRange(key, value, expr string) {
 }
 
 func (p *RustPrinter) PrintSwitch(init, expr string) {
-	p.PrintLevel(NONE, "switch ")
+	header := expr
 	if len(init) > 0 {
-		p.Print(init + "; ")
+		header = init + "; " + expr
 	}
-	p.Print(expr)
+
+	p.PrintLevel(NONE, strings.TrimSpace("switch "+header))
 }
 
 func (p *RustPrinter) PrintCase(expr string) {
 	if len(expr) > 0 {
-		p.PrintLevel(COLON, "case", expr)
+		p.PrintLevel(p.terms().Colon, "case", expr)
 	} else {
-		p.PrintLevel(NL, "default:")
+		p.PrintLevel(p.terms().NL, "default:")
 	}
 }
 
@@ -217,19 +338,26 @@ func (p *RustPrinter) PrintEndCase() {
 }
 
 func (p *RustPrinter) PrintIf(init, cond string) {
-	p.PrintLevel(NONE, "if ")
 	if len(init) > 0 {
-		p.Print(init + "; ")
+		cond = init + "; " + cond
 	}
-	p.Print(cond, "")
+
+	p.PrintLevel(NONE, "if", cond)
 }
 
 func (p *RustPrinter) PrintElse() {
-	p.Print(" else ")
+	if p.style.Braces == BRACE_ALLMAN {
+		p.sameline = false
+		p.Print(p.terms().NL)
+		p.PrintLevel(NONE, "else")
+		return
+	}
+
+	p.Print(" else")
 }
 
 func (p *RustPrinter) PrintEmpty() {
-	p.PrintLevel(SEMI, "")
+	p.PrintLevel(p.terms().Semi, "")
 }
 
 func (p *RustPrinter) PrintAssignment(lhs, op, rhs string, ltuple, rtuple bool) {
@@ -238,11 +366,11 @@ func (p *RustPrinter) PrintAssignment(lhs, op, rhs string, ltuple, rtuple bool)
 		op = "="
 	}
 
-	p.PrintLevel(NL, lhs, op, rhs)
+	p.PrintLevel(p.terms().NL, lhs, op, rhs)
 }
 
 func (p *RustPrinter) PrintSend(ch, value string) {
-	p.PrintLevel(SEMI, ch, "<-", value)
+	p.PrintLevel(p.terms().Semi, ch, "<-", value)
 }
 
 func (p *RustPrinter) FormatIdent(id string) string {
@@ -273,6 +401,14 @@ func (p *RustPrinter) FormatUnary(op, operand string) string {
 	return fmt.Sprintf("%s%s", op, operand)
 }
 
+func (p *RustPrinter) FormatIncDec(operand, op string) string {
+	// no ++/--
+	if op == "++" {
+		return fmt.Sprintf("%s += 1", operand)
+	}
+	return fmt.Sprintf("%s -= 1", operand)
+}
+
 func (p *RustPrinter) FormatBinary(lhs, op, rhs string) string {
 	return fmt.Sprintf("%s %s %s", lhs, op, rhs)
 }
@@ -280,13 +416,13 @@ func (p *RustPrinter) FormatBinary(lhs, op, rhs string) string {
 func (p *RustPrinter) FormatPair(v Pair, t FieldType) string {
 	switch t {
 	case METHOD:
-		return p.indent() + v.Name() + v.Value() + NL
+		return p.indent() + v.Name() + v.Value() + p.terms().NL
 	case FIELD:
-		return fmt.Sprintf("%s%s: %s%s", p.indent(), v.Name(), v.Value(), COMMANL)
+		return fmt.Sprintf("%s%s: %s%s", p.indent(), v.Name(), v.Value(), p.terms().CommaNL)
 	case PARAM:
-		return fmt.Sprintf("%s: %s%s", v.Name(), v.Value(), COMMA)
+		return fmt.Sprintf("%s: %s%s", v.Name(), v.Value(), p.terms().Comma)
 	default:
-		return v.String() + COMMA
+		return v.String() + p.terms().Comma
 	}
 }
 
@@ -298,6 +434,14 @@ func (p *RustPrinter) FormatArrayIndex(array, index string) string {
 	return fmt.Sprintf("%s[%s]", array, index)
 }
 
+func (p *RustPrinter) FormatStringIndex(str, index string) string {
+	return p.FormatArrayIndex(str, index)
+}
+
+func (p *RustPrinter) FormatGeneric(name, types string) string {
+	return fmt.Sprintf("%s::<%s>", name, types)
+}
+
 func (p *RustPrinter) FormatSlice(slice, low, high, max string) string {
 	if max == "" {
 		return fmt.Sprintf("%s[%s:%s]", slice, low, high)
@@ -307,7 +451,7 @@ func (p *RustPrinter) FormatSlice(slice, low, high, max string) string {
 }
 
 func (p *RustPrinter) FormatMap(key, elt string) string {
-	return fmt.Sprintf("[%s]%s", key, elt)
+	return fmt.Sprintf("HashMap<%s, %s>", key, elt)
 }
 
 func (p *RustPrinter) FormatKeyValue(key, value string) string {
@@ -335,9 +479,18 @@ func (p *RustPrinter) FormatChan(chdir, mtype string) string {
 }
 
 func (p *RustPrinter) FormatCall(fun, args string, isFuncLit bool) string {
+	if !isFuncLit && fun != "string" && p.symbols.IsType(fun) {
+		// type conversion
+		return fmt.Sprintf("(%s as %s)", args, fun)
+	}
+
 	return fmt.Sprintf("%s(%s)", fun, args)
 }
 
+func (p *RustPrinter) FormatSpread(arg string) string {
+	return fmt.Sprintf("%s...", arg)
+}
+
 func (p *RustPrinter) FormatFuncType(params, results string, withFunc bool) string {
 	prefix := ""
 	if withFunc {
@@ -359,7 +512,7 @@ func (p *RustPrinter) FormatFuncType(params, results string, withFunc bool) stri
 }
 
 func (p *RustPrinter) FormatFuncLit(ftype, body string) string {
-	return fmt.Sprintf("fn%s %s", ftype, body)
+	return fmt.Sprintf("%s %s", ftype, body)
 }
 
 func (p *RustPrinter) FormatSelector(pname, sel string, isObject bool) string {
@@ -367,5 +520,17 @@ func (p *RustPrinter) FormatSelector(pname, sel string, isObject bool) string {
 }
 
 func (p *RustPrinter) FormatTypeAssert(orig, assert string) string {
+	if len(assert) == 0 {
+		assert = "type"
+	}
+
 	return fmt.Sprintf("%s.(%s)", orig, assert)
 }
+
+func (p *RustPrinter) FormatAtomic(mtype string) string {
+	return mtype
+}
+
+func (p *RustPrinter) FormatUnsupported(what string) string {
+	return fmt.Sprintf("unimplemented!(%q)", "unsupported "+what)
+}
@@ -0,0 +1,49 @@
+package printer
+
+import (
+	"encoding/json"
+	"go/token"
+	"os"
+)
+
+//
+// SourceMap maps the lines of a generated file to the positions in the Go source
+// of the declarations and statements they were converted from (see Pipeline.SetSourceMap)
+//
+type SourceMap struct {
+	File     string        `json:"file"`     // the generated file
+	Source   string        `json:"source"`   // the Go source file
+	Mappings []LineMapping `json:"mappings"` // the mapped lines, in order (lines without a Go source are not listed)
+}
+
+//
+// LineMapping is the Go source position of a line of the generated file
+//
+type LineMapping struct {
+	Line         int `json:"line"`          // line in the generated file (1 based)
+	SourceLine   int `json:"source_line"`   // line in the Go source (1 based)
+	SourceColumn int `json:"source_column"` // column in the Go source (1 based, in bytes)
+}
+
+//
+// Reset clears the mappings, for a new generated file
+//
+func (m *SourceMap) Reset(file, source string) {
+	m.File, m.Source, m.Mappings = file, source, nil
+}
+
+func (m *SourceMap) add(line int, pos token.Position) {
+	m.Mappings = append(m.Mappings, LineMapping{Line: line, SourceLine: pos.Line, SourceColumn: pos.Column})
+}
+
+//
+// WriteFile writes the source map as JSON
+//
+func (m *SourceMap) WriteFile(filename string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
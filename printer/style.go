@@ -0,0 +1,119 @@
+package printer
+
+import (
+	"strings"
+)
+
+// BraceStyle describes where the opening brace of a code block goes
+type BraceStyle int
+
+const (
+	BRACE_KR     BraceStyle = iota // opening brace at the end of the statement line (} else {)
+	BRACE_ALLMAN                   // opening brace and else on their own line
+)
+
+//
+// FormatStyle describes the layout of the generated code
+//
+type FormatStyle struct {
+	Indent   string      // indentation for each level (empty for the default, two spaces)
+	Braces   BraceStyle  // placement of the opening brace for code blocks
+	MaxWidth int         // maximum line width (0 for no limit, see WrapLines)
+	Terms    Terminators // statement terminators and separators (empty fields for the printer defaults)
+}
+
+//
+// Terminators are the strings printers use to end lines and statements and to separate list items
+//
+type Terminators struct {
+	NL      string // end of line
+	Semi    string // end of statement
+	Colon   string // end of label (i.e. case)
+	Comma   string // list separator
+	CommaNL string // list separator, one item per line
+}
+
+//
+// DefaultTerminators are the terminators for C-like languages
+//
+var DefaultTerminators = Terminators{NL: "\n", Semi: ";\n", Colon: ":\n", Comma: ", ", CommaNL: ",\n"}
+
+//
+// Or returns a copy of t where the empty fields are taken from def
+//
+func (t Terminators) Or(def Terminators) Terminators {
+	if len(t.NL) == 0 {
+		t.NL = def.NL
+	}
+	if len(t.Semi) == 0 {
+		t.Semi = def.Semi
+	}
+	if len(t.Colon) == 0 {
+		t.Colon = def.Colon
+	}
+	if len(t.Comma) == 0 {
+		t.Comma = def.Comma
+	}
+	if len(t.CommaNL) == 0 {
+		t.CommaNL = def.CommaNL
+	}
+
+	return t
+}
+
+//
+// DefaultStyle is the style used by printers if SetStyle is not called
+//
+var DefaultStyle = FormatStyle{Indent: "  ", Braces: BRACE_KR}
+
+//
+// IndentLevel returns the indentation string for the specified level
+//
+func (s FormatStyle) IndentLevel(level int) string {
+	if level <= 0 {
+		return ""
+	}
+
+	indent := s.Indent
+	if len(indent) == 0 {
+		indent = DefaultStyle.Indent
+	}
+
+	return strings.Repeat(indent, level)
+}
+
+//
+// indentCache caches the indentation strings of a style by level, so that they are not built for every line
+// (it must be reset when the style changes)
+//
+type indentCache []string
+
+//
+// level returns the indentation string for the specified level of style s
+//
+func (c *indentCache) level(s FormatStyle, level int) string {
+	if level <= 0 {
+		return ""
+	}
+
+	for len(*c) <= level {
+		*c = append(*c, s.IndentLevel(len(*c)))
+	}
+
+	return (*c)[level]
+}
+
+//
+// ParseBraceStyle converts a brace style name ("kr", "allman") to a BraceStyle
+//
+func ParseBraceStyle(name string) (BraceStyle, bool) {
+	switch strings.ToLower(name) {
+	case "kr", "k&r", "same-line":
+		return BRACE_KR, true
+
+	case "allman", "next-line":
+		return BRACE_ALLMAN, true
+	}
+
+	return BRACE_KR, false
+}
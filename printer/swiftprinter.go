@@ -2,7 +2,10 @@ package printer
 
 import (
 	"fmt"
+	"go/token"
 	"io"
+	"log/slog"
+	"strconv"
 	"strings"
 )
 
@@ -15,23 +18,77 @@ type SwiftPrinter struct {
 	level    int
 	sameline bool
 	w        io.Writer
+	out      outBuffer
+	style    FormatStyle
+	indents  indentCache
+	log      *slog.Logger
+	symbols  *SymbolTable
+	pos      token.Position
+
+	ctx   ContextStack
+	names Names
 }
 
 func (p *SwiftPrinter) Reset() {
 	p.level = 0
 	p.sameline = false
+
+	p.ctx.Reset()
+	p.names.Reset()
+}
+
+func (p *SwiftPrinter) Capabilities() Capability {
+	return CAP_SWITCH_ANY
+}
+
+func (p *SwiftPrinter) ReservedWords() map[string]bool {
+	return swiftReserved
+}
+
+func (p *SwiftPrinter) MangleName(name string) string {
+	return mangleReserved(name, swiftReserved)
 }
 
 func (p *SwiftPrinter) PushContext() {
+	p.ctx.Push()
 }
 
 func (p *SwiftPrinter) PopContext() {
+	p.ctx.Pop()
+}
+
+func (p *SwiftPrinter) ReserveNames(names ...string) {
+	p.names.Reserve(names...)
+}
+
+//
+// terms returns the terminators for the current style
+//
+func (p *SwiftPrinter) terms() Terminators {
+	return p.style.Terms.Or(DefaultTerminators)
+}
+
+func (p *SwiftPrinter) SetSymbols(symbols *SymbolTable) {
+	p.symbols = symbols
+}
+
+func (p *SwiftPrinter) SetPosition(pos token.Position) {
+	p.pos = pos
 }
 
 func (p *SwiftPrinter) SetWriter(w io.Writer) {
 	p.w = w
 }
 
+func (p *SwiftPrinter) SetStyle(style FormatStyle) {
+	p.style = style
+	p.indents = nil
+}
+
+func (p *SwiftPrinter) SetLogger(logger *slog.Logger) {
+	p.log = logger
+}
+
 func (p *SwiftPrinter) UpdateLevel(delta int) {
 	p.level += delta
 }
@@ -45,24 +102,25 @@ func (p *SwiftPrinter) IsSameLine() bool {
 }
 
 func (p *SwiftPrinter) Chop(line string) string {
-	return strings.TrimRight(line, COMMA)
+	return strings.TrimRight(line, p.terms().Comma)
 }
 
 func (p *SwiftPrinter) indent() string {
 	if p.sameline {
+		// continue the statement header (i.e. the opening brace or the if after else)
 		p.sameline = false
-		return ""
+		return " "
 	}
 
-	return strings.Repeat("  ", p.level)
+	return p.indents.level(p.style, p.level)
 }
 
 func (p *SwiftPrinter) Print(values ...string) {
-	fmt.Fprint(p.w, strings.Join(values, " "))
+	p.out.writeValues(p.w, "", values, NONE)
 }
 
 func (p *SwiftPrinter) PrintLevel(term string, values ...string) {
-	fmt.Fprint(p.w, p.indent(), strings.Join(values, " "), term)
+	p.out.writeValues(p.w, p.indent(), values, term)
 }
 
 func (p *SwiftPrinter) PrintBlockStart(b BlockType) {
@@ -75,7 +133,12 @@ func (p *SwiftPrinter) PrintBlockStart(b BlockType) {
 		open = "{"
 	}
 
-	p.PrintLevel(NL, open)
+	if b == CODE && p.sameline && p.style.Braces == BRACE_ALLMAN {
+		p.sameline = false
+		p.Print(p.terms().NL)
+	}
+
+	p.PrintLevel(p.terms().NL, open)
 	p.UpdateLevel(UP)
 }
 
@@ -94,15 +157,66 @@ func (p *SwiftPrinter) PrintBlockEnd(b BlockType) {
 }
 
 func (p *SwiftPrinter) PrintPackage(name string) {
-	p.PrintLevel(NL, "package", name)
+	p.PrintLevel(p.terms().NL, "package", name)
 }
 
 func (p *SwiftPrinter) PrintImport(name, path string) {
-	p.PrintLevel(NL, "import", name, path)
+	p.PrintLevel(p.terms().NL, "import", name, path)
+}
+
+func (p *SwiftPrinter) PrintImportsEnd() {
+	// the imports are printed as they are
+}
+
+func (p *SwiftPrinter) PrintNamespace(name string) {
+	// the package is the namespace
+}
+
+func (p *SwiftPrinter) PrintNamespaceEnd(name string) {
 }
 
 func (p *SwiftPrinter) PrintType(name, typedef string) {
-	p.PrintLevel(NL, "type", name, typedef)
+	p.PrintLevel(p.terms().NL, "type", name, typedef)
+}
+
+func (p *SwiftPrinter) PrintNamedType(name, typedef string, methods []Method) {
+	p.PrintType(name, typedef)
+}
+
+func (p *SwiftPrinter) PrintStruct(name, fields string, methods []Method) {
+	p.PrintType(name, p.FormatStruct(fields))
+}
+
+func (p *SwiftPrinter) PrintEnum(name, typedef string, names, values []string) {
+	if names == nil {
+		p.PrintType(name, typedef)
+		return
+	}
+
+	for i, n := range names {
+		p.PrintValue("const", name, n, values[i], false, false)
+	}
+}
+
+func (p *SwiftPrinter) PrintStructTags(name string, fields []StructField) {
+}
+
+func (p *SwiftPrinter) PrintComparable(name string, fields []string) {
+}
+
+func (p *SwiftPrinter) PrintTypeName(name, typedef, goname string) {
+}
+
+func (p *SwiftPrinter) PrintDoc(doc string) {
+	for _, line := range docLines(doc) {
+		p.PrintLevel(p.terms().NL, strings.TrimRight("/// "+line, " "))
+	}
+}
+
+func (p *SwiftPrinter) PrintComment(comment string) {
+	for _, line := range docLines(comment) {
+		p.PrintLevel(p.terms().NL, strings.TrimRight("// "+line, " "))
+	}
 }
 
 func (p *SwiftPrinter) PrintValue(vtype, typedef, names, values string, ntuple, vtuple bool) {
@@ -126,12 +240,20 @@ func (p *SwiftPrinter) PrintValue(vtype, typedef, names, values string, ntuple,
 
 func (p *SwiftPrinter) PrintStmt(stmt, expr string) {
 	if len(stmt) > 0 {
-		p.PrintLevel(NL, stmt, expr)
+		p.PrintLevel(p.terms().NL, stmt, expr)
 	} else {
-		p.PrintLevel(NL, expr)
+		p.PrintLevel(p.terms().NL, expr)
 	}
 }
 
+func (p *SwiftPrinter) PrintLabel(name string) {
+	p.PrintLevel(p.terms().NL, name+":")
+}
+
+func (p *SwiftPrinter) PrintGuard(mutex, release string) {
+	p.PrintStmt("defer", release)
+}
+
 func (p *SwiftPrinter) PrintReturn(expr string, tuple bool) {
 	p.PrintStmt("return", expr)
 }
@@ -141,36 +263,35 @@ func (p *SwiftPrinter) PrintFunc(receiver, name, params, results string) {
 	if len(receiver) > 0 {
 		fmt.Fprintf(p.w, "(%s) ", receiver)
 	}
-	fmt.Fprintf(p.w, "%s(%s) ", name, params)
+	fmt.Fprintf(p.w, "%s(%s)", name, params)
 	if len(results) > 0 {
 		if strings.ContainsAny(results, " ,") {
 			// name type or multiple types
-			fmt.Fprintf(p.w, "-> (%s) ", results)
+			fmt.Fprintf(p.w, " -> (%s)", results)
 		} else {
-			fmt.Fprintf(p.w, "-> %s ", results)
+			fmt.Fprintf(p.w, " -> %s", results)
 		}
 	}
 }
 
+func (p *SwiftPrinter) PrintPrototype(name, params, results string) {
+	// functions can be used before their definition
+}
+
 func (p *SwiftPrinter) PrintFor(init, cond, post string) {
-	p.PrintLevel(NONE, "for ")
-	if len(init) > 0 {
-		p.Print(init)
-	}
+	header := cond
 	if len(init) > 0 || len(post) > 0 {
-		p.Print("; ")
+		header = init + "; " + cond + "; " + post
 	}
 
-	p.Print(cond)
-
-	if len(post) > 0 {
-		p.Print(";", post)
-	}
-
-	p.Print("")
+	p.PrintLevel(NONE, strings.TrimSpace("for "+header))
 }
 
 func (p *SwiftPrinter) PrintRange(key, value, expr string) {
+	if len(key) == 0 {
+		key = "_" // for range x
+	}
+
 	p.PrintLevel(NONE, "for", key)
 
 	if len(value) > 0 {
@@ -182,18 +303,19 @@ func (p *SwiftPrinter) PrintRange(key, value, expr string) {
 }
 
 func (p *SwiftPrinter) PrintSwitch(init, expr string) {
-	p.PrintLevel(NONE, "switch ")
+	header := expr
 	if len(init) > 0 {
-		p.Print(init + "; ")
+		header = init + "; " + expr
 	}
-	p.Print(expr)
+
+	p.PrintLevel(NONE, strings.TrimSpace("switch "+header))
 }
 
 func (p *SwiftPrinter) PrintCase(expr string) {
 	if len(expr) > 0 {
-		p.PrintLevel(COLON, "case", expr)
+		p.PrintLevel(p.terms().Colon, "case", expr)
 	} else {
-		p.PrintLevel(NL, "default:")
+		p.PrintLevel(p.terms().NL, "default:")
 	}
 }
 
@@ -202,34 +324,42 @@ func (p *SwiftPrinter) PrintEndCase() {
 }
 
 func (p *SwiftPrinter) PrintIf(init, cond string) {
-	p.PrintLevel(NONE, "if ")
 	if len(init) > 0 {
-		p.Print(init + "; ")
+		cond = init + "; " + cond
 	}
-	p.Print(cond, "")
+
+	p.PrintLevel(NONE, "if", cond)
 }
 
 func (p *SwiftPrinter) PrintElse() {
-	p.Print(" else ")
+	if p.style.Braces == BRACE_ALLMAN {
+		p.sameline = false
+		p.Print(p.terms().NL)
+		p.PrintLevel(NONE, "else")
+		return
+	}
+
+	p.Print(" else")
 }
 
 func (p *SwiftPrinter) PrintEmpty() {
-	p.PrintLevel(SEMI, "")
+	p.PrintLevel(p.terms().Semi, "")
 }
 
 func (p *SwiftPrinter) PrintAssignment(lhs, op, rhs string, ltuple, rtuple bool) {
-	p.PrintLevel(NL, lhs, op, rhs)
+	p.PrintLevel(p.terms().NL, lhs, op, rhs)
 }
 
 func (p *SwiftPrinter) PrintSend(ch, value string) {
-	p.PrintLevel(SEMI, ch, "<-", value)
+	p.PrintLevel(p.terms().Semi, ch, "<-", value)
 }
 
 func (p *SwiftPrinter) FormatIdent(id string) (ret string) {
 	switch id {
-	//ase IOTA:
-	//ret = strconv.Itoa(p.ctx.iota)
-	//p.ctx.iota += 1
+	case IOTA:
+		ctx := p.ctx.Current()
+		ret = strconv.Itoa(ctx.Iota)
+		ctx.Iota += 1
 
 	case "string":
 		ret = "String"
@@ -295,6 +425,14 @@ func (p *SwiftPrinter) FormatUnary(op, operand string) string {
 	return fmt.Sprintf("%s%s", op, operand)
 }
 
+func (p *SwiftPrinter) FormatIncDec(operand, op string) string {
+	// no ++/--
+	if op == "++" {
+		return fmt.Sprintf("%s += 1", operand)
+	}
+	return fmt.Sprintf("%s -= 1", operand)
+}
+
 func (p *SwiftPrinter) FormatBinary(lhs, op, rhs string) string {
 	return fmt.Sprintf("%s %s %s", lhs, op, rhs)
 }
@@ -302,13 +440,13 @@ func (p *SwiftPrinter) FormatBinary(lhs, op, rhs string) string {
 func (p *SwiftPrinter) FormatPair(v Pair, t FieldType) string {
 	switch t {
 	case METHOD:
-		return p.indent() + v.Name() + v.Value() + NL
+		return p.indent() + v.Name() + v.Value() + p.terms().NL
 	case FIELD:
-		return p.indent() + v.String() + NL
+		return p.indent() + v.String() + p.terms().NL
 	case PARAM:
-		return v.Name() + ": " + v.Value() + COMMA
+		return v.Name() + ": " + v.Value() + p.terms().Comma
 	default:
-		return v.String() + COMMA
+		return v.String() + p.terms().Comma
 	}
 }
 
@@ -324,6 +462,14 @@ func (p *SwiftPrinter) FormatArrayIndex(array, index string) string {
 	return fmt.Sprintf("%s[%s]", array, index)
 }
 
+func (p *SwiftPrinter) FormatStringIndex(str, index string) string {
+	return p.FormatArrayIndex(str, index)
+}
+
+func (p *SwiftPrinter) FormatGeneric(name, types string) string {
+	return fmt.Sprintf("%s<%s>", name, types)
+}
+
 func (p *SwiftPrinter) FormatSlice(slice, low, high, max string) string {
 	if max == "" {
 		return fmt.Sprintf("%s[%s:%s]", slice, low, high)
@@ -364,6 +510,10 @@ func (p *SwiftPrinter) FormatCall(fun, args string, isFuncLit bool) string {
 	return fmt.Sprintf("%s(%s)", fun, args)
 }
 
+func (p *SwiftPrinter) FormatSpread(arg string) string {
+	return fmt.Sprintf("%s...", arg)
+}
+
 func (p *SwiftPrinter) FormatFuncType(params, results string, withFunc bool) string {
 	prefix := ""
 	if withFunc {
@@ -385,7 +535,7 @@ func (p *SwiftPrinter) FormatFuncType(params, results string, withFunc bool) str
 }
 
 func (p *SwiftPrinter) FormatFuncLit(ftype, body string) string {
-	return fmt.Sprintf("func%s %s", ftype, body)
+	return fmt.Sprintf("%s %s", ftype, body)
 }
 
 func (p *SwiftPrinter) FormatSelector(pname, sel string, isObject bool) string {
@@ -393,5 +543,17 @@ func (p *SwiftPrinter) FormatSelector(pname, sel string, isObject bool) string {
 }
 
 func (p *SwiftPrinter) FormatTypeAssert(orig, assert string) string {
+	if len(assert) == 0 {
+		assert = "type"
+	}
+
 	return fmt.Sprintf("%s.(%s)", orig, assert)
 }
+
+func (p *SwiftPrinter) FormatAtomic(mtype string) string {
+	return mtype
+}
+
+func (p *SwiftPrinter) FormatUnsupported(what string) string {
+	return fmt.Sprintf("fatalError(%q)", "unsupported "+what)
+}
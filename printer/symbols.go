@@ -0,0 +1,151 @@
+package printer
+
+// SymbolKind is the kind of a declared name
+type SymbolKind int
+
+const (
+	SYM_VAR     SymbolKind = iota // variables, parameters, receivers and results
+	SYM_CONST                     // constants
+	SYM_TYPE                      // type names
+	SYM_FUNC                      // package level functions
+	SYM_PACKAGE                   // imported packages
+)
+
+var symbolKinds = []string{"var", "const", "type", "func", "package"}
+
+func (k SymbolKind) String() string {
+	if k >= 0 && int(k) < len(symbolKinds) {
+		return symbolKinds[k]
+	}
+
+	return "unknown"
+}
+
+//
+// Symbol is a name declared in the source
+//
+type Symbol struct {
+	Name  string
+	Kind  SymbolKind
+	Type  string // the type, as written in the source (empty if not specified)
+	Depth int    // scope depth (0 for the package scope)
+}
+
+// predeclared types, for names that are not in the symbol table
+var predeclaredTypes = map[string]bool{
+	"bool": true, "byte": true, "rune": true, "string": true, "error": true, "any": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true,
+}
+
+//
+// SymbolTable keeps track of the names declared in each scope while walking a file.
+//
+// The walker maintains the table (see Printer.SetSymbols) and printers can query it,
+// i.e. to detect shadowed names or to distinguish type conversions from function calls.
+// All methods can be called on a nil table, that behaves as an empty one.
+//
+type SymbolTable struct {
+	scopes []map[string]*Symbol
+}
+
+//
+// Reset removes all scopes and creates an empty package scope
+//
+func (t *SymbolTable) Reset() {
+	t.scopes = []map[string]*Symbol{{}}
+}
+
+//
+// Push creates a new scope, nested into the current one
+//
+func (t *SymbolTable) Push() {
+	t.scopes = append(t.scopes, map[string]*Symbol{})
+}
+
+//
+// Pop removes the current scope
+//
+func (t *SymbolTable) Pop() {
+	if len(t.scopes) > 0 {
+		t.scopes = t.scopes[:len(t.scopes)-1]
+	}
+}
+
+//
+// Depth returns the depth of the current scope (0 for the package scope, -1 if empty)
+//
+func (t *SymbolTable) Depth() int {
+	if t == nil {
+		return -1
+	}
+
+	return len(t.scopes) - 1
+}
+
+//
+// Declare adds name to the current scope (blank identifiers are ignored)
+//
+func (t *SymbolTable) Declare(name string, kind SymbolKind, typ string) *Symbol {
+	if name == "_" || len(name) == 0 {
+		return nil
+	}
+
+	if len(t.scopes) == 0 {
+		t.Reset()
+	}
+
+	sym := &Symbol{Name: name, Kind: kind, Type: typ, Depth: t.Depth()}
+	t.scopes[sym.Depth][name] = sym
+	return sym
+}
+
+//
+// Lookup returns the innermost declaration of name (nil if not declared)
+//
+func (t *SymbolTable) Lookup(name string) *Symbol {
+	for d := t.Depth(); d >= 0; d-- {
+		if sym, ok := t.scopes[d][name]; ok {
+			return sym
+		}
+	}
+
+	return nil
+}
+
+//
+// IsLocal returns true if name is declared in the current scope
+//
+func (t *SymbolTable) IsLocal(name string) bool {
+	d := t.Depth()
+	return d >= 0 && t.scopes[d][name] != nil
+}
+
+//
+// Shadows returns true if name is declared in the current scope and also in one of the outer scopes
+//
+func (t *SymbolTable) Shadows(name string) bool {
+	if !t.IsLocal(name) {
+		return false
+	}
+
+	for d := t.Depth() - 1; d >= 0; d-- {
+		if t.scopes[d][name] != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// IsType returns true if name refers to a type (declared or predeclared)
+//
+func (t *SymbolTable) IsType(name string) bool {
+	if sym := t.Lookup(name); sym != nil {
+		return sym.Kind == SYM_TYPE
+	}
+
+	return predeclaredTypes[name]
+}
@@ -0,0 +1,277 @@
+package printer
+
+import (
+	"bytes"
+	"go/token"
+	"io"
+	"regexp"
+	"strings"
+)
+
+//
+// Transformer is a post-processing step applied to the generated output, one line at the time.
+//
+// Transform returns the new line (without the terminating newline) and false if the line should be dropped.
+// Reset is called before processing a new file.
+//
+type Transformer interface {
+	Transform(line string) (string, bool)
+	Reset()
+}
+
+//
+// SourceTransformer is a Transformer that is also told the source position of each line (see Pipeline.Mark)
+//
+type SourceTransformer interface {
+	Transformer
+	SetPosition(pos token.Position)
+}
+
+//
+// TransformerFunc is a stateless Transformer
+//
+type TransformerFunc func(line string) (string, bool)
+
+func (f TransformerFunc) Transform(line string) (string, bool) {
+	return f(line)
+}
+
+func (f TransformerFunc) Reset() {
+}
+
+//
+// Pipeline is a writer that runs the generated output through a list of transformers
+// before writing it to the underlying writer.
+//
+// Only the current (incomplete) line is kept in memory.
+//
+type Pipeline struct {
+	w            io.Writer
+	transformers []Transformer
+	line         []byte
+
+	smap    *SourceMap     // records the source position of the output lines, if not nil
+	pos     token.Position // source position of the output being written (see Mark)
+	linePos token.Position // source position of the current line
+	lines   int            // number of output lines written
+}
+
+func NewPipeline(w io.Writer, transformers ...Transformer) *Pipeline {
+	return &Pipeline{w: w, transformers: transformers}
+}
+
+//
+// Add appends transformers to the pipeline
+//
+func (p *Pipeline) Add(transformers ...Transformer) {
+	p.transformers = append(p.transformers, transformers...)
+}
+
+//
+// SetSourceMap records in m the source position of each line written (the position marked
+// when the line was started, see Mark). The lines split by the transformers keep the same position
+//
+func (p *Pipeline) SetSourceMap(m *SourceMap) {
+	p.smap = m
+}
+
+//
+// Mark sets the source position of the output that follows (invalid positions are ignored)
+//
+func (p *Pipeline) Mark(pos token.Position) {
+	if pos.IsValid() {
+		p.pos = pos
+	}
+}
+
+//
+// Reset discards the current line and resets all transformers
+//
+func (p *Pipeline) Reset() {
+	p.line = p.line[:0]
+	p.pos, p.linePos, p.lines = token.Position{}, token.Position{}, 0
+
+	for _, t := range p.transformers {
+		t.Reset()
+	}
+}
+
+func (p *Pipeline) Write(b []byte) (int, error) {
+	if len(p.transformers) == 0 && p.smap == nil {
+		return p.w.Write(b)
+	}
+
+	n := len(b)
+
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			p.appendLine(b)
+			break
+		}
+
+		p.appendLine(b[:i])
+		b = b[i+1:]
+
+		if err := p.writeLine(true); err != nil {
+			return n - len(b), err
+		}
+	}
+
+	return n, nil
+}
+
+//
+// Flush processes and writes the last (incomplete) line, if any
+//
+func (p *Pipeline) Flush() error {
+	if len(p.line) == 0 {
+		return nil
+	}
+
+	return p.writeLine(false)
+}
+
+func (p *Pipeline) appendLine(b []byte) {
+	if len(p.line) == 0 && len(b) > 0 {
+		p.linePos = p.pos
+	}
+
+	p.line = append(p.line, b...)
+}
+
+func (p *Pipeline) writeLine(nl bool) error {
+	line := string(p.line)
+	p.line = p.line[:0]
+
+	for _, t := range p.transformers {
+		var keep bool
+
+		if st, ok := t.(SourceTransformer); ok {
+			st.SetPosition(p.linePos)
+		}
+
+		if line, keep = t.Transform(line); !keep {
+			return nil
+		}
+	}
+
+	for _, l := range strings.Split(line, "\n") {
+		p.lines++
+
+		if p.smap != nil && p.linePos.IsValid() && len(strings.TrimSpace(l)) > 0 {
+			p.smap.add(p.lines, p.linePos)
+		}
+	}
+
+	if nl {
+		line += "\n"
+	}
+
+	_, err := io.WriteString(p.w, line)
+	return err
+}
+
+//
+// TrimTrailingSpaces removes trailing spaces and tabs from each line
+//
+func TrimTrailingSpaces() Transformer {
+	return TransformerFunc(func(line string) (string, bool) {
+		return strings.TrimRight(line, " \t"), true
+	})
+}
+
+//
+// NormalizeIndent replaces each leading occurrence of "from" with "to" (i.e. two spaces to a tab)
+//
+func NormalizeIndent(from, to string) Transformer {
+	return TransformerFunc(func(line string) (string, bool) {
+		if len(from) == 0 {
+			return line, true
+		}
+
+		n := 0
+		for strings.HasPrefix(line[n*len(from):], from) {
+			n++
+		}
+
+		return strings.Repeat(to, n) + line[n*len(from):], true
+	})
+}
+
+//
+// includeDedup drops "#include" lines that were already emitted for the current file
+//
+type includeDedup struct {
+	seen map[string]bool
+}
+
+//
+// DedupIncludes returns a Transformer that removes duplicate #include lines
+//
+func DedupIncludes() Transformer {
+	return &includeDedup{seen: map[string]bool{}}
+}
+
+func (d *includeDedup) Transform(line string) (string, bool) {
+	inc := strings.TrimSpace(line)
+	if !strings.HasPrefix(inc, "#include") {
+		return line, true
+	}
+
+	if d.seen[inc] {
+		return line, false
+	}
+
+	d.seen[inc] = true
+	return line, true
+}
+
+func (d *includeDedup) Reset() {
+	d.seen = map[string]bool{}
+}
+
+//
+// RenameSymbols returns a Transformer that renames whole identifiers according to names (old -> new)
+//
+func RenameSymbols(names map[string]string) Transformer {
+	if len(names) == 0 {
+		return TransformerFunc(func(line string) (string, bool) { return line, true })
+	}
+
+	quoted := make([]string, 0, len(names))
+	for n := range names {
+		quoted = append(quoted, regexp.QuoteMeta(n))
+	}
+
+	re := regexp.MustCompile(`\b(` + strings.Join(quoted, "|") + `)\b`)
+
+	return TransformerFunc(func(line string) (string, bool) {
+		return re.ReplaceAllStringFunc(line, func(s string) string { return names[s] }), true
+	})
+}
+
+//
+// ValidUTF8 replaces the invalid UTF-8 sequences (i.e. from a file name or a source that is not UTF-8)
+// with the replacement character, U+FFFD
+//
+func ValidUTF8() Transformer {
+	return TransformerFunc(func(line string) (string, bool) {
+		return strings.ToValidUTF8(line, "\uFFFD"), true
+	})
+}
+
+//
+// DefaultTransformers returns the post-processing steps that should be applied to the output of a printer
+//
+func DefaultTransformers(p Printer) []Transformer {
+	switch p.(type) {
+	case *CPrinter:
+		return []Transformer{DedupIncludes(), TrimTrailingSpaces(), ValidUTF8()}
+
+	case *DebugPrinter:
+		return DefaultTransformers(p.(*DebugPrinter).P)
+	}
+
+	return []Transformer{ValidUTF8()}
+}
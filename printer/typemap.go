@@ -0,0 +1,38 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+//
+// TypeMap maps Go type names to target types.
+//
+// Keys are predeclared or local type names (i.e. "int", "UserID")
+// or qualified names using the import path (i.e. "time.Duration", "github.com/google/uuid.UUID").
+//
+type TypeMap map[string]string
+
+//
+// LoadTypeMap reads a TypeMap from a JSON file in the form:
+//
+//	{
+//	  "time.Duration": "int64_t",
+//	  "github.com/google/uuid.UUID": "boost::uuids::uuid"
+//	}
+//
+func LoadTypeMap(filename string) (TypeMap, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var m TypeMap
+
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	return m, nil
+}
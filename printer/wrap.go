@@ -0,0 +1,124 @@
+package printer
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+//
+// lineWrapper breaks lines longer than width after a list separator (", "),
+// indenting the continuation lines
+//
+type lineWrapper struct {
+	width  int
+	indent string // continuation indentation, added to the indentation of the line
+	raw    bool   // inside a raw string literal spanning multiple lines
+}
+
+//
+// WrapLines returns a Transformer that wraps lines longer than style.MaxWidth,
+// breaking them after commas (outside of literals and comments) and indenting the continuation lines
+// by two levels. Lines that cannot be broken are left as they are.
+//
+func WrapLines(style FormatStyle) Transformer {
+	return &lineWrapper{width: style.MaxWidth, indent: style.IndentLevel(2)}
+}
+
+func (l *lineWrapper) Reset() {
+	l.raw = false
+}
+
+func (l *lineWrapper) Transform(line string) (string, bool) {
+	breaks := l.breaks(line)
+
+	if l.width <= 0 || len(breaks) == 0 || utf8.RuneCountInString(line) <= l.width {
+		return line, true
+	}
+
+	trimmed := strings.TrimLeft(line, " \t")
+	if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+		// preprocessor directives and comments
+		return line, true
+	}
+
+	cont := line[:len(line)-len(trimmed)] + l.indent
+
+	var lines []string
+
+	prefix, start := "", 0
+
+	for utf8.RuneCountInString(prefix+line[start:]) > l.width {
+		// break at the last separator that fits, or at the first one if none fits
+		cut := -1
+
+		for _, b := range breaks {
+			if b <= start {
+				continue
+			}
+
+			if cut >= 0 && utf8.RuneCountInString(prefix+line[start:b]) > l.width {
+				break
+			}
+
+			cut = b
+		}
+
+		if cut < 0 {
+			break
+		}
+
+		lines = append(lines, prefix+line[start:cut])
+		prefix, start = cont, cut+1
+	}
+
+	lines = append(lines, prefix+line[start:])
+	return strings.Join(lines, "\n"), true
+}
+
+//
+// breaks returns the positions in line where it can be broken (the space after a comma),
+// skipping string and character literals and comments
+//
+func (l *lineWrapper) breaks(line string) (breaks []int) {
+	var quote byte
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		switch {
+		case l.raw:
+			if c == '`' {
+				l.raw = false
+			}
+
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+
+		case c == '`':
+			l.raw = true
+
+		case c == '"' || c == '\'':
+			quote = c
+
+		case strings.HasPrefix(line[i:], "//"):
+			return
+
+		case strings.HasPrefix(line[i:], "/*"):
+			end := strings.Index(line[i+2:], "*/")
+			if end < 0 {
+				return
+			}
+
+			i += end + 3
+
+		case c == ',' && i+1 < len(line) && line[i+1] == ' ':
+			breaks = append(breaks, i+1)
+		}
+	}
+
+	return
+}
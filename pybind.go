@@ -0,0 +1,142 @@
+package main
+
+//
+// pybind11 bindings for the converted packages (--pybind11)
+//
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/raff/walkngo/walker"
+)
+
+//
+// PybindModules collects the converted files and their exported declarations by output folder
+//
+type PybindModules map[string]*pybindModule
+
+type pybindModule struct {
+	name    string
+	sources []string
+	exports []walkngo.Export
+}
+
+//
+// Add records a converted (or overridden) file of a package. Package main and tests are not bound
+//
+func (m PybindModules) Add(outpath, namespace, source string, exports []walkngo.Export) {
+	if strings.HasSuffix(source, "_test.go") {
+		return
+	}
+
+	dir := filepath.Dir(outpath)
+
+	module, ok := m[dir]
+	if !ok {
+		module = &pybindModule{}
+		m[dir] = module
+	}
+
+	if len(namespace) > 0 {
+		module.name = namespace
+	}
+
+	module.sources = appendUnique(module.sources, filepath.Base(outpath))
+	module.exports = append(module.exports, exports...)
+}
+
+//
+// Write writes the bindings ({module}_pybind.cpp) in each output folder with a package other than main
+//
+func (m PybindModules) Write() error {
+	for dir, module := range m {
+		if len(module.name) == 0 {
+			continue
+		}
+
+		filename := filepath.Join(dir, module.name+"_pybind.cpp")
+		if err := os.WriteFile(filename, []byte(module.bindings()), 0644); err != nil {
+			return fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+
+	return nil
+}
+
+//
+// bindings returns the source of the Python extension module for the package: it includes the converted
+// files (so that it can be built as a single translation unit) and binds the exported functions, struct types
+// (with their exported fields and methods), constants and variables (as module attributes, with the value at import time)
+//
+func (m *pybindModule) bindings() string {
+	var b strings.Builder
+
+	ns := m.name
+	sort.Strings(m.sources)
+
+	fmt.Fprintf(&b, "//\n// pybind11 bindings for package %s, build with:\n//\n", ns)
+	fmt.Fprintf(&b, "//     c++ -O2 -shared -fPIC -std=c++17 $(python3 -m pybind11 --includes) -I<runtime> %s_pybind.cpp -o %s$(python3-config --extension-suffix)\n//\n\n", ns, ns)
+	fmt.Fprintf(&b, "#include <go_pybind.h>\n\n")
+
+	for _, s := range m.sources {
+		fmt.Fprintf(&b, "#include %q\n", s)
+	}
+
+	fmt.Fprintf(&b, "\nPYBIND11_MODULE(%s, m) {\n", ns)
+	fmt.Fprintf(&b, "    m.doc() = %q;\n", "Go package "+ns+" (converted by walkngo)")
+
+	methods := map[string][]string{}
+	for _, e := range m.exports {
+		if e.Kind == token.FUNC && len(e.Receiver) > 0 {
+			methods[e.Receiver] = append(methods[e.Receiver], e.Name)
+		}
+	}
+
+	for _, e := range m.exports {
+		if e.Kind != token.TYPE || e.Fields == nil { // only struct types are bound as classes
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n    py::class_<%s::%s>(m, %q)\n", ns, e.Name, e.Name)
+		fmt.Fprintf(&b, "        .def(py::init<>())")
+
+		for _, f := range e.Fields {
+			fmt.Fprintf(&b, "\n        .def_readwrite(%q, &%s::%s::%s)", f, ns, e.Name, f)
+		}
+
+		for _, name := range methods[e.Name] {
+			fmt.Fprintf(&b, "\n        .def(%q, &%s::%s::%s)", name, ns, e.Name, name)
+
+			if name == "String" { // fmt.Stringer
+				fmt.Fprintf(&b, "\n        .def(\"__str__\", &%s::%s::%s)", ns, e.Name, name)
+			}
+		}
+
+		fmt.Fprintf(&b, ";\n")
+	}
+
+	newLine := "\n"
+
+	for _, e := range m.exports {
+		switch {
+		case e.Kind == token.FUNC && len(e.Receiver) == 0:
+			fmt.Fprintf(&b, "%s    m.def(%q, &%s::%s);\n", newLine, e.Name, ns, e.Name)
+
+		case e.Kind == token.CONST || e.Kind == token.VAR:
+			fmt.Fprintf(&b, "%s    m.attr(%q) = %s::%s;\n", newLine, e.Name, ns, e.Name)
+
+		default:
+			continue
+		}
+
+		newLine = ""
+	}
+
+	fmt.Fprintf(&b, "}\n")
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package main
+
+//
+// The "runtime" command, to install the C++ runtime for the converted code
+//
+
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+//go:embed runtime/c/*.h
+var cruntime embed.FS
+
+// pkg-config file for the runtime (the prefix is the install folder)
+const pkgConfig = `prefix=%s
+includedir=${prefix}/include/walkngo
+
+Name: walkngo
+Description: Runtime for C++ code converted from Go by walkngo
+Version: %s
+Cflags: -std=c++17 -I${includedir}
+Libs: -pthread
+`
+
+//
+// runtimeCommand implements "walkngo runtime --install PREFIX":
+// it installs the runtime headers in PREFIX/include/walkngo and walkngo.pc in PREFIX/lib/pkgconfig,
+// so that the converted code can be built with "pkg-config --cflags --libs walkngo"
+//
+func runtimeCommand(args []string) error {
+	flags := flag.NewFlagSet("runtime", flag.ExitOnError)
+	prefix := flags.String("install", "", "install the runtime headers and walkngo.pc in the specified prefix (i.e. /usr/local)")
+	flags.Parse(args)
+
+	if len(*prefix) == 0 {
+		flags.Usage()
+		return fmt.Errorf("missing --install prefix")
+	}
+
+	root, err := filepath.Abs(*prefix)
+	if err != nil {
+		return err
+	}
+
+	incdir := filepath.Join(root, "include", "walkngo")
+	pcdir := filepath.Join(root, "lib", "pkgconfig")
+
+	for _, dir := range []string{incdir, pcdir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	headers, err := cruntime.ReadDir("runtime/c")
+	if err != nil {
+		return err
+	}
+
+	for _, h := range headers {
+		data, err := cruntime.ReadFile("runtime/c/" + h.Name())
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(filepath.Join(incdir, h.Name()), data, 0644); err != nil {
+			return err
+		}
+
+		fmt.Println("installed", filepath.Join(incdir, h.Name()))
+	}
+
+	pc := filepath.Join(pcdir, "walkngo.pc")
+	if err := os.WriteFile(pc, []byte(fmt.Sprintf(pkgConfig, root, version)), 0644); err != nil {
+		return err
+	}
+
+	fmt.Println("installed", pc)
+	return nil
+}
@@ -0,0 +1,30 @@
+# conan recipe for the walkngo runtime (header only), used by the conanfile.py generated with --manifest=conan
+#
+#     conan create runtime/c
+#
+import os
+
+from conan import ConanFile
+from conan.tools.files import copy
+
+
+class WalkngoConan(ConanFile):
+    name = "walkngo"
+    version = "0.1"
+    description = "Runtime for C++ code converted from Go by walkngo"
+    license = "MIT"
+    package_type = "header-library"
+    exports_sources = "*.h"
+    no_copy_source = True
+
+    def package(self):
+        copy(self, "*.h", self.source_folder, os.path.join(self.package_folder, "include", "walkngo"))
+
+    def package_id(self):
+        self.info.clear()
+
+    def package_info(self):
+        self.cpp_info.includedirs = ["include/walkngo"]
+        self.cpp_info.bindirs = []
+        self.cpp_info.libdirs = []
+        self.cpp_info.system_libs = ["pthread"]
@@ -0,0 +1,46 @@
+#ifndef _GO_RUNTIME_ENCODING_JSON_H
+#define _GO_RUNTIME_ENCODING_JSON_H 1
+
+//
+// encoding/json implemented with nlohmann::json (https://github.com/nlohmann/json).
+// The converter generates to_json/from_json for struct types with json tags.
+//
+
+#include <string>
+#include <tuple>
+#include <nlohmann/json.hpp>
+
+namespace json {
+
+template<typename T> std::tuple<std::string, error> Marshal(const T& v) {
+    try {
+        return std::make_tuple(nlohmann::json(v).dump(), error(nullptr));
+    } catch (nlohmann::json::exception& e) {
+        return std::make_tuple(std::string(), error(std::string("json: ") + e.what()));
+    }
+}
+
+template<typename T> std::tuple<std::string, error> MarshalIndent(const T& v, std::string prefix, std::string indent) {
+    try {
+        return std::make_tuple(nlohmann::json(v).dump(indent.size()), error(nullptr));
+    } catch (nlohmann::json::exception& e) {
+        return std::make_tuple(std::string(), error(std::string("json: ") + e.what()));
+    }
+}
+
+template<typename T> error Unmarshal(const std::string& data, T* v) {
+    try {
+        nlohmann::json::parse(data).get_to(*v);
+        return nullptr;
+    } catch (nlohmann::json::exception& e) {
+        return error(std::string("json: ") + e.what());
+    }
+}
+
+inline bool Valid(const std::string& data) {
+    return nlohmann::json::accept(data);
+}
+
+}
+
+#endif
@@ -1,16 +1,142 @@
 #ifndef _GO_RUNTIME_FMT_H
 #define _GO_RUNTIME_FMT_H 1
 
+#include <complex>
+#include <cstring>
 #include <iostream>
+#include <sstream>
+#include <string>
+
+#include <go.h>
 
 namespace fmt {
 
+//
+// value returns the arguments as they are printed, except the bytes (uint8 and int8, printed as numbers)
+// and the complex numbers, that are printed like in Go: (1+2i)
+//
+template<typename T> const T& value(const T& v) {
+    return v;
+}
+
+inline int value(uint8 v) {
+    return v; // a number, not a char
+}
+
+inline int value(int8 v) {
+    return v;
+}
+
+template<typename T> std::string value(const std::complex<T>& c) {
+    std::ostringstream out;
+    out << '(' << c.real() << std::showpos << c.imag() << "i)";
+    return out.str();
+}
+
+//
+// print prints a value, or its type for the values without operator<< (that can be formatted with %T)
+//
+template<typename T> void print(std::ostream& out, const T& v) {
+    if constexpr (go_printable<T>::value) {
+        out << value(v);
+    } else {
+        out << "<" << go_type_of(v) << ">";
+    }
+}
+
+//
+// print_char prints a byte or a rune (ASCII only) as a character, for %c
+//
+template<typename T> void print_char(std::ostream& out, const T& v) {
+    if constexpr (std::is_integral_v<T>) {
+        out << char(v);
+    } else {
+        print(out, v);
+    }
+}
+
+//
+// format writes format to out, replacing each verb (%v, %d, %s, %q, %T, ...) with the next argument.
+// Flags, width and precision are ignored.
+//
+inline void format(std::ostream& out, const char* f) {
+    for (; *f; f++) {
+        if (*f == '%' && f[1] == '%') {
+            f++;
+        }
+
+        out << *f;
+    }
+}
+
+template<typename T, typename... A> void format(std::ostream& out, const char* f, T arg, A... args) {
+    for (; *f; f++) {
+        if (*f != '%') {
+            out << *f;
+            continue;
+        }
+
+        if (f[1] == '%') {
+            out << '%';
+            f++;
+            continue;
+        }
+
+        const char* verb = f + 1;
+        while (*verb && strchr("+-# 0123456789.", *verb)) {
+            verb++;
+        }
+
+        if (*verb == 'T') {
+            out << go_type_of(arg);
+        } else if (*verb == 'c') {
+            print_char(out, arg);
+        } else if (*verb == 'q') {
+            out << '"';
+            print(out, arg);
+            out << '"';
+        } else {
+            print(out, arg);
+        }
+
+        format(out, *verb ? verb + 1 : verb, args...);
+        return;
+    }
+}
+
+template<typename... T> std::string Sprintf(std::string f, T... args) {
+    std::ostringstream out;
+    out << std::boolalpha;
+    format(out, f.c_str(), args...);
+    return out.str();
+}
+
+template<typename... T> void Printf(std::string f, T... args) {
+    std::cout << Sprintf(f, args...);
+}
+
+template<typename... T> std::string Sprint(T... args) {
+    std::ostringstream out;
+    out << std::boolalpha;
+    int dummy[sizeof...(T) + 1] = { 0, (out << value(args), 0)... };
+    return out.str();
+}
+
+template<typename... T> std::string Sprintln(T... args) {
+    std::ostringstream out;
+    out << std::boolalpha;
+    const char* sep = "";
+    int dummy[sizeof...(T) + 1] = { 0, (out << sep << value(args), sep = " ", 0)... };
+    out << std::endl;
+    return out.str();
+}
+
 template<typename... T> void Print(T... args) {
-    int dummy[sizeof...(T)] = { (std::cout << args, 0)... };
+    int dummy[sizeof...(T)] = { (std::cout << value(args), 0)... };
 }
 
 template<typename... T> void Println(T... args) {
-    int dummy[sizeof...(T)] = { (std::cout << args << " ", 0)... };
+    int dummy[sizeof...(T)] = { (std::cout << value(args) << " ", 0)... };
     std::cout << std::endl;
 }
 
@@ -1,6 +1,9 @@
 #ifndef _GO_RUNTIME_H
 #define _GO_RUNTIME_H 1
 
+#include <cstdint>
+#include <cstdlib>
+#include <cstring>
 #include <iostream>
 #include <map>
 #include <string>
@@ -9,6 +12,15 @@
 #include <thread>
 #include <mutex>
 #include <condition_variable>
+#include <any>
+#include <array>
+#include <complex>
+#include <functional>
+#include <type_traits>
+#include <typeinfo>
+#if defined(__GNUG__)
+#include <cxxabi.h>
+#endif
 
 typedef unsigned char      uint8;
 typedef unsigned short int uint16;
@@ -20,31 +32,224 @@ typedef signed short int int16;
 typedef signed int       int32;
 typedef signed long int  int64;
 
+typedef uintptr_t uintptr;
+
 typedef float  float32;
 typedef double float64;
 
+typedef std::complex<float>  complex64;
+typedef std::complex<double> complex128;
+
 typedef uint8 byte;
 typedef int32 rune;
 
 class error {
 private:
     std::string s;
+    bool isnil = true;
 public:
+    error() {
+    }
+
+    error(std::nullptr_t) {
+    }
+
     error(std::string message) {
         s = message;
+        isnil = false;
     }
 
     std::string Error() {
         return s;
     }
+
+    bool operator==(std::nullptr_t) const {
+        return isnil;
+    }
+
+    bool operator!=(std::nullptr_t) const {
+        return !isnil;
+    }
+
+    friend std::ostream& operator<<(std::ostream& out, const error& e) {
+        return out << (e.isnil ? "<nil>" : e.s);
+    }
+};
+
+//
+// go_empty is the empty struct (struct{}), for the signals sent on channels (done <- struct{}{}) and the values
+// of the sets (map[string]struct{}): like std::monostate all the values are equal, and they are printed as {}
+//
+struct go_empty {
+    bool operator==(const go_empty&) const { return true; }
+    bool operator!=(const go_empty&) const { return false; }
+    bool operator<(const go_empty&) const { return false; }
 };
 
+inline std::ostream& operator<<(std::ostream& out, const go_empty&) {
+    return out << "{}";
+}
+
+//
+// Any is the empty interface (interface{}, any): a boxed value of any type, that can be printed
+// (values without operator<< are printed as their type), compared with nil and that knows the Go name
+// of the type of its value, for %T (see go_type_of). Compiling with -DWALKNGO_STD_ANY it's std::any,
+// lighter but without printing and type names.
+//
+template<typename T, typename = void> struct go_printable : std::false_type {};
+template<typename T> struct go_printable<T, std::void_t<decltype(std::declval<std::ostream&>() << std::declval<const T&>())>> : std::true_type {};
+
+template<typename T> std::string go_type_name_of(); // see go_type_name
+
+#ifdef WALKNGO_STD_ANY
+typedef std::any Any;
+#else
+
+class Any {
+private:
+    std::any v;
+    void (*print)(std::ostream&, const std::any&) = nullptr;
+    std::string (*type_name)() = nullptr;
+
+    template<typename T> static void printValue(std::ostream& out, const std::any& v) {
+        if constexpr (go_printable<T>::value) {
+            out << std::any_cast<const T&>(v);
+        } else {
+            out << "<" << go_type_name_of<T>() << ">";
+        }
+    }
+
+public:
+    Any() {
+    }
+
+    Any(std::nullptr_t) {
+    }
+
+    Any(const char *s) : Any(std::string(s)) {
+    }
+
+    template<typename T, typename = std::enable_if_t<!std::is_same_v<std::decay_t<T>, Any>>> Any(T value)
+        : v(std::move(value)), print(printValue<T>), type_name(go_type_name_of<T>) {
+    }
+
+    bool has_value() const {
+        return v.has_value();
+    }
+
+    const std::type_info& type() const {
+        return v.type();
+    }
+
+    template<typename T> friend const T* go_any_cast(const Any* a) {
+        return std::any_cast<T>(&a->v);
+    }
+
+    bool operator==(std::nullptr_t) const {
+        return !v.has_value();
+    }
+
+    bool operator!=(std::nullptr_t) const {
+        return v.has_value();
+    }
+
+    friend std::string go_type_of(const Any& a) {
+        return a.type_name ? a.type_name() : "<nil>";
+    }
+
+    friend std::ostream& operator<<(std::ostream& out, const Any& a) {
+        if (a.print) {
+            a.print(out, a.v);
+        } else {
+            out << "<nil>";
+        }
+
+        return out;
+    }
+};
+
+template<typename T> const T* go_any_cast(const Any* a);
+std::string go_type_of(const Any& a);
+#endif
+
+//
+// go_type_assert implements x.(T): the value of type T in x, or a panic
+//
+template<typename T> T go_type_assert(const Any& x) {
+#ifdef WALKNGO_STD_ANY
+    const T* v = std::any_cast<T>(&x);
+#else
+    const T* v = go_any_cast<T>(&x);
+#endif
+    if (v == nullptr) {
+        std::cerr << "panic: interface conversion: interface {} is not " << go_type_name_of<T>() << std::endl;
+        std::abort();
+    }
+
+    return *v;
+}
+
+//
+// go_type_assert_ok implements v, ok := x.(T): the value of type T in x and true, or the zero value of T and false
+//
+template<typename T> std::tuple<T, bool> go_type_assert_ok(const Any& x) {
+#ifdef WALKNGO_STD_ANY
+    const T* v = std::any_cast<T>(&x);
+#else
+    const T* v = go_any_cast<T>(&x);
+#endif
+    if (v == nullptr) {
+        return std::make_tuple(T{}, false);
+    }
+
+    return std::make_tuple(*v, true);
+}
+
 inline void panic(std::string &arg) {
     std::cerr << "panic: " << arg << std::endl;
     char *paniker = 0;
     *paniker = 0;
 }
 
+//
+// go_unsupported is the placeholder for the constructs walkngo couldn't convert (i.e. "select statement at foo.go:42:2"):
+// it compiles where a value is expected (of type T, or of any type with the default) and panics when evaluated
+//
+struct go_unsupported_value {
+    template<typename T> operator T() const {
+        return T{};
+    }
+};
+
+template<typename T = go_unsupported_value> T go_unsupported(std::string what) {
+    std::cerr << "panic: unsupported " << what << std::endl;
+    std::abort();
+}
+
+//
+// go_named is the base of the named types with methods that are not structs (type MyInt int):
+// it converts to and from the underlying type, so that the operators and the functions of the
+// underlying type still apply (class types are inherited, other types are wrapped)
+//
+template<class T> struct go_named_value {
+    T value;
+
+    go_named_value() : value() {}
+    go_named_value(const T& v) : value(v) {}
+
+    operator T&() { return value; }
+    operator const T&() const { return value; }
+};
+
+template<class T> struct go_named_class : T {
+    using T::T;
+
+    go_named_class() = default;
+    go_named_class(const T& v) : T(v) {}
+};
+
+template<class T> using go_named = typename std::conditional<std::is_class<T>::value, go_named_class<T>, go_named_value<T>>::type;
+
 inline void Goroutine(std::function<void()> const& fun) {
     std::thread t(fun);
     t.detach();
@@ -63,6 +268,25 @@ public:
     }
 };
 
+//
+// go_scope_exit calls a function at the end of the scope (see --raii)
+//
+template<class F> class go_scope_exit {
+private:
+    F f;
+
+public:
+    go_scope_exit(F fun) : f(std::move(fun)) {
+    }
+
+    go_scope_exit(const go_scope_exit&) = delete;
+    go_scope_exit& operator=(const go_scope_exit&) = delete;
+
+    ~go_scope_exit() {
+        f();
+    }
+};
+
 template<class T> class Chan {
 private:
     std::queue<T> buffer;
@@ -121,12 +345,330 @@ public:
         return _cap;
     }
 
+    T& operator[](int i) {
+        if (i < 0 || i >= _len) {
+            std::cerr << "panic: runtime error: index out of range [" << i << "] with length " << _len << std::endl;
+            std::abort();
+        }
+
+        return _p[i];
+    }
+
     Slice operator()(int first) {
-        return Slice(_p+first, _len - first);
+        return Slice(_p+first, _len - first, _cap - first);
     }
 
     Slice operator()(int first, int last) {
-        return Slice(_p + first, last-first);
+        return Slice(_p + first, last-first, _cap - first);
+    }
+
+    Slice operator()(int first, int last, int max) {
+        return Slice(_p + first, last-first, max - first);
+    }
+
+    void clear() {
+        for (int i = 0; i < _len; i++) {
+            _p[i] = T();
+        }
     }
 };
+
+//
+// min, max and clear builtins (Go 1.21): string literals are compared as strings
+// and mixed arguments (untyped constants) are converted to their common type
+//
+template<typename T> struct go_builtin_type { typedef T type; };
+template<> struct go_builtin_type<const char*> { typedef std::string type; };
+
+template<typename... Ts> using go_common_type = typename std::common_type<typename go_builtin_type<Ts>::type...>::type;
+
+template<typename T, typename... Ts> go_common_type<T, Ts...> min(T first, Ts... rest) {
+    go_common_type<T, Ts...> m = first;
+    ((m = go_common_type<T, Ts...>(rest) < m ? go_common_type<T, Ts...>(rest) : m), ...);
+    return m;
+}
+
+template<typename T, typename... Ts> go_common_type<T, Ts...> max(T first, Ts... rest) {
+    go_common_type<T, Ts...> m = first;
+    ((m = m < go_common_type<T, Ts...>(rest) ? go_common_type<T, Ts...>(rest) : m), ...);
+    return m;
+}
+
+//
+// complex builtin (real and imag are std::real and std::imag, found by argument dependent lookup).
+// The imaginary literals are complex128(0, x) and the untyped constants mixed with complex values
+// (1 + 2i, c * 2) are converted to the type of the complex values. complex of two untyped integer
+// constants (complex(1, 2)) is a complex128, like in Go.
+//
+inline complex128 complex(double r, double i) {
+    return complex128(r, i);
+}
+
+inline complex64 complex(float r, float i) {
+    return complex64(r, i);
+}
+
+template<typename R, typename I, typename = std::enable_if_t<std::is_integral_v<R> && std::is_integral_v<I>>>
+complex128 complex(R r, I i) {
+    return complex128(r, i);
+}
+
+template<typename T, typename U, typename = std::enable_if_t<std::is_integral_v<U>>>
+std::complex<T> operator+(U a, const std::complex<T>& b) { return T(a) + b; }
+template<typename T, typename U, typename = std::enable_if_t<std::is_integral_v<U>>>
+std::complex<T> operator+(const std::complex<T>& a, U b) { return a + T(b); }
+template<typename T, typename U, typename = std::enable_if_t<std::is_integral_v<U>>>
+std::complex<T> operator-(U a, const std::complex<T>& b) { return T(a) - b; }
+template<typename T, typename U, typename = std::enable_if_t<std::is_integral_v<U>>>
+std::complex<T> operator-(const std::complex<T>& a, U b) { return a - T(b); }
+template<typename T, typename U, typename = std::enable_if_t<std::is_integral_v<U>>>
+std::complex<T> operator*(U a, const std::complex<T>& b) { return T(a) * b; }
+template<typename T, typename U, typename = std::enable_if_t<std::is_integral_v<U>>>
+std::complex<T> operator*(const std::complex<T>& a, U b) { return a * T(b); }
+template<typename T, typename U, typename = std::enable_if_t<std::is_integral_v<U>>>
+std::complex<T> operator/(U a, const std::complex<T>& b) { return T(a) / b; }
+template<typename T, typename U, typename = std::enable_if_t<std::is_integral_v<U>>>
+std::complex<T> operator/(const std::complex<T>& a, U b) { return a / T(b); }
+
+//
+// go_shl and go_shr implement the shifts by a count that isn't an integer literal (x << n, x >> n):
+// shifting by the size of the type or more gives 0 in Go (or -1 shifting right a negative value) but it's
+// undefined in C++, the bits shifted out of a signed value wrap around and a negative count panics.
+//
+template<typename N> constexpr void go_check_shift(N n) {
+    if constexpr (std::is_signed_v<N>) {
+        if (n < 0) {
+            std::cerr << "panic: runtime error: negative shift amount" << std::endl;
+            std::abort();
+        }
+    }
+}
+
+template<typename T, typename N> constexpr T go_shl(T x, N n) {
+    if constexpr (std::is_integral_v<T>) {
+        go_check_shift(n);
+        if (static_cast<unsigned long long>(n) >= sizeof(T) * 8) {
+            return 0;
+        }
+
+        return T(std::make_unsigned_t<T>(x) << n);
+    } else {
+        return x << n;
+    }
+}
+
+template<typename T, typename N> constexpr T go_shr(T x, N n) {
+    if constexpr (std::is_integral_v<T>) {
+        go_check_shift(n);
+        if (static_cast<unsigned long long>(n) >= sizeof(T) * 8) {
+            if constexpr (std::is_signed_v<T>) {
+                return x < 0 ? -1 : 0;
+            }
+
+            return 0;
+        }
+    }
+
+    return x >> n;
+}
+
+//
+// go_add_assign, go_sub_assign and go_mul_assign implement the arithmetic assignments (x += y, x -= y, x *= y): the overflow
+// wraps around in Go but it's undefined in C++ for the signed types (and for the unsigned types smaller than int, promoted
+// to int), so the integers are computed as unsigned, at least as large as unsigned int, and converted back. The other types
+// (strings, floats) use the assignment operator. x is a reference, so that it's evaluated once (xs[next()] += 5)
+//
+template<typename T> using go_wrap_u = std::common_type_t<std::make_unsigned_t<T>, unsigned>;
+template<typename T> constexpr bool go_wraps = std::is_integral_v<T> && !std::is_same_v<T, bool>;
+
+template<typename T, typename U> T& go_add_assign(T& x, U y) {
+    if constexpr (go_wraps<T>) {
+        return x = T(go_wrap_u<T>(x) + go_wrap_u<T>(T(y)));
+    } else {
+        return x += y;
+    }
+}
+
+template<typename T, typename U> T& go_sub_assign(T& x, U y) {
+    if constexpr (go_wraps<T>) {
+        return x = T(go_wrap_u<T>(x) - go_wrap_u<T>(T(y)));
+    } else {
+        return x -= y;
+    }
+}
+
+template<typename T, typename U> T& go_mul_assign(T& x, U y) {
+    if constexpr (go_wraps<T>) {
+        return x = T(go_wrap_u<T>(x) * go_wrap_u<T>(T(y)));
+    } else {
+        return x *= y;
+    }
+}
+
+//
+// go_shl_assign and go_shr_assign are the shift assignments by a count that isn't an integer literal (x <<= n), see go_shl
+//
+template<typename T, typename N> T& go_shl_assign(T& x, N n) { return x = go_shl(x, n); }
+template<typename T, typename N> T& go_shr_assign(T& x, N n) { return x = go_shr(x, n); }
+
+//
+// go_flags marks the enums converted from the types used as sets of flags (type Perm uint8 with const ( Read Perm = 1 << iota; ... )):
+// their bitwise operators keep the type like in Go (Read | Write is a Perm, not an int), also with the untyped constants (p & 1).
+// The converter converts the untyped constants assigned to the variables (var p Perm = 2 is Perm p = Perm(2), p |= 4 is p |= Perm(4))
+//
+template<typename E> struct go_flags : std::false_type {};
+template<typename E> using go_flags_t = std::enable_if_t<go_flags<E>::value, E>;
+template<typename E> using go_flags_u = std::underlying_type_t<E>;
+template<typename I> using go_flags_i = std::enable_if_t<std::is_integral_v<I> && !std::is_same_v<I, bool>>;
+
+template<typename E> constexpr go_flags_t<E> operator|(E a, E b) { return E(go_flags_u<E>(a) | go_flags_u<E>(b)); }
+template<typename E> constexpr go_flags_t<E> operator&(E a, E b) { return E(go_flags_u<E>(a) & go_flags_u<E>(b)); }
+template<typename E> constexpr go_flags_t<E> operator^(E a, E b) { return E(go_flags_u<E>(a) ^ go_flags_u<E>(b)); }
+template<typename E> constexpr go_flags_t<E> operator~(E a) { return E(~go_flags_u<E>(a)); }
+template<typename E, typename I, typename = go_flags_i<I>> constexpr go_flags_t<E> operator|(E a, I b) { return a | E(b); }
+template<typename E, typename I, typename = go_flags_i<I>> constexpr go_flags_t<E> operator&(E a, I b) { return a & E(b); }
+template<typename E, typename I, typename = go_flags_i<I>> constexpr go_flags_t<E> operator^(E a, I b) { return a ^ E(b); }
+template<typename E> go_flags_t<E>& operator|=(E& a, E b) { return a = a | b; }
+template<typename E> go_flags_t<E>& operator&=(E& a, E b) { return a = a & b; }
+template<typename E> go_flags_t<E>& operator^=(E& a, E b) { return a = a ^ b; }
+
+template<typename E, typename = go_flags_t<E>> std::ostream& operator<<(std::ostream& out, E e) {
+    return out << +go_flags_u<E>(e); // the numeric value, also for uint8
+}
+
+template<class K, class V> void clear(std::map<K, V> &m) {
+    m.clear();
+}
+
+template<class T> void clear(Slice<T> s) {
+    s.clear();
+}
+
+//
+// arrays are std::array, copied on assignment and when passed as arguments like in Go.
+// go_slice implements x[low:high:max]: the slices of an array share its elements (the array must outlive them),
+// -1 is the length (or capacity) of x
+//
+template<class T, size_t N> Slice<T> go_slice(std::array<T, N> &a, int low = 0, int high = -1, int max = -1) {
+    return Slice<T>(a.data(), N)(low, high < 0 ? int(N) : high, max < 0 ? int(N) : max);
+}
+
+template<class T> Slice<T> go_slice(Slice<T> s, int low = 0, int high = -1, int max = -1) {
+    return s(low, high < 0 ? s.len() : high, max < 0 ? s.cap() : max);
+}
+
+inline std::string go_slice(const std::string &s, int low = 0, int high = -1) {
+    return s.substr(low, (high < 0 ? s.size() : high) - low);
+}
+
+template<class T, size_t N> int len(const std::array<T, N> &a) {
+    return N;
+}
+
+template<class T, size_t N> int cap(const std::array<T, N> &a) {
+    return N;
+}
+
+template<class T> int len(Slice<T> s) {
+    return s.len();
+}
+
+template<class T> int cap(Slice<T> s) {
+    return s.cap();
+}
+
+inline int len(const std::string &s) {
+    return s.size();
+}
+
+template<class K, class V> int len(const std::map<K, V> &m) {
+    return m.size();
+}
+
+template<class T, size_t N> std::ostream& operator<<(std::ostream& out, const std::array<T, N> &a) {
+    out << "[";
+    for (size_t i = 0; i < N; i++) {
+        out << (i > 0 ? " " : "") << a[i];
+    }
+    return out << "]";
+}
+
+template<class T> std::ostream& operator<<(std::ostream& out, Slice<T> s) {
+    out << "[";
+    for (int i = 0; i < s.len(); i++) {
+        out << (i > 0 ? " " : "") << s[i];
+    }
+    return out << "]";
+}
+
+//
+// go_type_name returns the Go name of a type, for %T and reflect.TypeOf: the converter declares
+// go_type_name(const T*) after the struct types (and the named types with methods) of the files using them,
+// found by argument dependent lookup. The other types are printed as their (demangled) C++ name.
+//
+inline std::string go_type_name(const bool*) { return "bool"; }
+inline std::string go_type_name(const go_empty*) { return "struct {}"; }
+inline std::string go_type_name(const char*) { return "int32"; }
+inline std::string go_type_name(const int8*) { return "int8"; }
+inline std::string go_type_name(const int16*) { return "int16"; }
+inline std::string go_type_name(const int*) { return "int"; }
+inline std::string go_type_name(const int64*) { return "int64"; }
+inline std::string go_type_name(const uint8*) { return "uint8"; }
+inline std::string go_type_name(const uint16*) { return "uint16"; }
+inline std::string go_type_name(const uint32*) { return "uint32"; }
+inline std::string go_type_name(const uint64*) { return "uint64"; }
+inline std::string go_type_name(const float32*) { return "float32"; }
+inline std::string go_type_name(const float64*) { return "float64"; }
+inline std::string go_type_name(const complex64*) { return "complex64"; }
+inline std::string go_type_name(const complex128*) { return "complex128"; }
+inline std::string go_type_name(const std::string*) { return "string"; }
+inline std::string go_type_name(const char* const*) { return "string"; }
+inline std::string go_type_name(const error*) { return "error"; }
+
+template<typename T> std::string go_type_name(const T*);
+template<typename T> std::string go_type_name(T* const*);
+template<typename T> std::string go_type_name(const Slice<T>*);
+template<typename T, size_t N> std::string go_type_name(const std::array<T, N>*);
+template<typename K, typename V> std::string go_type_name(const std::map<K, V>*);
+
+template<size_t N> std::string go_type_name(const char (*)[N]) {
+    return "string"; // string literal
+}
+
+template<typename T> std::string go_type_name(const T*) {
+    const char* name = typeid(T).name();
+#if defined(__GNUG__)
+    int status = 0;
+    if (char* demangled = abi::__cxa_demangle(name, nullptr, nullptr, &status)) {
+        std::string s(demangled);
+        std::free(demangled);
+        return s;
+    }
+#endif
+    return name;
+}
+
+template<typename T> std::string go_type_name(T* const*) {
+    return "*" + go_type_name(static_cast<const T*>(nullptr));
+}
+
+template<typename T> std::string go_type_name(const Slice<T>*) {
+    return "[]" + go_type_name(static_cast<const T*>(nullptr));
+}
+
+template<typename T, size_t N> std::string go_type_name(const std::array<T, N>*) {
+    return "[" + std::to_string(N) + "]" + go_type_name(static_cast<const T*>(nullptr));
+}
+
+template<typename K, typename V> std::string go_type_name(const std::map<K, V>*) {
+    return "map[" + go_type_name(static_cast<const K*>(nullptr)) + "]" + go_type_name(static_cast<const V*>(nullptr));
+}
+
+template<typename T> std::string go_type_name_of() {
+    return go_type_name(static_cast<const T*>(nullptr));
+}
+
+template<typename T> std::string go_type_of(const T&) {
+    return go_type_name_of<T>();
+}
 #endif
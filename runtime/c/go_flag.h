@@ -0,0 +1,453 @@
+#ifndef _GO_RUNTIME_FLAG_H
+#define _GO_RUNTIME_FLAG_H 1
+
+//
+// flag implemented as a small command line parser, following the Go syntax
+// (-flag, --flag, -flag=value, -flag value for non-boolean flags, -- to stop parsing).
+//
+// The converted main calls flag::Init(argc, argv) so that flag::Parse() can access the command line.
+//
+
+#include <cstdlib>
+#include <functional>
+#include <iostream>
+#include <map>
+#include <memory>
+#include <sstream>
+#include <string>
+#include <vector>
+#include <go_time.h>
+
+namespace flag {
+
+//
+// parseDuration parses a Go duration string (i.e. "1.5s", "300ms", "1h20m")
+//
+inline bool parseDuration(std::string s, go_time::Duration& d) {
+    static const std::map<std::string, double> units = {
+        {"ns", 1e-9}, {"us", 1e-6}, {"µs", 1e-6}, {"ms", 1e-3}, {"s", 1}, {"m", 60}, {"h", 3600},
+    };
+
+    double sign = 1, total = 0;
+    if (!s.empty() && (s[0] == '-' || s[0] == '+')) {
+        sign = s[0] == '-' ? -1 : 1;
+        s = s.substr(1);
+    }
+
+    if (s == "0") {
+        d = go_time::Duration(0);
+        return true;
+    }
+
+    if (s.empty()) {
+        return false;
+    }
+
+    while (!s.empty()) {
+        size_t n = 0;
+        double v;
+
+        try {
+            v = std::stod(s, &n);
+        } catch (...) {
+            return false;
+        }
+
+        s = s.substr(n);
+
+        size_t u = 0;
+        while (u < s.size() && !isdigit(s[u]) && s[u] != '.') {
+            u++;
+        }
+
+        auto it = units.find(s.substr(0, u));
+        if (it == units.end()) {
+            return false;
+        }
+
+        total += v * it->second;
+        s = s.substr(u);
+    }
+
+    d = go_time::Duration(sign * total);
+    return true;
+}
+
+struct Flag {
+    std::string Name;
+    std::string Usage;
+    std::string DefValue;
+    bool isBool;
+    bool isString;
+    std::function<bool(std::string)> set;
+};
+
+class FlagSet {
+private:
+    std::string name;
+    std::map<std::string, Flag> flags;
+    std::vector<std::shared_ptr<void>> values;
+    std::vector<std::string> args;
+    bool parsed = false;
+    int nflag = 0;
+
+    template<typename T> T* alloc(T value) {
+        auto p = std::make_shared<T>(value);
+        values.push_back(p);
+        return p.get();
+    }
+
+    void define(Flag f) {
+        if (flags.count(f.Name)) {
+            std::cerr << name << " flag redefined: " << f.Name << std::endl;
+            std::exit(2);
+        }
+
+        flags[f.Name] = f;
+    }
+
+    void failf(std::string message) {
+        std::cerr << message << std::endl;
+        Usage();
+        std::exit(2);
+    }
+
+public:
+    std::function<void()> Usage;
+
+    FlagSet(std::string name = "") : name(name) {
+        Usage = [this]() {
+            std::cerr << "Usage of " << this->name << ":" << std::endl;
+            PrintDefaults();
+        };
+    }
+
+    std::string Name() const {
+        return name;
+    }
+
+    void SetName(std::string name) {
+        this->name = name;
+    }
+
+    void BoolVar(bool* p, std::string name, bool value, std::string usage) {
+        *p = value;
+        define(Flag{name, usage, value ? "true" : "false", true, false, [p](std::string s) {
+            if (s == "1" || s == "t" || s == "T" || s == "true" || s == "TRUE" || s == "True") *p = true;
+            else if (s == "0" || s == "f" || s == "F" || s == "false" || s == "FALSE" || s == "False") *p = false;
+            else return false;
+            return true;
+        }});
+    }
+
+    void StringVar(std::string* p, std::string name, std::string value, std::string usage) {
+        *p = value;
+        define(Flag{name, usage, value, false, true, [p](std::string s) {
+            *p = s;
+            return true;
+        }});
+    }
+
+    template<typename T> void numberVar(T* p, std::string name, T value, std::string usage) {
+        *p = value;
+        std::ostringstream def;
+        def << value;
+
+        define(Flag{name, usage, def.str(), false, false, [p](std::string s) {
+            std::istringstream in(s);
+            T v;
+            if (!(in >> v) || !in.eof()) {
+                return false;
+            }
+            *p = v;
+            return true;
+        }});
+    }
+
+    void IntVar(int* p, std::string name, int value, std::string usage) {
+        numberVar(p, name, value, usage);
+    }
+
+    void Int64Var(int64* p, std::string name, int64 value, std::string usage) {
+        numberVar(p, name, value, usage);
+    }
+
+    void UintVar(unsigned int* p, std::string name, unsigned int value, std::string usage) {
+        numberVar(p, name, value, usage);
+    }
+
+    void Uint64Var(uint64* p, std::string name, uint64 value, std::string usage) {
+        numberVar(p, name, value, usage);
+    }
+
+    void Float64Var(float64* p, std::string name, float64 value, std::string usage) {
+        numberVar(p, name, value, usage);
+    }
+
+    void DurationVar(go_time::Duration* p, std::string name, go_time::Duration value, std::string usage) {
+        *p = value;
+        std::ostringstream def;
+        def << value.count() << "s";
+
+        define(Flag{name, usage, def.str(), false, false, [p](std::string s) {
+            return parseDuration(s, *p);
+        }});
+    }
+
+    // a constant duration (i.e. 0) is in nanoseconds
+    void DurationVar(go_time::Duration* p, std::string name, int64 value, std::string usage) {
+        DurationVar(p, name, go_time::Duration(value * 1e-9), usage);
+    }
+
+    bool* Bool(std::string name, bool value, std::string usage) {
+        auto p = alloc(value);
+        BoolVar(p, name, value, usage);
+        return p;
+    }
+
+    std::string* String(std::string name, std::string value, std::string usage) {
+        auto p = alloc(value);
+        StringVar(p, name, value, usage);
+        return p;
+    }
+
+    int* Int(std::string name, int value, std::string usage) {
+        auto p = alloc(value);
+        IntVar(p, name, value, usage);
+        return p;
+    }
+
+    int64* Int64(std::string name, int64 value, std::string usage) {
+        auto p = alloc(value);
+        Int64Var(p, name, value, usage);
+        return p;
+    }
+
+    unsigned int* Uint(std::string name, unsigned int value, std::string usage) {
+        auto p = alloc(value);
+        UintVar(p, name, value, usage);
+        return p;
+    }
+
+    uint64* Uint64(std::string name, uint64 value, std::string usage) {
+        auto p = alloc(value);
+        Uint64Var(p, name, value, usage);
+        return p;
+    }
+
+    float64* Float64(std::string name, float64 value, std::string usage) {
+        auto p = alloc(value);
+        Float64Var(p, name, value, usage);
+        return p;
+    }
+
+    go_time::Duration* Duration(std::string name, go_time::Duration value, std::string usage) {
+        auto p = alloc(value);
+        DurationVar(p, name, value, usage);
+        return p;
+    }
+
+    go_time::Duration* Duration(std::string name, int64 value, std::string usage) {
+        return Duration(name, go_time::Duration(value * 1e-9), usage);
+    }
+
+    error Set(std::string name, std::string value) {
+        auto it = flags.find(name);
+        if (it == flags.end()) {
+            return error("no such flag -" + name);
+        }
+
+        if (!it->second.set(value)) {
+            return error("invalid value \"" + value + "\" for flag -" + name);
+        }
+
+        return nullptr;
+    }
+
+    const Flag* Lookup(std::string name) const {
+        auto it = flags.find(name);
+        return it == flags.end() ? nullptr : &it->second;
+    }
+
+    void PrintDefaults() const {
+        for (auto& f : flags) {
+            std::cerr << "  -" << f.first;
+            if (!f.second.isBool) {
+                std::cerr << " value";
+            }
+
+            std::cerr << std::endl << "    \t" << f.second.Usage;
+            auto def = f.second.DefValue;
+            if (def != "" && def != "false" && def != "0" && def != "0s") {
+                std::cerr << " (default " << (f.second.isString ? "\"" + def + "\"" : def) << ")";
+            }
+
+            std::cerr << std::endl;
+        }
+    }
+
+    //
+    // Parse parses the arguments (without the program name). On errors, or for -h and -help,
+    // it prints the usage and exits
+    //
+    error Parse(std::vector<std::string> arguments) {
+        parsed = true;
+        args.clear();
+
+        size_t i = 0;
+        for (; i < arguments.size(); i++) {
+            auto arg = arguments[i];
+
+            if (arg.size() < 2 || arg[0] != '-') {
+                break;
+            }
+
+            if (arg == "--") {
+                i++;
+                break;
+            }
+
+            auto name = arg.substr(arg[1] == '-' ? 2 : 1);
+            std::string value;
+            bool hasValue = false;
+
+            auto eq = name.find('=');
+            if (eq != std::string::npos) {
+                value = name.substr(eq + 1);
+                name = name.substr(0, eq);
+                hasValue = true;
+            }
+
+            auto it = flags.find(name);
+            if (it == flags.end()) {
+                if (name == "h" || name == "help") {
+                    Usage();
+                    std::exit(0);
+                }
+
+                failf("flag provided but not defined: -" + name);
+            }
+
+            if (it->second.isBool && !hasValue) {
+                value = "true";
+            } else if (!hasValue) {
+                if (i + 1 >= arguments.size()) {
+                    failf("flag needs an argument: -" + name);
+                }
+
+                value = arguments[++i];
+            }
+
+            if (!it->second.set(value)) {
+                failf("invalid value \"" + value + "\" for flag -" + name);
+            }
+
+            nflag++;
+        }
+
+        args.assign(arguments.begin() + i, arguments.end());
+        return nullptr;
+    }
+
+    bool Parsed() const {
+        return parsed;
+    }
+
+    std::vector<std::string> Args() const {
+        return args;
+    }
+
+    int NArg() const {
+        return args.size();
+    }
+
+    std::string Arg(int i) const {
+        return i >= 0 && i < (int)args.size() ? args[i] : "";
+    }
+
+    int NFlag() const {
+        return nflag;
+    }
+};
+
+inline FlagSet CommandLine;
+
+// flag.Usage, can be replaced to customize the usage message
+inline std::function<void()>& Usage = CommandLine.Usage;
+
+inline std::vector<std::string>& commandLineArgs() {
+    static std::vector<std::string> args;
+    return args;
+}
+
+//
+// Init records the command line, to be parsed by Parse
+//
+inline void Init(int argc, char** argv) {
+    CommandLine.SetName(argc > 0 ? argv[0] : "");
+    commandLineArgs().assign(argv + (argc > 0 ? 1 : 0), argv + argc);
+}
+
+inline FlagSet* NewFlagSet(std::string name, int errorHandling) {
+    return new FlagSet(name);
+}
+
+const int ContinueOnError = 0;
+const int ExitOnError = 1;
+const int PanicOnError = 2;
+
+inline void BoolVar(bool* p, std::string name, bool value, std::string usage) { CommandLine.BoolVar(p, name, value, usage); }
+inline void StringVar(std::string* p, std::string name, std::string value, std::string usage) { CommandLine.StringVar(p, name, value, usage); }
+inline void IntVar(int* p, std::string name, int value, std::string usage) { CommandLine.IntVar(p, name, value, usage); }
+inline void Int64Var(int64* p, std::string name, int64 value, std::string usage) { CommandLine.Int64Var(p, name, value, usage); }
+inline void UintVar(unsigned int* p, std::string name, unsigned int value, std::string usage) { CommandLine.UintVar(p, name, value, usage); }
+inline void Uint64Var(uint64* p, std::string name, uint64 value, std::string usage) { CommandLine.Uint64Var(p, name, value, usage); }
+inline void Float64Var(float64* p, std::string name, float64 value, std::string usage) { CommandLine.Float64Var(p, name, value, usage); }
+inline void DurationVar(go_time::Duration* p, std::string name, go_time::Duration value, std::string usage) { CommandLine.DurationVar(p, name, value, usage); }
+inline void DurationVar(go_time::Duration* p, std::string name, int64 value, std::string usage) { CommandLine.DurationVar(p, name, value, usage); }
+
+inline bool* Bool(std::string name, bool value, std::string usage) { return CommandLine.Bool(name, value, usage); }
+inline std::string* String(std::string name, std::string value, std::string usage) { return CommandLine.String(name, value, usage); }
+inline int* Int(std::string name, int value, std::string usage) { return CommandLine.Int(name, value, usage); }
+inline int64* Int64(std::string name, int64 value, std::string usage) { return CommandLine.Int64(name, value, usage); }
+inline unsigned int* Uint(std::string name, unsigned int value, std::string usage) { return CommandLine.Uint(name, value, usage); }
+inline uint64* Uint64(std::string name, uint64 value, std::string usage) { return CommandLine.Uint64(name, value, usage); }
+inline float64* Float64(std::string name, float64 value, std::string usage) { return CommandLine.Float64(name, value, usage); }
+inline go_time::Duration* Duration(std::string name, go_time::Duration value, std::string usage) { return CommandLine.Duration(name, value, usage); }
+inline go_time::Duration* Duration(std::string name, int64 value, std::string usage) { return CommandLine.Duration(name, value, usage); }
+
+inline void Parse() {
+    CommandLine.Parse(commandLineArgs());
+}
+
+inline bool Parsed() {
+    return CommandLine.Parsed();
+}
+
+inline error Set(std::string name, std::string value) {
+    return CommandLine.Set(name, value);
+}
+
+inline std::vector<std::string> Args() {
+    return CommandLine.Args();
+}
+
+inline int NArg() {
+    return CommandLine.NArg();
+}
+
+inline std::string Arg(int i) {
+    return CommandLine.Arg(i);
+}
+
+inline int NFlag() {
+    return CommandLine.NFlag();
+}
+
+inline void PrintDefaults() {
+    CommandLine.PrintDefaults();
+}
+
+}
+
+#endif
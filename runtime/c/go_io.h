@@ -0,0 +1,18 @@
+#ifndef _GO_RUNTIME_IO_H
+#define _GO_RUNTIME_IO_H 1
+
+#include <string>
+#include <tuple>
+
+namespace io {
+
+//
+// ReadAll reads all the content of a reader (any type with a ReadAll method, i.e. http::Body)
+//
+template<typename R> std::tuple<std::string, error> ReadAll(R& r) {
+    return r.ReadAll();
+}
+
+}
+
+#endif
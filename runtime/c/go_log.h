@@ -0,0 +1,150 @@
+#ifndef _GO_RUNTIME_LOG_H
+#define _GO_RUNTIME_LOG_H 1
+
+//
+// log implemented writing to std::cerr or, when WALKNGO_LOG_SPDLOG is defined, to spdlog
+// (https://github.com/gabime/spdlog, that adds its own timestamp).
+//
+// The namespace is go_log since "log" conflicts with the C/C++ log() function.
+//
+
+#include <chrono>
+#include <cstdlib>
+#include <ctime>
+#include <iomanip>
+#include <iostream>
+#include <sstream>
+#include <string>
+#include <fmt.h>
+
+#if defined(WALKNGO_LOG_SPDLOG)
+#include <spdlog/spdlog.h>
+#endif
+
+namespace go_log {
+
+const int Ldate = 1;
+const int Ltime = 2;
+const int Lmicroseconds = 4;
+const int Llongfile = 8;
+const int Lshortfile = 16;
+const int LUTC = 32;
+const int Lmsgprefix = 64;
+const int LstdFlags = Ldate | Ltime;
+
+inline std::string& prefix() {
+    static std::string p;
+    return p;
+}
+
+inline int& flags() {
+    static int f = LstdFlags;
+    return f;
+}
+
+inline void SetPrefix(std::string p) {
+    prefix() = p;
+}
+
+inline std::string Prefix() {
+    return prefix();
+}
+
+inline void SetFlags(int f) {
+    flags() = f;
+}
+
+inline int Flags() {
+    return flags();
+}
+
+//
+// header returns the prefix and timestamp for a log line, according to the flags
+//
+inline std::string header() {
+    std::ostringstream out;
+
+    if ((flags() & Lmsgprefix) == 0) {
+        out << prefix();
+    }
+
+    auto now = std::chrono::system_clock::now();
+    std::time_t t = std::chrono::system_clock::to_time_t(now);
+    std::tm tm = (flags() & LUTC) ? *std::gmtime(&t) : *std::localtime(&t);
+
+    if (flags() & Ldate) {
+        out << std::put_time(&tm, "%Y/%m/%d ");
+    }
+
+    if (flags() & (Ltime | Lmicroseconds)) {
+        out << std::put_time(&tm, "%H:%M:%S");
+
+        if (flags() & Lmicroseconds) {
+            auto us = std::chrono::duration_cast<std::chrono::microseconds>(now.time_since_epoch()).count() % 1000000;
+            out << "." << std::setw(6) << std::setfill('0') << us;
+        }
+
+        out << " ";
+    }
+
+    if (flags() & Lmsgprefix) {
+        out << prefix();
+    }
+
+    return out.str();
+}
+
+inline void Output(int calldepth, std::string message) {
+    if (!message.empty() && message.back() == '\n') {
+        message.pop_back();
+    }
+
+#if defined(WALKNGO_LOG_SPDLOG)
+    spdlog::info("{}{}", prefix(), message);
+#else
+    std::cerr << header() << message << std::endl;
+#endif
+}
+
+template<typename... T> void Print(T... args) {
+    Output(2, fmt::Sprint(args...));
+}
+
+template<typename... T> void Printf(std::string format, T... args) {
+    Output(2, fmt::Sprintf(format, args...));
+}
+
+template<typename... T> void Println(T... args) {
+    Output(2, fmt::Sprintln(args...));
+}
+
+template<typename... T> void Fatal(T... args) {
+    Output(2, fmt::Sprint(args...));
+    std::exit(1);
+}
+
+template<typename... T> void Fatalf(std::string format, T... args) {
+    Output(2, fmt::Sprintf(format, args...));
+    std::exit(1);
+}
+
+template<typename... T> void Fatalln(T... args) {
+    Output(2, fmt::Sprintln(args...));
+    std::exit(1);
+}
+
+template<typename... T> void Panic(T... args) {
+    auto s = fmt::Sprint(args...);
+    Output(2, s);
+    panic(s);
+}
+
+template<typename... T> void Panicf(std::string format, T... args) {
+    auto s = fmt::Sprintf(format, args...);
+    Output(2, s);
+    panic(s);
+}
+
+}
+
+#endif
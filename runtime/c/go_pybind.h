@@ -0,0 +1,52 @@
+#ifndef _GO_RUNTIME_GO_PYBIND_H
+#define _GO_RUNTIME_GO_PYBIND_H 1
+
+//
+// pybind11 (https://github.com/pybind/pybind11) support for the bindings generated with --pybind11.
+//
+// A Go error is converted to None (nil) or to the error message, so that (T, error) results
+// are returned to Python as (value, None) or (value, "message"). Slices, maps and functions
+// are converted by the pybind11 STL and functional casters.
+//
+
+#include <go.h>
+#include <pybind11/pybind11.h>
+#include <pybind11/functional.h>
+#include <pybind11/stl.h>
+
+namespace py = pybind11;
+
+namespace pybind11 {
+namespace detail {
+
+template <> struct type_caster<error> {
+public:
+    PYBIND11_TYPE_CASTER(error, const_name("error"));
+
+    bool load(handle src, bool) {
+        if (src.is_none()) {
+            value = error(nullptr);
+            return true;
+        }
+
+        if (!isinstance<str>(src)) {
+            return false;
+        }
+
+        value = error(src.cast<std::string>());
+        return true;
+    }
+
+    static handle cast(error src, return_value_policy, handle) {
+        if (src == nullptr) {
+            return none().release();
+        }
+
+        return str(src.Error()).release();
+    }
+};
+
+}
+}
+
+#endif
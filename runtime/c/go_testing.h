@@ -0,0 +1,232 @@
+#ifndef _GO_RUNTIME_TESTING_H
+#define _GO_RUNTIME_TESTING_H 1
+
+//
+// testing implemented with GoogleTest or, when WALKNGO_TESTING_CATCH2 is defined, with Catch2.
+//
+// The converter maps func TestXxx(t *testing.T) to void TestXxx(go_testing::T& t), registered
+// with WALKNGO_TEST(pkg, TestXxx). Compile one file with WALKNGO_TEST_MAIN defined to get the test main
+// (walkngo writes walkngo_test_main.cc when converting tests).
+//
+
+#include <algorithm>
+#include <functional>
+#include <iostream>
+#include <sstream>
+#include <string>
+#include <vector>
+#include <fmt.h>
+
+#if defined(WALKNGO_TESTING_CATCH2)
+#include <catch2/catch_test_macros.hpp>
+
+#define WALKNGO_TEST(pkg, name) TEST_CASE(#pkg "." #name) { go_testing::run(#name, name); }
+#define WALKNGO_FAILURE(msg) FAIL_CHECK(msg)
+#define WALKNGO_SKIP(msg) SKIP(msg)
+#else
+#include <gtest/gtest.h>
+
+#define WALKNGO_TEST(pkg, name) TEST(pkg, name) { go_testing::run(#name, name); }
+#define WALKNGO_FAILURE(msg) ADD_FAILURE() << msg
+#define WALKNGO_SKIP(msg) GTEST_SKIP() << msg
+#endif
+
+namespace go_testing {
+
+struct failnow {}; // thrown to stop the test (FailNow, Fatal, Fatalf)
+
+struct skipnow {   // thrown to skip the test (SkipNow, Skip, Skipf)
+    std::string message;
+};
+
+class T {
+private:
+    std::string name;
+    bool failed = false;
+    bool skipped = false;
+
+    void fail(std::string message) {
+        failed = true;
+        WALKNGO_FAILURE(name + ": " + message);
+    }
+
+    void log(std::string message) {
+        std::cout << "    " << name << ": " << message << std::endl;
+    }
+
+public:
+    T(std::string name) : name(name) {
+    }
+
+    std::string Name() {
+        return name;
+    }
+
+    bool Failed() {
+        return failed;
+    }
+
+    bool Skipped() {
+        return skipped;
+    }
+
+    void Fail() {
+        fail("failed");
+    }
+
+    void FailNow() {
+        Fail();
+        throw failnow{};
+    }
+
+    void SkipNow() {
+        skipped = true;
+        throw skipnow{};
+    }
+
+    void Helper() {
+    }
+
+    void Parallel() {
+    }
+
+    template<typename... A> void Log(A... args) {
+        log(fmt::Sprint(args...));
+    }
+
+    template<typename... A> void Logf(std::string format, A... args) {
+        log(fmt::Sprintf(format, args...));
+    }
+
+    template<typename... A> void Error(A... args) {
+        fail(fmt::Sprint(args...));
+    }
+
+    template<typename... A> void Errorf(std::string format, A... args) {
+        fail(fmt::Sprintf(format, args...));
+    }
+
+    template<typename... A> void Fatal(A... args) {
+        Error(args...);
+        throw failnow{};
+    }
+
+    template<typename... A> void Fatalf(std::string format, A... args) {
+        Errorf(format, args...);
+        throw failnow{};
+    }
+
+    template<typename... A> void Skip(A... args) {
+        skipped = true;
+        throw skipnow{fmt::Sprint(args...)};
+    }
+
+    template<typename... A> void Skipf(std::string format, A... args) {
+        skipped = true;
+        throw skipnow{fmt::Sprintf(format, args...)};
+    }
+
+    bool Run(std::string subtest, std::function<void(T&)> test) {
+        T t(name + "/" + subtest);
+
+        try {
+            test(t);
+        } catch (failnow&) {
+        } catch (skipnow& s) {
+            t.log("skipped: " + s.message);
+        }
+
+        failed = failed || t.failed;
+        return !t.failed;
+    }
+};
+
+//
+// run runs a test function, stopping at FailNow/SkipNow
+//
+inline void run(std::string name, std::function<void(T&)> test) {
+    T t(name);
+
+    try {
+        test(t);
+    } catch (failnow&) {
+    } catch (skipnow& s) {
+        WALKNGO_SKIP(s.message);
+    }
+}
+
+//
+// example_lines returns the lines of an example output, without leading and trailing spaces
+// (and sorted, for an unordered output)
+//
+inline std::vector<std::string> example_lines(std::string output, bool unordered) {
+    std::vector<std::string> lines;
+    std::istringstream in(output);
+    const char *spaces = " \t\r";
+
+    for (std::string line; std::getline(in, line);) {
+        auto start = line.find_first_not_of(spaces);
+        lines.push_back(start == std::string::npos ? "" : line.substr(start, line.find_last_not_of(spaces) - start + 1));
+    }
+
+    while (!lines.empty() && lines.back().empty()) {
+        lines.pop_back();
+    }
+
+    while (!lines.empty() && lines.front().empty()) {
+        lines.erase(lines.begin());
+    }
+
+    if (unordered) {
+        std::sort(lines.begin(), lines.end());
+    }
+
+    return lines;
+}
+
+//
+// example runs a testable example (func ExampleXxx with an "// Output:" comment), printing its output,
+// and returns 1 if the output is not the expected one (see the examples folder written by walkngo)
+//
+inline int example(std::string name, std::function<void()> f, std::string want, bool unordered) {
+    std::ostringstream out;
+    auto old = std::cout.rdbuf(out.rdbuf());
+
+    try {
+        f();
+    } catch (...) {
+        std::cout.rdbuf(old);
+        throw;
+    }
+
+    std::cout.rdbuf(old);
+    std::cout << out.str();
+
+    if (example_lines(out.str(), unordered) != example_lines(want, unordered)) {
+        std::cerr << "--- FAIL: " << name << std::endl;
+        std::cerr << "got:" << std::endl << out.str() << std::endl;
+        std::cerr << "want:" << std::endl << want << std::endl;
+        return 1;
+    }
+
+    return 0;
+}
+
+}
+
+#if defined(WALKNGO_TEST_MAIN)
+#if defined(WALKNGO_TESTING_CATCH2)
+#include <catch2/catch_session.hpp>
+
+int main(int argc, char **argv) {
+    return Catch::Session().run(argc, argv);
+}
+#else
+int main(int argc, char **argv) {
+    ::testing::InitGoogleTest(&argc, argv);
+    return RUN_ALL_TESTS();
+}
+#endif
+#endif
+
+#endif
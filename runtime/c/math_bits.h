@@ -0,0 +1,158 @@
+#ifndef _GO_RUNTIME_MATH_BITS_H
+#define _GO_RUNTIME_MATH_BITS_H 1
+
+//
+// math/bits implemented with std::countl_zero, std::popcount, std::rotl... (C++20)
+// or with the GCC/Clang builtins.
+//
+// uint is unsigned int (32 bits), so UintSize is 32 and the functions without size work on 32 bits.
+// Add64, Sub64, Mul64 and Div64 use unsigned __int128.
+//
+
+#include <climits>
+#include <cstdint>
+#include <cstdlib>
+#include <iostream>
+#include <tuple>
+
+#if __cplusplus >= 202002L
+#include <bit>
+#endif
+
+namespace bits {
+
+const int UintSize = sizeof(unsigned int) * CHAR_BIT;
+
+template<typename T> int count_leading_zeros(T x) {
+#ifdef __cpp_lib_bitops
+    return std::countl_zero(x);
+#else
+    const int size = sizeof(T) * CHAR_BIT;
+    return x == 0 ? size : __builtin_clzll((unsigned long long)x) - (sizeof(unsigned long long) * CHAR_BIT - size);
+#endif
+}
+
+template<typename T> int count_trailing_zeros(T x) {
+#ifdef __cpp_lib_bitops
+    return std::countr_zero(x);
+#else
+    return x == 0 ? sizeof(T) * CHAR_BIT : __builtin_ctzll((unsigned long long)x);
+#endif
+}
+
+template<typename T> int count_ones(T x) {
+#ifdef __cpp_lib_bitops
+    return std::popcount(x);
+#else
+    return __builtin_popcountll((unsigned long long)x);
+#endif
+}
+
+// rotate_left rotates right for k < 0, like Go
+template<typename T> T rotate_left(T x, int k) {
+#ifdef __cpp_lib_bitops
+    return std::rotl(x, k);
+#else
+    const int size = sizeof(T) * CHAR_BIT;
+    unsigned s = (unsigned)k & (size - 1);
+    return s == 0 ? x : T(x << s | x >> (size - s));
+#endif
+}
+
+template<typename T> T reverse_bits(T x) {
+    T r = 0;
+    for (int i = 0; i < (int)(sizeof(T) * CHAR_BIT); i++) {
+        r = T(r << 1 | (x & 1));
+        x >>= 1;
+    }
+    return r;
+}
+
+template<typename T> T reverse_bytes(T x) {
+    T r = 0;
+    for (int i = 0; i < (int)sizeof(T); i++) {
+        r = T(r << 8 | (x & 0xff));
+        x >>= 8;
+    }
+    return r;
+}
+
+inline int LeadingZeros(unsigned int x) { return count_leading_zeros(x); }
+inline int LeadingZeros8(uint8_t x) { return count_leading_zeros(x); }
+inline int LeadingZeros16(uint16_t x) { return count_leading_zeros(x); }
+inline int LeadingZeros32(uint32_t x) { return count_leading_zeros(x); }
+inline int LeadingZeros64(uint64_t x) { return count_leading_zeros(x); }
+
+inline int TrailingZeros(unsigned int x) { return count_trailing_zeros(x); }
+inline int TrailingZeros8(uint8_t x) { return count_trailing_zeros(x); }
+inline int TrailingZeros16(uint16_t x) { return count_trailing_zeros(x); }
+inline int TrailingZeros32(uint32_t x) { return count_trailing_zeros(x); }
+inline int TrailingZeros64(uint64_t x) { return count_trailing_zeros(x); }
+
+inline int OnesCount(unsigned int x) { return count_ones(x); }
+inline int OnesCount8(uint8_t x) { return count_ones(x); }
+inline int OnesCount16(uint16_t x) { return count_ones(x); }
+inline int OnesCount32(uint32_t x) { return count_ones(x); }
+inline int OnesCount64(uint64_t x) { return count_ones(x); }
+
+inline unsigned int RotateLeft(unsigned int x, int k) { return rotate_left(x, k); }
+inline uint8_t RotateLeft8(uint8_t x, int k) { return rotate_left(x, k); }
+inline uint16_t RotateLeft16(uint16_t x, int k) { return rotate_left(x, k); }
+inline uint32_t RotateLeft32(uint32_t x, int k) { return rotate_left(x, k); }
+inline uint64_t RotateLeft64(uint64_t x, int k) { return rotate_left(x, k); }
+
+inline unsigned int Reverse(unsigned int x) { return reverse_bits(x); }
+inline uint8_t Reverse8(uint8_t x) { return reverse_bits(x); }
+inline uint16_t Reverse16(uint16_t x) { return reverse_bits(x); }
+inline uint32_t Reverse32(uint32_t x) { return reverse_bits(x); }
+inline uint64_t Reverse64(uint64_t x) { return reverse_bits(x); }
+
+inline unsigned int ReverseBytes(unsigned int x) { return reverse_bytes(x); }
+inline uint16_t ReverseBytes16(uint16_t x) { return reverse_bytes(x); }
+inline uint32_t ReverseBytes32(uint32_t x) { return reverse_bytes(x); }
+inline uint64_t ReverseBytes64(uint64_t x) { return reverse_bytes(x); }
+
+// Len is the number of bits needed to represent x (0 for 0)
+inline int Len(unsigned int x) { return UintSize - LeadingZeros(x); }
+inline int Len8(uint8_t x) { return 8 - LeadingZeros8(x); }
+inline int Len16(uint16_t x) { return 16 - LeadingZeros16(x); }
+inline int Len32(uint32_t x) { return 32 - LeadingZeros32(x); }
+inline int Len64(uint64_t x) { return 64 - LeadingZeros64(x); }
+
+// Add64 returns sum, carryOut
+inline std::tuple<uint64_t, uint64_t> Add64(uint64_t x, uint64_t y, uint64_t carry) {
+    unsigned __int128 sum = (unsigned __int128)x + y + carry;
+    return std::make_tuple(uint64_t(sum), uint64_t(sum >> 64));
+}
+
+// Sub64 returns diff, borrowOut
+inline std::tuple<uint64_t, uint64_t> Sub64(uint64_t x, uint64_t y, uint64_t borrow) {
+    uint64_t diff = x - y - borrow;
+    return std::make_tuple(diff, ((~x & y) | (~(x ^ y) & diff)) >> 63);
+}
+
+// Mul64 returns hi, lo
+inline std::tuple<uint64_t, uint64_t> Mul64(uint64_t x, uint64_t y) {
+    unsigned __int128 p = (unsigned __int128)x * y;
+    return std::make_tuple(uint64_t(p >> 64), uint64_t(p));
+}
+
+// Div64 returns quo, rem of (hi, lo) / y and panics if y == 0 or y <= hi (overflow)
+inline std::tuple<uint64_t, uint64_t> Div64(uint64_t hi, uint64_t lo, uint64_t y) {
+    if (y == 0) {
+        std::cerr << "panic: runtime error: integer divide by zero" << std::endl;
+        std::abort();
+    }
+
+    if (y <= hi) {
+        std::cerr << "panic: runtime error: integer overflow" << std::endl;
+        std::abort();
+    }
+
+    unsigned __int128 n = (unsigned __int128)hi << 64 | lo;
+    return std::make_tuple(uint64_t(n / y), uint64_t(n % y));
+}
+
+}
+
+#endif
@@ -0,0 +1,295 @@
+#ifndef _GO_RUNTIME_NET_HTTP_H
+#define _GO_RUNTIME_NET_HTTP_H 1
+
+//
+// net/http client (Get, Post, NewRequest, Client.Do) implemented with libcurl,
+// or with cpr (https://github.com/libcpr/cpr) when WALKNGO_HTTP_CPR is defined.
+//
+// Request and response bodies are strings, Response.Body can be read with io.ReadAll (go_io.h).
+//
+
+#include <map>
+#include <string>
+#include <tuple>
+#include <vector>
+#include <go_time.h>
+
+#if defined(WALKNGO_HTTP_CPR)
+#include <cpr/cpr.h>
+#else
+#include <curl/curl.h>
+#endif
+
+namespace http {
+
+const std::string MethodGet = "GET";
+const std::string MethodHead = "HEAD";
+const std::string MethodPost = "POST";
+const std::string MethodPut = "PUT";
+const std::string MethodPatch = "PATCH";
+const std::string MethodDelete = "DELETE";
+
+const int StatusOK = 200;
+const int StatusCreated = 201;
+const int StatusNoContent = 204;
+const int StatusMovedPermanently = 301;
+const int StatusFound = 302;
+const int StatusNotModified = 304;
+const int StatusBadRequest = 400;
+const int StatusUnauthorized = 401;
+const int StatusForbidden = 403;
+const int StatusNotFound = 404;
+const int StatusInternalServerError = 500;
+const int StatusServiceUnavailable = 503;
+
+inline std::string StatusText(int code) {
+    static const std::map<int, std::string> text = {
+        {200, "OK"}, {201, "Created"}, {204, "No Content"},
+        {301, "Moved Permanently"}, {302, "Found"}, {304, "Not Modified"},
+        {400, "Bad Request"}, {401, "Unauthorized"}, {403, "Forbidden"}, {404, "Not Found"},
+        {500, "Internal Server Error"}, {503, "Service Unavailable"},
+    };
+
+    auto it = text.find(code);
+    return it == text.end() ? "" : it->second;
+}
+
+class Header {
+private:
+    std::map<std::string, std::vector<std::string>> values;
+
+    static std::string canonical(std::string key) {
+        bool upper = true;
+        for (auto& c : key) {
+            c = upper ? toupper(c) : tolower(c);
+            upper = c == '-';
+        }
+        return key;
+    }
+
+public:
+    std::string Get(std::string key) const {
+        auto it = values.find(canonical(key));
+        return it == values.end() || it->second.empty() ? "" : it->second[0];
+    }
+
+    void Set(std::string key, std::string value) {
+        values[canonical(key)] = {value};
+    }
+
+    void Add(std::string key, std::string value) {
+        values[canonical(key)].push_back(value);
+    }
+
+    void Del(std::string key) {
+        values.erase(canonical(key));
+    }
+
+    const std::map<std::string, std::vector<std::string>>& all() const {
+        return values;
+    }
+};
+
+class Body {
+private:
+    std::string data;
+
+public:
+    Body() {
+    }
+
+    Body(std::string data) : data(data) {
+    }
+
+    std::tuple<std::string, error> ReadAll() {
+        return std::make_tuple(data, error(nullptr));
+    }
+
+    error Close() {
+        return nullptr;
+    }
+};
+
+struct Response {
+    std::string Status;
+    int StatusCode = 0;
+    http::Header Header;
+    http::Body Body;
+    int64 ContentLength = -1;
+};
+
+struct Request {
+    std::string Method;
+    std::string URL;
+    http::Header Header;
+    std::string body;
+};
+
+inline std::tuple<Request, error> NewRequest(std::string method, std::string url, std::nullptr_t) {
+    return std::make_tuple(Request{method, url}, error(nullptr));
+}
+
+inline std::tuple<Request, error> NewRequest(std::string method, std::string url, std::string body) {
+    return std::make_tuple(Request{method, url, http::Header(), body}, error(nullptr));
+}
+
+#if defined(WALKNGO_HTTP_CPR)
+
+inline std::tuple<Response, error> perform(const Request& req, go_time::Duration timeout) {
+    cpr::Session session;
+    session.SetUrl(cpr::Url{req.URL});
+
+    cpr::Header headers;
+    for (auto& h : req.Header.all()) {
+        for (auto& v : h.second) {
+            headers[h.first] = v;
+        }
+    }
+    session.SetHeader(headers);
+
+    if (!req.body.empty()) {
+        session.SetBody(cpr::Body{req.body});
+    }
+
+    if (timeout.count() > 0) {
+        session.SetTimeout(std::chrono::duration_cast<std::chrono::milliseconds>(timeout));
+    }
+
+    cpr::Response r;
+    if (req.Method == MethodGet) r = session.Get();
+    else if (req.Method == MethodPost) r = session.Post();
+    else if (req.Method == MethodPut) r = session.Put();
+    else if (req.Method == MethodPatch) r = session.Patch();
+    else if (req.Method == MethodDelete) r = session.Delete();
+    else if (req.Method == MethodHead) r = session.Head();
+    else return std::make_tuple(Response(), error("http: unsupported method " + req.Method));
+
+    if (r.error) {
+        return std::make_tuple(Response(), error(req.Method + " " + req.URL + ": " + r.error.message));
+    }
+
+    Response resp;
+    resp.StatusCode = r.status_code;
+    resp.Status = std::to_string(r.status_code) + " " + StatusText(r.status_code);
+    for (auto& h : r.header) {
+        resp.Header.Add(h.first, h.second);
+    }
+    resp.Body = http::Body(r.text);
+    resp.ContentLength = r.text.size();
+    return std::make_tuple(resp, error(nullptr));
+}
+
+#else
+
+inline size_t write_body(char* ptr, size_t size, size_t nmemb, void* userdata) {
+    static_cast<std::string*>(userdata)->append(ptr, size * nmemb);
+    return size * nmemb;
+}
+
+inline size_t write_header(char* ptr, size_t size, size_t nmemb, void* userdata) {
+    std::string line(ptr, size * nmemb);
+    auto colon = line.find(':');
+
+    if (colon != std::string::npos) {
+        auto value = line.substr(colon + 1);
+        value.erase(0, value.find_first_not_of(" \t"));
+        value.erase(value.find_last_not_of(" \t\r\n") + 1);
+        static_cast<http::Header*>(userdata)->Add(line.substr(0, colon), value);
+    }
+
+    return size * nmemb;
+}
+
+inline std::tuple<Response, error> perform(const Request& req, go_time::Duration timeout) {
+    CURL* curl = curl_easy_init();
+    if (!curl) {
+        return std::make_tuple(Response(), error("http: cannot initialize curl"));
+    }
+
+    Response resp;
+    std::string body;
+    struct curl_slist* headers = nullptr;
+
+    for (auto& h : req.Header.all()) {
+        for (auto& v : h.second) {
+            headers = curl_slist_append(headers, (h.first + ": " + v).c_str());
+        }
+    }
+
+    curl_easy_setopt(curl, CURLOPT_URL, req.URL.c_str());
+    curl_easy_setopt(curl, CURLOPT_CUSTOMREQUEST, req.Method.c_str());
+    curl_easy_setopt(curl, CURLOPT_FOLLOWLOCATION, 1L);
+    curl_easy_setopt(curl, CURLOPT_HTTPHEADER, headers);
+    curl_easy_setopt(curl, CURLOPT_WRITEFUNCTION, write_body);
+    curl_easy_setopt(curl, CURLOPT_WRITEDATA, &body);
+    curl_easy_setopt(curl, CURLOPT_HEADERFUNCTION, write_header);
+    curl_easy_setopt(curl, CURLOPT_HEADERDATA, &resp.Header);
+
+    if (req.Method == MethodHead) {
+        curl_easy_setopt(curl, CURLOPT_NOBODY, 1L);
+    }
+
+    if (!req.body.empty()) {
+        curl_easy_setopt(curl, CURLOPT_POSTFIELDS, req.body.data());
+        curl_easy_setopt(curl, CURLOPT_POSTFIELDSIZE, (long)req.body.size());
+    }
+
+    if (timeout.count() > 0) {
+        curl_easy_setopt(curl, CURLOPT_TIMEOUT_MS, (long)std::chrono::duration_cast<std::chrono::milliseconds>(timeout).count());
+    }
+
+    CURLcode res = curl_easy_perform(curl);
+
+    long code = 0;
+    curl_easy_getinfo(curl, CURLINFO_RESPONSE_CODE, &code);
+    curl_slist_free_all(headers);
+    curl_easy_cleanup(curl);
+
+    if (res != CURLE_OK) {
+        return std::make_tuple(Response(), error(req.Method + " " + req.URL + ": " + curl_easy_strerror(res)));
+    }
+
+    resp.StatusCode = code;
+    resp.Status = std::to_string(code) + " " + StatusText(code);
+    resp.Body = http::Body(body);
+    resp.ContentLength = body.size();
+    return std::make_tuple(resp, error(nullptr));
+}
+
+#endif
+
+struct Client {
+    go_time::Duration Timeout = go_time::Duration(0);
+
+    std::tuple<Response, error> Do(const Request& req) {
+        return perform(req, Timeout);
+    }
+
+    std::tuple<Response, error> Get(std::string url) {
+        return perform(Request{MethodGet, url}, Timeout);
+    }
+
+    std::tuple<Response, error> Post(std::string url, std::string contentType, std::string body) {
+        Request req{MethodPost, url, http::Header(), body};
+        req.Header.Set("Content-Type", contentType);
+        return perform(req, Timeout);
+    }
+};
+
+inline Client DefaultClient;
+
+inline std::tuple<Response, error> Get(std::string url) {
+    return DefaultClient.Get(url);
+}
+
+inline std::tuple<Response, error> Head(std::string url) {
+    return DefaultClient.Do(Request{MethodHead, url});
+}
+
+inline std::tuple<Response, error> Post(std::string url, std::string contentType, std::string body) {
+    return DefaultClient.Post(url, contentType, body);
+}
+
+}
+
+#endif
@@ -0,0 +1,52 @@
+#ifndef _GO_RUNTIME_REFLECT_H
+#define _GO_RUNTIME_REFLECT_H 1
+
+//
+// reflect.TypeOf returns a Type with the Go name of the type of its argument (see go_type_name in go.h):
+// String() is the full name (main.Point, []int) and Name() the name of the named types (Point, empty for []int)
+//
+
+#include <go.h>
+
+namespace reflect {
+
+class Type {
+private:
+    std::string name;
+
+public:
+    Type(std::string name) : name(name) {
+    }
+
+    std::string String() const {
+        return name;
+    }
+
+    std::string Name() const {
+        if (name.empty() || name[0] == '*' || name[0] == '[' || name.rfind("map[", 0) == 0 || name.rfind("func(", 0) == 0) {
+            return "";
+        }
+
+        return name.substr(name.rfind('.') + 1);
+    }
+
+    bool operator==(const Type& t) const {
+        return name == t.name;
+    }
+
+    bool operator!=(const Type& t) const {
+        return name != t.name;
+    }
+
+    friend std::ostream& operator<<(std::ostream& out, const Type& t) {
+        return out << t.name;
+    }
+};
+
+template<typename T> Type TypeOf(const T& x) {
+    return Type(go_type_of(x));
+}
+
+}
+
+#endif
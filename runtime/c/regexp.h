@@ -0,0 +1,454 @@
+#ifndef _GO_RUNTIME_REGEXP_H
+#define _GO_RUNTIME_REGEXP_H 1
+
+//
+// regexp implemented with std::regex (ECMAScript syntax) or, when WALKNGO_REGEXP_RE2 is defined,
+// with RE2 (https://github.com/google/re2), that implements the same syntax as Go (see regexp_re2.h).
+//
+// With std::regex the Go specific syntax is translated where possible: (?P<name>...) and (?<name>...) groups,
+// a leading (?i) flag, \A and \z. Other flags and Unicode classes (\pL) are not supported.
+//
+// A Regexp is a handle to the compiled expression (Go uses *Regexp), so it can be copied.
+//
+
+#include <functional>
+#include <memory>
+#include <string>
+#include <tuple>
+#include <vector>
+
+#if defined(WALKNGO_REGEXP_RE2)
+#include <re2/re2.h>
+#else
+#include <regex>
+#endif
+
+namespace regexp {
+
+class Regexp {
+private:
+    std::string expr;
+    std::vector<std::string> names; // names of the groups, names[0] is the whole match
+
+#if defined(WALKNGO_REGEXP_RE2)
+    std::shared_ptr<re2::RE2> re;
+#else
+    std::shared_ptr<std::regex> re;
+#endif
+
+    //
+    // match returns the positions (begin, end) of the groups of the first match at or after pos,
+    // with -1 for the groups that didn't participate in the match, or an empty vector
+    //
+    std::vector<int> match(const std::string& s, size_t pos) const {
+        std::vector<int> loc;
+
+#if defined(WALKNGO_REGEXP_RE2)
+        int n = re->NumberOfCapturingGroups() + 1;
+        std::vector<re2::StringPiece> groups(n);
+
+        if (!re->Match(s, pos, s.size(), re2::RE2::UNANCHORED, groups.data(), n)) {
+            return loc;
+        }
+
+        for (auto& g : groups) {
+            if (g.data() == nullptr) {
+                loc.push_back(-1);
+                loc.push_back(-1);
+            } else {
+                loc.push_back(g.data() - s.data());
+                loc.push_back(g.data() - s.data() + g.size());
+            }
+        }
+#else
+        std::smatch m;
+        auto flags = pos > 0 ? std::regex_constants::match_prev_avail : std::regex_constants::match_default;
+
+        if (!std::regex_search(s.begin() + pos, s.end(), m, *re, flags)) {
+            return loc;
+        }
+
+        for (size_t i = 0; i < m.size(); i++) {
+            if (!m[i].matched) {
+                loc.push_back(-1);
+                loc.push_back(-1);
+            } else {
+                loc.push_back(m[i].first - s.begin());
+                loc.push_back(m[i].second - s.begin());
+            }
+        }
+#endif
+
+        return loc;
+    }
+
+    //
+    // all returns the positions of the groups of the successive non-overlapping matches (at most n, if n >= 0)
+    //
+    std::vector<std::vector<int>> all(const std::string& s, int n) const {
+        std::vector<std::vector<int>> matches;
+        size_t pos = 0;
+        int prevEnd = -1;
+
+        while ((n < 0 || (int)matches.size() < n) && pos <= s.size()) {
+            auto loc = match(s, pos);
+            if (loc.empty()) {
+                break;
+            }
+
+            if (loc[1] == loc[0] && loc[0] == prevEnd) {
+                // empty match right after the previous one, skip it (as Go does)
+                pos = loc[0] + 1;
+                continue;
+            }
+
+            matches.push_back(loc);
+            prevEnd = loc[1];
+            pos = loc[1] > loc[0] ? loc[1] : loc[1] + 1;
+        }
+
+        return matches;
+    }
+
+    //
+    // expand returns the replacement template with $1, ${1}, $name and ${name} replaced by the groups of a match
+    //
+    std::string expand(const std::string& repl, const std::string& src, const std::vector<int>& loc) const {
+        std::string out;
+
+        for (size_t i = 0; i < repl.size(); i++) {
+            if (repl[i] != '$' || i + 1 >= repl.size()) {
+                out += repl[i];
+                continue;
+            }
+
+            if (repl[i + 1] == '$') {
+                out += '$';
+                i++;
+                continue;
+            }
+
+            std::string name;
+            size_t end = i + 1;
+
+            if (repl[end] == '{') {
+                auto close = repl.find('}', end);
+                if (close == std::string::npos) {
+                    out += repl[i];
+                    continue;
+                }
+
+                name = repl.substr(end + 1, close - end - 1);
+                end = close + 1;
+            } else {
+                while (end < repl.size() && (isalnum(repl[end]) || repl[end] == '_')) {
+                    end++;
+                }
+
+                name = repl.substr(i + 1, end - i - 1);
+            }
+
+            if (name.empty()) {
+                out += repl[i];
+                continue;
+            }
+
+            int group = SubexpIndex(name);
+            if (group < 0 && name.find_first_not_of("0123456789") == std::string::npos) {
+                group = std::stoi(name);
+            }
+
+            if (group >= 0 && group * 2 + 1 < (int)loc.size() && loc[group * 2] >= 0) {
+                out += src.substr(loc[group * 2], loc[group * 2 + 1] - loc[group * 2]);
+            }
+
+            i = end - 1;
+        }
+
+        return out;
+    }
+
+    std::string replace(const std::string& src, std::function<std::string(const std::vector<int>&)> repl) const {
+        std::string out;
+        size_t last = 0;
+
+        for (auto& loc : all(src, -1)) {
+            out += src.substr(last, loc[0] - last) + repl(loc);
+            last = loc[1];
+        }
+
+        return out + src.substr(last);
+    }
+
+#if !defined(WALKNGO_REGEXP_RE2)
+    //
+    // translate converts the Go syntax not supported by ECMAScript, recording the group names
+    //
+    static std::string translate(std::string expr, std::vector<std::string>& names, bool& icase) {
+        std::string out;
+
+        if (expr.compare(0, 4, "(?i)") == 0) {
+            icase = true;
+            expr = expr.substr(4);
+        }
+
+        names.push_back("");
+
+        for (size_t i = 0; i < expr.size(); i++) {
+            char c = expr[i];
+
+            if (c == '\\' && i + 1 < expr.size()) {
+                char n = expr[++i];
+                if (n == 'A') out += "^";
+                else if (n == 'z') out += "$";
+                else out += std::string("\\") + n;
+                continue;
+            }
+
+            if (c == '[') {
+                // copy the character class as it is
+                auto close = expr.find(']', i + (expr[i + 1] == ']' ? 2 : 1));
+                if (close == std::string::npos) close = expr.size() - 1;
+                out += expr.substr(i, close - i + 1);
+                i = close;
+                continue;
+            }
+
+            if (c == '(' && expr.compare(i, 2, "(?") != 0) {
+                names.push_back("");
+            } else if (c == '(' && (expr.compare(i, 4, "(?P<") == 0 || (expr.compare(i, 3, "(?<") == 0 && expr.compare(i, 4, "(?<=") != 0 && expr.compare(i, 4, "(?<!") != 0))) {
+                auto start = expr.find('<', i) + 1;
+                auto close = expr.find('>', start);
+                names.push_back(expr.substr(start, close - start));
+                out += "(";
+                i = close;
+                continue;
+            }
+
+            out += c;
+        }
+
+        return out;
+    }
+#endif
+
+public:
+    Regexp() {
+    }
+
+    //
+    // compile returns the compiled expression, or an error
+    //
+    static std::tuple<Regexp, error> compile(std::string expr) {
+        Regexp r;
+        r.expr = expr;
+
+#if defined(WALKNGO_REGEXP_RE2)
+        r.re = std::make_shared<re2::RE2>(expr, re2::RE2::Quiet);
+        if (!r.re->ok()) {
+            return std::make_tuple(Regexp(), error("error parsing regexp: " + r.re->error() + ": `" + expr + "`"));
+        }
+
+        r.names.resize(r.re->NumberOfCapturingGroups() + 1);
+        for (auto& n : r.re->CapturingGroupNames()) {
+            r.names[n.first] = n.second;
+        }
+#else
+        bool icase = false;
+        auto translated = translate(expr, r.names, icase);
+
+        try {
+            auto flags = std::regex::ECMAScript;
+            if (icase) flags |= std::regex::icase;
+            r.re = std::make_shared<std::regex>(translated, flags);
+        } catch (const std::regex_error& e) {
+            return std::make_tuple(Regexp(), error(std::string("error parsing regexp: ") + e.what() + ": `" + expr + "`"));
+        }
+#endif
+
+        return std::make_tuple(r, error(nullptr));
+    }
+
+    std::string String() const {
+        return expr;
+    }
+
+    int NumSubexp() const {
+        return names.size() - 1;
+    }
+
+    std::vector<std::string> SubexpNames() const {
+        return names;
+    }
+
+    int SubexpIndex(std::string name) const {
+        for (size_t i = 1; i < names.size(); i++) {
+            if (!name.empty() && names[i] == name) {
+                return i;
+            }
+        }
+
+        return -1;
+    }
+
+    bool MatchString(std::string s) const {
+        return !match(s, 0).empty();
+    }
+
+    bool Match(std::string b) const {
+        return MatchString(b);
+    }
+
+    std::string FindString(std::string s) const {
+        auto loc = match(s, 0);
+        return loc.empty() ? "" : s.substr(loc[0], loc[1] - loc[0]);
+    }
+
+    std::vector<int> FindStringIndex(std::string s) const {
+        auto loc = match(s, 0);
+        if (loc.size() > 2) {
+            loc.resize(2);
+        }
+
+        return loc;
+    }
+
+    std::vector<std::string> FindStringSubmatch(std::string s) const {
+        std::vector<std::string> groups;
+        auto loc = match(s, 0);
+
+        for (size_t i = 0; i < loc.size(); i += 2) {
+            groups.push_back(loc[i] < 0 ? "" : s.substr(loc[i], loc[i + 1] - loc[i]));
+        }
+
+        return groups;
+    }
+
+    std::vector<int> FindStringSubmatchIndex(std::string s) const {
+        return match(s, 0);
+    }
+
+    std::vector<std::string> FindAllString(std::string s, int n) const {
+        std::vector<std::string> found;
+
+        for (auto& loc : all(s, n)) {
+            found.push_back(s.substr(loc[0], loc[1] - loc[0]));
+        }
+
+        return found;
+    }
+
+    std::vector<std::vector<int>> FindAllStringIndex(std::string s, int n) const {
+        auto matches = all(s, n);
+        for (auto& loc : matches) {
+            loc.resize(2);
+        }
+
+        return matches;
+    }
+
+    std::vector<std::vector<std::string>> FindAllStringSubmatch(std::string s, int n) const {
+        std::vector<std::vector<std::string>> found;
+
+        for (auto& loc : all(s, n)) {
+            std::vector<std::string> groups;
+            for (size_t i = 0; i < loc.size(); i += 2) {
+                groups.push_back(loc[i] < 0 ? "" : s.substr(loc[i], loc[i + 1] - loc[i]));
+            }
+
+            found.push_back(groups);
+        }
+
+        return found;
+    }
+
+    std::string ReplaceAllString(std::string src, std::string repl) const {
+        return replace(src, [&](const std::vector<int>& loc) { return expand(repl, src, loc); });
+    }
+
+    std::string ReplaceAllLiteralString(std::string src, std::string repl) const {
+        return replace(src, [&](const std::vector<int>& loc) { return repl; });
+    }
+
+    std::string ReplaceAllStringFunc(std::string src, std::function<std::string(std::string)> repl) const {
+        return replace(src, [&](const std::vector<int>& loc) { return repl(src.substr(loc[0], loc[1] - loc[0])); });
+    }
+
+    std::string ReplaceAll(std::string src, std::string repl) const {
+        return ReplaceAllString(src, repl);
+    }
+
+    std::vector<std::string> Split(std::string s, int n) const {
+        std::vector<std::string> parts;
+        if (n == 0) {
+            return parts;
+        }
+
+        if (expr.empty() && s.empty()) {
+            parts.push_back("");
+            return parts;
+        }
+
+        // as in Go, an empty match at the beginning doesn't produce an empty string
+        size_t beg = 0, end = 0;
+        for (auto& loc : all(s, n)) {
+            if (n > 0 && (int)parts.size() == n - 1) {
+                break;
+            }
+
+            end = loc[0];
+            if (loc[1] != 0) {
+                parts.push_back(s.substr(beg, end - beg));
+            }
+
+            beg = loc[1];
+        }
+
+        if (end != s.size()) {
+            parts.push_back(s.substr(beg));
+        }
+
+        return parts;
+    }
+};
+
+inline std::tuple<Regexp, error> Compile(std::string expr) {
+    return Regexp::compile(expr);
+}
+
+inline Regexp MustCompile(std::string expr) {
+    auto [re, err] = Regexp::compile(expr);
+    if (err != nullptr) {
+        std::string message = "regexp: Compile(`" + expr + "`): " + err.Error();
+        panic(message);
+    }
+
+    return re;
+}
+
+inline std::tuple<bool, error> MatchString(std::string pattern, std::string s) {
+    auto [re, err] = Compile(pattern);
+    if (err != nullptr) {
+        return std::make_tuple(false, err);
+    }
+
+    return std::make_tuple(re.MatchString(s), error(nullptr));
+}
+
+inline std::string QuoteMeta(std::string s) {
+    std::string out;
+
+    for (char c : s) {
+        if (std::string("\\.+*?()|[]{}^$").find(c) != std::string::npos) {
+            out += '\\';
+        }
+
+        out += c;
+    }
+
+    return out;
+}
+
+}
+
+#endif
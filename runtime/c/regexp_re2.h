@@ -0,0 +1,11 @@
+#ifndef _GO_RUNTIME_REGEXP_RE2_H
+#define _GO_RUNTIME_REGEXP_RE2_H 1
+
+//
+// regexp implemented with RE2 (the include for regexp with --regexp=re2)
+//
+
+#define WALKNGO_REGEXP_RE2 1
+#include <regexp.h>
+
+#endif
@@ -0,0 +1,150 @@
+#ifndef _GO_RUNTIME_SLOG_H
+#define _GO_RUNTIME_SLOG_H 1
+
+//
+// log/slog (the default logger) implemented writing text records via go_log.h
+// (to std::cerr or, when WALKNGO_LOG_SPDLOG is defined, to spdlog at the corresponding level).
+//
+// Attributes can be passed as key, value pairs or as Attr (slog.String, slog.Int, ...).
+//
+
+#include <iomanip>
+#include <sstream>
+#include <string>
+#include <type_traits>
+#include <go_log.h>
+
+namespace slog {
+
+typedef int Level;
+
+const Level LevelDebug = -4;
+const Level LevelInfo = 0;
+const Level LevelWarn = 4;
+const Level LevelError = 8;
+
+struct Attr {
+    std::string Key;
+    std::string Value;
+};
+
+template<typename V> Attr Any(std::string key, V value) {
+    std::ostringstream out;
+    out << std::boolalpha << value;
+    return Attr{key, out.str()};
+}
+
+inline Attr String(std::string key, std::string value) {
+    return Attr{key, value};
+}
+
+template<typename V> Attr Int(std::string key, V value) {
+    return Any(key, value);
+}
+
+template<typename V> Attr Int64(std::string key, V value) {
+    return Any(key, value);
+}
+
+template<typename V> Attr Float64(std::string key, V value) {
+    return Any(key, value);
+}
+
+inline Attr Bool(std::string key, bool value) {
+    return Any(key, value);
+}
+
+inline Level& level() {
+    static Level l = LevelInfo;
+    return l;
+}
+
+//
+// SetLogLoggerLevel sets the minimum level of the records that are logged
+//
+inline Level SetLogLoggerLevel(Level l) {
+    Level old = level();
+    level() = l;
+    return old;
+}
+
+inline std::string levelName(Level l) {
+    if (l < LevelInfo) return "DEBUG";
+    if (l < LevelWarn) return "INFO";
+    if (l < LevelError) return "WARN";
+    return "ERROR";
+}
+
+//
+// value formats an attribute value, quoting it if needed
+//
+inline std::string value(std::string v) {
+    if (v.empty() || v.find_first_of(" =\"\t\n") != std::string::npos) {
+        std::ostringstream out;
+        out << std::quoted(v);
+        return out.str();
+    }
+
+    return v;
+}
+
+inline void attrs(std::ostream& out) {
+}
+
+template<typename K, typename V, typename... A> void attrs2(std::ostream& out, K key, V v, A... rest);
+
+//
+// attrs formats the arguments, as Attr or key, value pairs
+//
+template<typename K, typename... A> void attrs(std::ostream& out, K key, A... rest) {
+    if constexpr (std::is_same<K, Attr>::value) {
+        out << " " << key.Key << "=" << value(key.Value);
+        attrs(out, rest...);
+    } else if constexpr (sizeof...(rest) == 0) {
+        out << " !BADKEY=" << value(Any("", key).Value);
+    } else {
+        attrs2(out, key, rest...);
+    }
+}
+
+template<typename K, typename V, typename... A> void attrs2(std::ostream& out, K key, V v, A... rest) {
+    out << " " << key << "=" << value(Any("", v).Value);
+    attrs(out, rest...);
+}
+
+template<typename... A> void Log(Level l, std::string msg, A... args) {
+    if (l < level()) {
+        return;
+    }
+
+    std::ostringstream out;
+    out << msg;
+    attrs(out, args...);
+
+#if defined(WALKNGO_LOG_SPDLOG)
+    auto spdlevel = l < LevelInfo ? spdlog::level::debug : l < LevelWarn ? spdlog::level::info : l < LevelError ? spdlog::level::warn : spdlog::level::err;
+    spdlog::log(spdlevel, "{}", out.str());
+#else
+    go_log::Output(2, levelName(l) + " " + out.str());
+#endif
+}
+
+template<typename... A> void Debug(std::string msg, A... args) {
+    Log(LevelDebug, msg, args...);
+}
+
+template<typename... A> void Info(std::string msg, A... args) {
+    Log(LevelInfo, msg, args...);
+}
+
+template<typename... A> void Warn(std::string msg, A... args) {
+    Log(LevelWarn, msg, args...);
+}
+
+template<typename... A> void Error(std::string msg, A... args) {
+    Log(LevelError, msg, args...);
+}
+
+}
+
+#endif
@@ -3,6 +3,8 @@
 
 #include <mutex>
 #include <condition_variable>
+#include <functional>
+#include <thread>
 
 namespace sync {
 
@@ -12,6 +14,10 @@ namespace sync {
     class Mutex : private std::mutex {
         friend class Cond;
     public:
+        // lock and unlock for std::lock_guard
+        using std::mutex::lock;
+        using std::mutex::unlock;
+
         void Lock() {
             std::mutex::lock();
         }
@@ -49,6 +55,10 @@ namespace sync {
     //
     class RWMutex : private std::mutex {
     public:
+        // lock and unlock for std::lock_guard
+        using std::mutex::lock;
+        using std::mutex::unlock;
+
         void Lock() {
             std::mutex::lock();
         }
@@ -73,7 +83,7 @@ namespace sync {
     private:
         std::mutex m;
         std::condition_variable cv;
-        int waiters;
+        int waiters = 0;
     public:
         void Add(int delta) {
             std::unique_lock<std::mutex> lk(m);
@@ -93,6 +103,21 @@ namespace sync {
             std::unique_lock<std::mutex> lk(m);
             while (waiters > 0) cv.wait(lk);
         }
+
+        //
+        // Go runs fun in a new thread, counted by the group until it returns (or throws)
+        //
+        void Go(std::function<void()> fun) {
+            Add(1);
+            std::thread([this, fun]() {
+                struct done {
+                    WaitGroup *wg;
+                    ~done() { wg->Done(); }
+                } guard{this};
+
+                fun();
+            }).detach();
+        }
     };
 
     //
@@ -0,0 +1,175 @@
+#ifndef _GO_RUNTIME_SYNC_ATOMIC_H
+#define _GO_RUNTIME_SYNC_ATOMIC_H 1
+
+//
+// sync/atomic implemented with std::atomic.
+//
+// The variables passed to the atomic functions (atomic.AddInt64(&x, 1)) are declared by the converter
+// as std::atomic<T>. Go atomic operations are sequentially consistent, so all the operations use
+// std::memory_order_seq_cst.
+//
+
+#include <any>
+#include <atomic>
+#include <mutex>
+
+namespace atomic {
+
+const std::memory_order order = std::memory_order_seq_cst;
+
+// same<T>::type prevents the deduction of T from the value arguments (i.e. AddInt64(&x, 1))
+template<typename T> struct same {
+    typedef T type;
+};
+
+template<typename T> T add(std::atomic<T>* addr, typename same<T>::type delta) {
+    return addr->fetch_add(delta, order) + delta;
+}
+
+template<typename T> T load(std::atomic<T>* addr) {
+    return addr->load(order);
+}
+
+template<typename T> void store(std::atomic<T>* addr, typename same<T>::type val) {
+    addr->store(val, order);
+}
+
+template<typename T> T swap(std::atomic<T>* addr, typename same<T>::type val) {
+    return addr->exchange(val, order);
+}
+
+template<typename T> bool compareAndSwap(std::atomic<T>* addr, typename same<T>::type old, typename same<T>::type val) {
+    return addr->compare_exchange_strong(old, val, order);
+}
+
+// And and Or return the old value
+template<typename T> T and_(std::atomic<T>* addr, typename same<T>::type mask) {
+    return addr->fetch_and(mask, order);
+}
+
+template<typename T> T or_(std::atomic<T>* addr, typename same<T>::type mask) {
+    return addr->fetch_or(mask, order);
+}
+
+inline int32 AddInt32(std::atomic<int32>* addr, int32 delta) { return add(addr, delta); }
+inline int64 AddInt64(std::atomic<int64>* addr, int64 delta) { return add(addr, delta); }
+inline uint32 AddUint32(std::atomic<uint32>* addr, uint32 delta) { return add(addr, delta); }
+inline uint64 AddUint64(std::atomic<uint64>* addr, uint64 delta) { return add(addr, delta); }
+inline uintptr AddUintptr(std::atomic<uintptr>* addr, uintptr delta) { return add(addr, delta); }
+
+inline int32 LoadInt32(std::atomic<int32>* addr) { return load(addr); }
+inline int64 LoadInt64(std::atomic<int64>* addr) { return load(addr); }
+inline uint32 LoadUint32(std::atomic<uint32>* addr) { return load(addr); }
+inline uint64 LoadUint64(std::atomic<uint64>* addr) { return load(addr); }
+inline uintptr LoadUintptr(std::atomic<uintptr>* addr) { return load(addr); }
+template<typename T> T* LoadPointer(std::atomic<T*>* addr) { return load(addr); }
+
+inline void StoreInt32(std::atomic<int32>* addr, int32 val) { store(addr, val); }
+inline void StoreInt64(std::atomic<int64>* addr, int64 val) { store(addr, val); }
+inline void StoreUint32(std::atomic<uint32>* addr, uint32 val) { store(addr, val); }
+inline void StoreUint64(std::atomic<uint64>* addr, uint64 val) { store(addr, val); }
+inline void StoreUintptr(std::atomic<uintptr>* addr, uintptr val) { store(addr, val); }
+template<typename T> void StorePointer(std::atomic<T*>* addr, typename same<T*>::type val) { store(addr, val); }
+
+inline int32 SwapInt32(std::atomic<int32>* addr, int32 val) { return swap(addr, val); }
+inline int64 SwapInt64(std::atomic<int64>* addr, int64 val) { return swap(addr, val); }
+inline uint32 SwapUint32(std::atomic<uint32>* addr, uint32 val) { return swap(addr, val); }
+inline uint64 SwapUint64(std::atomic<uint64>* addr, uint64 val) { return swap(addr, val); }
+inline uintptr SwapUintptr(std::atomic<uintptr>* addr, uintptr val) { return swap(addr, val); }
+template<typename T> T* SwapPointer(std::atomic<T*>* addr, typename same<T*>::type val) { return swap(addr, val); }
+
+inline bool CompareAndSwapInt32(std::atomic<int32>* addr, int32 old, int32 val) { return compareAndSwap(addr, old, val); }
+inline bool CompareAndSwapInt64(std::atomic<int64>* addr, int64 old, int64 val) { return compareAndSwap(addr, old, val); }
+inline bool CompareAndSwapUint32(std::atomic<uint32>* addr, uint32 old, uint32 val) { return compareAndSwap(addr, old, val); }
+inline bool CompareAndSwapUint64(std::atomic<uint64>* addr, uint64 old, uint64 val) { return compareAndSwap(addr, old, val); }
+inline bool CompareAndSwapUintptr(std::atomic<uintptr>* addr, uintptr old, uintptr val) { return compareAndSwap(addr, old, val); }
+template<typename T> bool CompareAndSwapPointer(std::atomic<T*>* addr, typename same<T*>::type old, typename same<T*>::type val) { return compareAndSwap(addr, old, val); }
+
+inline int32 AndInt32(std::atomic<int32>* addr, int32 mask) { return and_(addr, mask); }
+inline int64 AndInt64(std::atomic<int64>* addr, int64 mask) { return and_(addr, mask); }
+inline uint32 AndUint32(std::atomic<uint32>* addr, uint32 mask) { return and_(addr, mask); }
+inline uint64 AndUint64(std::atomic<uint64>* addr, uint64 mask) { return and_(addr, mask); }
+inline int32 OrInt32(std::atomic<int32>* addr, int32 mask) { return or_(addr, mask); }
+inline int64 OrInt64(std::atomic<int64>* addr, int64 mask) { return or_(addr, mask); }
+inline uint32 OrUint32(std::atomic<uint32>* addr, uint32 mask) { return or_(addr, mask); }
+inline uint64 OrUint64(std::atomic<uint64>* addr, uint64 mask) { return or_(addr, mask); }
+
+//
+// typed atomic values (atomic.Int64, atomic.Bool, atomic.Pointer[T], ...)
+//
+template<typename T> class value {
+protected:
+    std::atomic<T> v{};
+
+public:
+    T Load() const {
+        return v.load(order);
+    }
+
+    void Store(T val) {
+        v.store(val, order);
+    }
+
+    T Swap(T val) {
+        return v.exchange(val, order);
+    }
+
+    bool CompareAndSwap(T old, T val) {
+        return v.compare_exchange_strong(old, val, order);
+    }
+};
+
+template<typename T> class integer : public value<T> {
+public:
+    T Add(T delta) {
+        return this->v.fetch_add(delta, order) + delta;
+    }
+
+    T And(T mask) {
+        return this->v.fetch_and(mask, order);
+    }
+
+    T Or(T mask) {
+        return this->v.fetch_or(mask, order);
+    }
+};
+
+typedef integer<int32> Int32;
+typedef integer<int64> Int64;
+typedef integer<uint32> Uint32;
+typedef integer<uint64> Uint64;
+typedef integer<uintptr> Uintptr;
+typedef value<bool> Bool;
+
+template<typename T> class Pointer : public value<T*> {
+};
+
+//
+// Value holds any value (std::any), protected by a mutex
+//
+class Value {
+private:
+    mutable std::mutex m;
+    std::any v;
+
+public:
+    std::any Load() const {
+        std::lock_guard<std::mutex> lock(m);
+        return v;
+    }
+
+    void Store(std::any val) {
+        std::lock_guard<std::mutex> lock(m);
+        v = val;
+    }
+
+    std::any Swap(std::any val) {
+        std::lock_guard<std::mutex> lock(m);
+        std::swap(v, val);
+        return val;
+    }
+};
+
+}
+
+#endif
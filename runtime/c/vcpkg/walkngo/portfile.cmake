@@ -0,0 +1,7 @@
+# overlay port for the walkngo runtime (header only), used by the vcpkg.json generated with --manifest=vcpkg
+#
+#     vcpkg install --overlay-ports=<walkngo>/runtime/c/vcpkg
+
+file(GLOB HEADERS "${CMAKE_CURRENT_LIST_DIR}/../../*.h")
+file(INSTALL ${HEADERS} DESTINATION "${CURRENT_PACKAGES_DIR}/include/walkngo")
+file(INSTALL "${CMAKE_CURRENT_LIST_DIR}/../../../../LICENSE" DESTINATION "${CURRENT_PACKAGES_DIR}/share/${PORT}" RENAME copyright)
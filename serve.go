@@ -0,0 +1,203 @@
+package main
+
+//
+// The "serve" command: a web page and a JSON API to convert Go code, for demos and to debug the printers
+//
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/raff/walkngo/walker"
+)
+
+// default address of the server
+const SERVE_ADDR = "localhost:8080"
+
+// maximum size of a request
+const MAX_SOURCE_SIZE = 1 << 20
+
+//
+// ConvertRequest is the body of POST /convert: the Go source (a file, or a snippet of statements
+// or declarations as for the expr command) and the target language (c, go, rust or swift, default --lang)
+//
+type ConvertRequest struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+//
+// ConvertResponse is the result of POST /convert: the converted code and the problems found
+//
+type ConvertResponse struct {
+	Output      string               `json:"output"`
+	Diagnostics []walkngo.Diagnostic `json:"diagnostics"`
+}
+
+//
+// converter converts the source of a request (newWalker returns a walker, configured as for the command line,
+// for a target language)
+//
+type converter struct {
+	newWalker func(target string) (*walkngo.GoWalker, error)
+	target    string // default target
+}
+
+//
+// serveCommand implements "walkngo [flags] serve [address]": it serves a page to convert the Go code pasted in it
+// and the JSON API used by the page (POST /convert {"source": "...", "target": "c"} -> {"output": "...", "diagnostics": [...]})
+//
+func serveCommand(newWalker func(target string) (*walkngo.GoWalker, error), target string, args []string) error {
+	addr := SERVE_ADDR
+
+	switch len(args) {
+	case 0:
+	case 1:
+		addr = args[0]
+	default:
+		return fmt.Errorf("usage: walkngo [flags] serve [address]")
+	}
+
+	c := &converter{newWalker: newWalker, target: target}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", c.page)
+	mux.HandleFunc("/convert", c.convert)
+
+	fmt.Fprintf(os.Stderr, "serving on http://%s\n", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+func (c *converter) page(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, strings.ReplaceAll(servePage, "{{TARGET}}", c.target))
+}
+
+func (c *converter) convert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ConvertRequest
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MAX_SOURCE_SIZE)).Decode(&req); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Target) == 0 {
+		req.Target = c.target
+	}
+
+	walker, err := c.newWalker(req.Target)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	output, err := walker.Convert(req.Source)
+	resp := ConvertResponse{Output: output, Diagnostics: walkngo.Problems(err)}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false) // the output is code, i.e. #include <go.h>
+	enc.Encode(resp)
+}
+
+// the page of the serve command: the Go source on the left, the converted code and the diagnostics on the right
+const servePage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>walkngo</title>
+<style>
+body { font-family: sans-serif; margin: 0; display: flex; flex-direction: column; height: 100vh; }
+header { padding: 8px 12px; background: #eee; display: flex; gap: 12px; align-items: center; }
+main { flex: 1; display: flex; min-height: 0; }
+textarea, pre { flex: 1; margin: 0; padding: 8px; font-family: monospace; font-size: 13px; border: 0; border-right: 1px solid #ccc; overflow: auto; }
+#result { flex: 1; display: flex; flex-direction: column; min-width: 0; }
+#diagnostics { max-height: 30%; overflow: auto; margin: 0; padding: 4px 8px; border-top: 1px solid #ccc; font-family: monospace; font-size: 12px; list-style: none; }
+.error { color: #c00; }
+.warning { color: #a60; }
+</style>
+</head>
+<body>
+<header>
+<b>walkngo</b>
+<select id="target">
+<option value="c">C++</option>
+<option value="go">Go</option>
+<option value="rust">Rust</option>
+<option value="swift">Swift</option>
+</select>
+<button id="convert">Convert</button>
+<span>(Ctrl+Enter)</span>
+</header>
+<main>
+<textarea id="source" spellcheck="false">package main
+
+import "fmt"
+
+func main() {
+	for i := 0; i < 3; i++ {
+		fmt.Println("hello", i)
+	}
+}
+</textarea>
+<div id="result">
+<pre id="output"></pre>
+<ul id="diagnostics"></ul>
+</div>
+</main>
+<script>
+const target = document.getElementById("target");
+const source = document.getElementById("source");
+const output = document.getElementById("output");
+const diagnostics = document.getElementById("diagnostics");
+
+target.value = {"cc": "c", "rs": "rust"}["{{TARGET}}"] || "{{TARGET}}";
+
+async function convert() {
+	const resp = await fetch("/convert", {
+		method: "POST",
+		headers: {"Content-Type": "application/json"},
+		body: JSON.stringify({source: source.value, target: target.value}),
+	});
+
+	diagnostics.replaceChildren();
+
+	if (!resp.ok) {
+		output.textContent = await resp.text();
+		return;
+	}
+
+	const result = await resp.json();
+	output.textContent = result.output;
+
+	for (const d of result.diagnostics) {
+		const li = document.createElement("li");
+		li.className = d.severity;
+		li.textContent = (d.line ? d.line + ":" + d.column + ": " : "") + d.severity + ": " + d.message;
+		diagnostics.appendChild(li);
+	}
+}
+
+document.getElementById("convert").onclick = convert;
+target.onchange = convert;
+source.onkeydown = e => { if (e.key === "Enter" && e.ctrlKey) convert(); };
+convert();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,53 @@
+package main
+
+//
+// verbosity levels of the messages printed while converting (-q, -v, -vv)
+//
+
+import (
+	"fmt"
+	"os"
+)
+
+//
+// Verbosity selects the messages printed while converting.
+// The messages always go to stderr, so that they don't mix with the converted code printed to stdout
+//
+type Verbosity int
+
+const (
+	QUIET        Verbosity = iota - 1 // only the errors
+	NORMAL                            // the errors and the warnings for the constructs not converted
+	VERBOSE                           // also the progress, for each file
+	VERY_VERBOSE                      // also the notes for each construct (fallbacks taken, types guessed)
+)
+
+//
+// ParseVerbosity returns the verbosity for the -q, -v and -vv flags, and false if they are combined
+//
+func ParseVerbosity(quiet, verbose, veryVerbose bool) (Verbosity, bool) {
+	switch {
+	case quiet && (verbose || veryVerbose):
+		return NORMAL, false
+
+	case quiet:
+		return QUIET, true
+
+	case veryVerbose:
+		return VERY_VERBOSE, true
+
+	case verbose:
+		return VERBOSE, true
+	}
+
+	return NORMAL, true
+}
+
+//
+// Printf prints a message if the verbosity is at least level
+//
+func (v Verbosity) Printf(level Verbosity, format string, args ...interface{}) {
+	if v >= level {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+}
@@ -0,0 +1,37 @@
+package walkngo
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"strings"
+
+	"github.com/raff/walkngo/printer"
+)
+
+//
+// SetAnnotate enables printing the Go signature of each function (receiver, parameters and results)
+// as a one-line comment above its translation, to check the conversion of the types
+//
+func (w *GoWalker) SetAnnotate(enable bool) {
+	w.annotate = enable
+}
+
+//
+// printSignature prints the Go signature of the function as a comment:
+//
+//	// Go: func (s *Stack) Pop() (int, bool)
+//
+func (w *GoWalker) printSignature(fd *ast.FuncDecl) {
+	decl := *fd
+	decl.Doc, decl.Body = nil, nil
+
+	var b bytes.Buffer
+	if err := format.Node(&b, w.fset, &decl); err != nil {
+		return
+	}
+
+	// signatures spanning multiple lines are joined
+	w.p.PrintLevel(printer.NONE, "// Go:", strings.Join(strings.Fields(b.String()), " "))
+	w.p.Print("\n")
+}
@@ -0,0 +1,137 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/types"
+	"strconv"
+
+	"github.com/raff/walkngo/printer"
+)
+
+//
+// collectAnonTypes names the struct and interface types declared in the function signatures
+// (func f(p struct{ X, Y int })), for the targets that can't declare types there (without CAP_ANON_TYPES).
+// The name is the function name (with the receiver type for methods) and the parameter name, i.e. f_p,
+// and the same type gets the same name in all the signatures and in the composite literals
+//
+func (w *GoWalker) collectAnonTypes(f *ast.File) {
+	w.anonTypes, w.anonDone = map[string]string{}, map[string]bool{}
+
+	if w.p.Capabilities().Has(printer.CAP_ANON_TYPES) {
+		return
+	}
+
+	for _, d := range f.Decls {
+		fd, ok := d.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+
+		prefix := fd.Name.Name
+		if fd.Recv != nil && len(fd.Recv.List) > 0 {
+			if recv := receiverType(fd.Recv.List[0].Type); len(recv) > 0 {
+				prefix = recv + "_" + prefix
+			}
+		}
+
+		w.nameAnonTypes(prefix, "param", fd.Type.Params)
+		w.nameAnonTypes(prefix, "result", fd.Type.Results)
+	}
+}
+
+//
+// nameAnonTypes names the anonymous types in a list of parameters or results (unnamed is the name
+// of the fields without a name, followed by their index)
+//
+func (w *GoWalker) nameAnonTypes(prefix, unnamed string, l *ast.FieldList) {
+	if l == nil {
+		return
+	}
+
+	for i, f := range l.List {
+		key := types.ExprString(f.Type)
+		if !isAnonType(f.Type) || len(w.anonTypes[key]) > 0 {
+			continue
+		}
+
+		field := unnamed + strconv.Itoa(i)
+		if len(f.Names) > 0 && f.Names[0].Name != "_" {
+			field = f.Names[0].Name
+		}
+
+		name := prefix + "_" + field
+		if w.names.IsUsed(name) {
+			name = w.names.New(name + "_")
+		} else {
+			w.names.Reserve(name)
+		}
+
+		w.anonTypes[key] = name
+	}
+}
+
+//
+// printAnonTypes declares the anonymous types in the signature of a function (before the function) and replaces them
+// with their names (see collectAnonTypes)
+//
+func (w *GoWalker) printAnonTypes(ft *ast.FuncType) {
+	for _, l := range []*ast.FieldList{ft.Params, ft.Results} {
+		if l == nil {
+			continue
+		}
+
+		for _, f := range l.List {
+			key := types.ExprString(f.Type)
+
+			name, ok := w.anonTypes[key]
+			if !ok || !isAnonType(f.Type) {
+				continue
+			}
+
+			if !w.anonDone[key] {
+				w.anonDone[key] = true
+
+				w.newLine()
+				w.p.PrintType(name, w.parseExpr(f.Type))
+			}
+
+			f.Type = &ast.Ident{NamePos: f.Type.Pos(), Name: name}
+		}
+	}
+}
+
+//
+// isAnonType returns true for a non empty struct or interface type (struct{} is the same type everywhere)
+//
+func isAnonType(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.StructType:
+		return t.Fields != nil && len(t.Fields.List) > 0
+
+	case *ast.InterfaceType:
+		return t.Methods != nil && len(t.Methods.List) > 0
+	}
+
+	return false
+}
+
+//
+// receiverType returns the name of the type of a receiver (T for t *T or t T[K])
+//
+func receiverType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverType(t.X)
+
+	case *ast.IndexExpr:
+		return receiverType(t.X)
+
+	case *ast.IndexListExpr:
+		return receiverType(t.X)
+
+	case *ast.Ident:
+		return t.Name
+	}
+
+	return ""
+}
@@ -0,0 +1,92 @@
+package walkngo
+
+import (
+	"go/ast"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// prefixes of the sync/atomic functions operating on the address of a variable
+var atomicOps = []string{"Add", "Load", "Store", "Swap", "CompareAndSwap", "And", "Or"}
+
+//
+// collectAtomics records the variables and struct fields whose address is passed to the sync/atomic functions
+// (i.e. atomic.AddInt64(&counter, 1), atomic.LoadInt32(&s.state)), so that they can be declared as atomic types.
+//
+// Fields are matched by name, since the walker doesn't have type information.
+//
+func (w *GoWalker) collectAtomics(f *ast.File) {
+	w.atomicVars = map[*ast.Object]bool{}
+	w.atomicFields = map[string]bool{}
+
+	local := ""
+	for _, i := range f.Imports {
+		if ipath, err := strconv.Unquote(i.Path.Value); err == nil && ipath == "sync/atomic" {
+			local = path.Base(ipath)
+			if i.Name != nil {
+				local = i.Name.Name
+			}
+		}
+	}
+
+	if len(local) == 0 {
+		return
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || !isAtomicOp(sel.Sel.Name) {
+			return true
+		}
+
+		if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != local || pkg.Obj != nil {
+			return true
+		}
+
+		addr, ok := call.Args[0].(*ast.UnaryExpr)
+		if !ok || addr.Op.String() != "&" {
+			return true
+		}
+
+		switch x := addr.X.(type) {
+		case *ast.Ident:
+			if x.Obj != nil {
+				w.atomicVars[x.Obj] = true
+			}
+
+		case *ast.SelectorExpr:
+			w.atomicFields[x.Sel.Name] = true
+		}
+
+		return true
+	})
+}
+
+func isAtomicOp(name string) bool {
+	for _, op := range atomicOps {
+		if strings.HasPrefix(name, op) && name != op {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// isAtomicSpec returns true if any of the variables declared by the spec is used atomically
+//
+func (w *GoWalker) isAtomicSpec(spec *ast.ValueSpec) bool {
+	for _, n := range spec.Names {
+		if n.Obj != nil && w.atomicVars[n.Obj] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,58 @@
+package walkngo
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// cgo names for C types that are not valid C identifiers
+var cgoTypes = map[string]string{
+	"schar":     "signed char",
+	"uchar":     "unsigned char",
+	"ushort":    "unsigned short",
+	"uint":      "unsigned int",
+	"ulong":     "unsigned long",
+	"longlong":  "long long",
+	"ulonglong": "unsigned long long",
+}
+
+//
+// isCgoImport returns true for import "C"
+//
+func isCgoImport(spec *ast.ImportSpec) bool {
+	return spec.Path.Value == `"C"`
+}
+
+//
+// printPreamble prints the C code in the comment preceding import "C" (without #cgo directives)
+//
+func (w *GoWalker) printPreamble(doc *ast.CommentGroup) {
+	if doc == nil {
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(doc.Text(), "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#cgo") {
+			continue
+		}
+
+		w.p.Print(line + "\n")
+	}
+}
+
+//
+// cgoName converts C.name to the C name (C.struct_x to "struct x", C.uint to "unsigned int")
+//
+func cgoName(name string) string {
+	if t, ok := cgoTypes[name]; ok {
+		return t
+	}
+
+	for _, prefix := range []string{"struct", "union", "enum"} {
+		if strings.HasPrefix(name, prefix+"_") {
+			return prefix + " " + name[len(prefix)+1:]
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,35 @@
+package walkngo
+
+import (
+	"fmt"
+
+	"github.com/raff/walkngo/printer"
+)
+
+//
+// SetCheckSyntax enables the sanity check of the generated C++ code (see printer.SyntaxCheck):
+// the lines that can't be valid are reported as errors of the converted file
+//
+func (w *GoWalker) SetCheckSyntax(enable bool) {
+	if enable && w.check == nil {
+		w.check = printer.NewSyntaxCheck()
+		w.pipe.Add(w.check)
+	}
+}
+
+//
+// addSyntaxErrors records the problems found by the syntax check in the output of the current file
+//
+func (w *GoWalker) addSyntaxErrors() {
+	if w.check == nil {
+		return
+	}
+
+	for _, p := range w.check.Problems() {
+		w.errors = append(w.errors, &WalkError{
+			Pos:       p.Pos,
+			Msg:       fmt.Sprintf("invalid output (line %d): %s", p.Line, p.Msg),
+			Construct: "invalid output",
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+//
+// SetComments enables (the default) or disables printing the comments of the Go source
+//
+func (w *GoWalker) SetComments(enable bool) {
+	w.comments = enable
+}
+
+//
+// nodeComments returns the comments associated to node (see ast.CommentMap), split in the ones
+// preceding the node and the ones following it (on the same line or after), without the doc comments
+// printed by printDoc, the cgo preamble and the directives
+//
+func (w *GoWalker) nodeComments(node ast.Node) (before, after []*ast.CommentGroup) {
+	if !w.comments || w.cmap == nil {
+		return nil, nil
+	}
+
+	for _, g := range w.cmap[node] {
+		if w.isDoc(node, g) || len(g.Text()) == 0 { // directives (pragmas) only
+			continue
+		}
+
+		if g.Pos() < node.Pos() {
+			before = append(before, g)
+		} else {
+			after = append(after, g)
+		}
+	}
+
+	return
+}
+
+//
+// isDoc returns true if the comment group is the doc of an exported declaration (printed with PrintDoc)
+// or the doc of an import (that can be the cgo preamble)
+//
+func (w *GoWalker) isDoc(node ast.Node, g *ast.CommentGroup) bool {
+	switch n := node.(type) {
+	case *ast.FuncDecl:
+		return g == n.Doc && n.Name.IsExported()
+
+	case *ast.GenDecl:
+		if g != n.Doc {
+			return false
+		}
+
+		if n.Tok == token.IMPORT {
+			return true
+		}
+
+		if n.Lparen.IsValid() || len(n.Specs) == 0 {
+			return false
+		}
+
+		// the doc of the only spec (see specDoc)
+		switch s := n.Specs[0].(type) {
+		case *ast.TypeSpec:
+			return s.Doc == nil && s.Name.IsExported()
+
+		case *ast.ValueSpec:
+			return s.Doc == nil && hasExported(s.Names)
+		}
+
+	case *ast.TypeSpec:
+		return g == n.Doc && n.Name.IsExported()
+
+	case *ast.ValueSpec:
+		return g == n.Doc && hasExported(n.Names)
+
+	case *ast.ImportSpec:
+		return g == n.Doc
+	}
+
+	return false
+}
+
+func hasExported(names []*ast.Ident) bool {
+	for _, n := range names {
+		if n.IsExported() {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// printComments prints the comment groups, one line comment for each line
+//
+func (w *GoWalker) printComments(groups []*ast.CommentGroup) {
+	for _, g := range groups {
+		w.p.PrintComment(g.Text())
+	}
+}
@@ -0,0 +1,39 @@
+package walkngo
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+//
+// Convert converts Go code in memory and returns the output: a Go file (starting with a package clause),
+// converted as main.go, or a snippet of statements or declarations (see WalkSnippet).
+// As for WalkFile, with the constructs not converted the output is returned with a WalkErrors
+//
+func (w *GoWalker) Convert(src string) (string, error) {
+	var out bytes.Buffer
+
+	old := w.SetWriter(&out)
+	defer w.SetWriter(old)
+
+	var err error
+
+	if IsGoFile(src) {
+		err = w.WalkSource("main.go", []byte(src))
+	} else {
+		err = w.WalkSnippet(src)
+	}
+
+	w.Flush()
+	return strings.Trim(out.String(), "\n"), err
+}
+
+//
+// IsGoFile returns true if the source starts with a package clause (otherwise it's a snippet)
+//
+func IsGoFile(src string) bool {
+	_, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly)
+	return err == nil
+}
@@ -0,0 +1,144 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// translation status of the source ranges
+const (
+	COVERAGE_TRANSLATED = "translated"
+	COVERAGE_PARTIAL    = "partial"
+	COVERAGE_SKIPPED    = "skipped"
+)
+
+// severity of the statuses, a line takes the most severe of the ranges containing it
+var coverageRank = map[string]int{
+	COVERAGE_TRANSLATED: 1,
+	COVERAGE_PARTIAL:    2,
+	COVERAGE_SKIPPED:    3,
+}
+
+//
+// CoverageRange is a range of the Go source (a declaration or a construct not converted) and its translation status
+//
+type CoverageRange struct {
+	Status      string   `json:"status"`
+	StartLine   int      `json:"start_line"`
+	StartColumn int      `json:"start_column"`
+	EndLine     int      `json:"end_line"`
+	EndColumn   int      `json:"end_column"`
+	Constructs  []string `json:"constructs,omitempty"` // what was not converted
+}
+
+//
+// FileCoverage is the "transpilation coverage" of a Go source file: the declarations fully translated,
+// partially translated (some constructs were not converted or are not supported by the target) and skipped
+// (//walkngo:skip), and the constructs replaced by placeholders (skipped)
+//
+type FileCoverage struct {
+	File   string          `json:"file"`
+	Lines  map[string]int  `json:"lines"` // number of lines of the declarations, by status
+	Ranges []CoverageRange `json:"ranges"`
+
+	status []string // status of each line ("" for the lines outside of the declarations)
+}
+
+//
+// Status returns the status of a line (1 based), empty for the lines outside of the declarations
+//
+func (c *FileCoverage) Status(line int) string {
+	if line < 1 || line > len(c.status) {
+		return ""
+	}
+
+	return c.status[line-1]
+}
+
+//
+// Coverage returns the coverage of the last file walked (nil if it couldn't be parsed)
+//
+func (w *GoWalker) Coverage() *FileCoverage {
+	if w.file == nil {
+		return nil
+	}
+
+	tf := w.fset.File(w.file.Pos())
+	c := &FileCoverage{File: tf.Name(), Lines: map[string]int{}, status: make([]string, tf.LineCount())}
+
+	add := func(start, end token.Pos, status string, constructs []string) {
+		from, to := w.fset.PositionFor(start, false), w.fset.PositionFor(end, false)
+		c.Ranges = append(c.Ranges, CoverageRange{status, from.Line, from.Column, to.Line, to.Column, constructs})
+
+		for l := from.Line; l <= to.Line && l <= len(c.status); l++ {
+			if coverageRank[status] > coverageRank[c.status[l-1]] {
+				c.status[l-1] = status
+			}
+		}
+	}
+
+	for _, d := range w.file.Decls {
+		var doc *ast.CommentGroup
+
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			doc = d.Doc
+		case *ast.GenDecl:
+			doc = d.Doc
+		}
+
+		if prag, _ := parsePragmas(doc); prag.skip {
+			add(d.Pos(), d.End(), COVERAGE_SKIPPED, nil)
+			continue
+		}
+
+		start, end := w.fset.PositionFor(d.Pos(), false).Offset, w.fset.PositionFor(d.End(), false).Offset
+		status, constructs, seen := COVERAGE_TRANSLATED, []string(nil), map[string]bool{}
+
+		for _, e := range w.errors {
+			if e.Pos.IsValid() && e.Pos.Offset >= start && e.Pos.Offset < end {
+				status = COVERAGE_PARTIAL
+
+				if !seen[e.Construct] {
+					seen[e.Construct] = true
+					constructs = append(constructs, e.Construct)
+				}
+			}
+		}
+
+		add(d.Pos(), d.End(), status, constructs)
+	}
+
+	for _, n := range w.skipped {
+		add(n.Pos(), n.End(), COVERAGE_SKIPPED, []string{constructName(n) + "s"})
+	}
+
+	for i, status := range c.status {
+		if len(status) > 0 && !w.blankLine(i+1) {
+			c.Lines[status]++
+		}
+	}
+
+	return c
+}
+
+//
+// blankLine returns true if the line (1 based) of the source is empty
+//
+func (w *GoWalker) blankLine(line int) bool {
+	tf := w.fset.File(w.file.Pos())
+
+	start := tf.Offset(tf.LineStart(line))
+	end := len(w.src)
+	if line < tf.LineCount() {
+		end = tf.Offset(tf.LineStart(line + 1))
+	}
+
+	for _, b := range w.src[start:end] {
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return false
+		}
+	}
+
+	return true
+}
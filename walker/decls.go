@@ -0,0 +1,210 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+//
+// declaration returns how a variable or a constant was declared, for the predicates that guess the type of an
+// expression without type information (isInteger, isChan, isString, ...): the type, if the declaration has one
+// (var x T, func f(x T), the struct fields, the elements of a range over a declared type), and the value it was
+// initialized with (var x = v, x := v, const c = v). The value of a constant repeating the previous expression of
+// the block (const ( A = iota; B )) is nil. Both are nil for the declarations that are not known, and for the
+// initializations depending on themselves (var a = b; var b = a, in a file with errors)
+//
+func declaration(id *ast.Ident) (typ, value ast.Expr) {
+	typ, init, index, isRange := initialization(id)
+	if init != nil && dependsOn(init, id.Obj, map[*ast.Object]bool{}) {
+		return typ, nil
+	}
+
+	if isRange {
+		return rangeType(exprType(init), index), nil
+	}
+
+	return typ, init
+}
+
+//
+// initialization returns the declared type and the expression initializing a variable or a constant (see declaration):
+// for the key (index 0) or the value (index 1) of a range, the expression ranged over
+//
+func initialization(id *ast.Ident) (typ, init ast.Expr, index int, isRange bool) {
+	if id.Obj == nil || (id.Obj.Kind != ast.Var && id.Obj.Kind != ast.Con) {
+		return
+	}
+
+	switch decl := id.Obj.Decl.(type) {
+	case *ast.Field:
+		return decl.Type, nil, 0, false
+
+	case *ast.ValueSpec:
+		for i, name := range decl.Names {
+			if name.Name == id.Name && i < len(decl.Values) {
+				init = decl.Values[i]
+			}
+		}
+
+		return decl.Type, init, 0, false
+
+	case *ast.AssignStmt:
+		for i, lhs := range decl.Lhs {
+			if n, ok := lhs.(*ast.Ident); !ok || n.Name != id.Name {
+				continue
+			}
+
+			if len(decl.Rhs) == len(decl.Lhs) {
+				if r, ok := decl.Rhs[i].(*ast.UnaryExpr); !ok || r.Op != token.RANGE {
+					return nil, decl.Rhs[i], 0, false
+				}
+			}
+
+			if r, ok := decl.Rhs[0].(*ast.UnaryExpr); ok && r.Op == token.RANGE {
+				return nil, r.X, i, true
+			}
+		}
+	}
+
+	return
+}
+
+//
+// dependsOn returns true if the expression uses obj, directly or through the initialization of the variables and
+// the constants it uses (seen are the objects already visited). The function literals are not followed
+//
+func dependsOn(expr ast.Expr, obj *ast.Object, seen map[*ast.Object]bool) (found bool) {
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+
+		case *ast.Ident:
+			if n.Obj == obj {
+				found = true
+			} else if n.Obj != nil && !seen[n.Obj] {
+				seen[n.Obj] = true
+				if _, init, _, _ := initialization(n); init != nil {
+					found = dependsOn(init, obj, seen)
+				}
+			}
+		}
+
+		return !found
+	})
+
+	return
+}
+
+//
+// rangeType returns the type of the key (i = 0) or of the value (i = 1) of a range over a slice, an array or a map, or nil
+//
+func rangeType(t ast.Expr, i int) ast.Expr {
+	switch t := t.(type) {
+	case *ast.ArrayType:
+		if i == 0 {
+			return &ast.Ident{NamePos: t.Pos(), Name: "int"}
+		}
+		return t.Elt
+
+	case *ast.MapType:
+		if i == 0 {
+			return t.Key
+		}
+		return t.Value
+	}
+
+	return nil
+}
+
+//
+// exprType returns the type of the expression, when it can be found from the declarations in the file
+// (var s string, s := "literal", name := p.Name), or nil
+//
+func exprType(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.STRING {
+			return &ast.Ident{NamePos: e.Pos(), Name: "string"}
+		}
+
+	case *ast.ParenExpr:
+		return exprType(e.X)
+
+	case *ast.CompositeLit:
+		return e.Type
+
+	case *ast.UnaryExpr:
+		if t := exprType(e.X); t != nil && e.Op == token.AND {
+			return &ast.StarExpr{Star: e.Pos(), X: t}
+		}
+
+	case *ast.BinaryExpr:
+		if e.Op == token.ADD {
+			if t := exprType(e.X); t != nil {
+				return t
+			}
+
+			return exprType(e.Y)
+		}
+
+	case *ast.SliceExpr:
+		return exprType(e.X)
+
+	case *ast.IndexExpr:
+		return rangeType(exprType(e.X), 1)
+
+	case *ast.CallExpr:
+		// conversions, string(b)
+		if fun, ok := e.Fun.(*ast.Ident); ok && fun.Obj == nil && (fun.Name == "string" || integerTypes[fun.Name]) {
+			return fun
+		}
+
+	case *ast.SelectorExpr:
+		return fieldType(exprType(e.X), e.Sel.Name)
+
+	case *ast.Ident:
+		typ, value := declaration(e)
+		if typ == nil && value != nil {
+			return exprType(value)
+		}
+
+		return typ
+	}
+
+	return nil
+}
+
+//
+// fieldType returns the type of the field name of a struct type declared in the file (or a pointer to it), or nil
+//
+func fieldType(t ast.Expr, name string) ast.Expr {
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+
+	id, ok := t.(*ast.Ident)
+	if !ok || id.Obj == nil || id.Obj.Kind != ast.Typ {
+		return nil
+	}
+
+	spec, ok := id.Obj.Decl.(*ast.TypeSpec)
+	if !ok {
+		return nil
+	}
+
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return nil
+	}
+
+	for _, f := range st.Fields.List {
+		for _, n := range f.Names {
+			if n.Name == name {
+				return f.Type
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+//
+// TestDeclarationCycle checks that the predicates following the declarations stop at the initializations
+// depending on themselves, in the files with errors
+//
+func TestDeclarationCycle(t *testing.T) {
+	src := `package main
+
+var a = b
+var b = a + 1
+
+func main() {
+	x, y := y, x
+	for _, c := range a {
+		println(c, x, y)
+	}
+}
+`
+
+	f, err := parser.ParseFile(token.NewFileSet(), "cycle.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Obj != nil && id.Obj.Kind == ast.Var {
+			if isInteger(id) || isString(id) || isChan(id) || exprType(id) != nil {
+				t.Errorf("%s: unexpected type", id.Name)
+			}
+		}
+
+		return true
+	})
+}
@@ -0,0 +1,60 @@
+package walkngo
+
+import (
+	"bytes"
+	"go/ast"
+)
+
+//
+// SetDeclsFallback enables converting only the declarations of the files that can't be fully converted:
+// types, constants, variables and the prototypes of the functions (without the methods and the function bodies),
+// so that the rest of the package can be compiled against them while the file is ported by hand
+//
+func (w *GoWalker) SetDeclsFallback(enable bool) {
+	w.declsFallback = enable
+}
+
+//
+// emitDeclsFallback converts the file analyzed last to a buffer and writes it to the output
+// if there were no problems, otherwise it converts it again with only the declarations.
+// The problems reported are the ones of the full conversion
+//
+func (w *GoWalker) emitDeclsFallback(pf *ParsedFile) {
+	var buffer bytes.Buffer
+
+	out := w.SetWriter(&buffer)
+	w.emit(pf)
+	w.SetWriter(out)
+
+	if len(w.errors) == 0 {
+		w.out.Write(buffer.Bytes())
+		w.out.Flush()
+		return
+	}
+
+	errors := w.errors
+
+	w.reset(pf.Filename)
+	w.prepare(pf.File, pf.Src, false)
+
+	w.declsOnly = true
+	w.emit(pf)
+	w.declsOnly = false
+
+	w.errors = errors
+}
+
+//
+// printDeclOnly prints the prototype of a function, in place of its definition (see SetDeclsFallback)
+//
+func (w *GoWalker) printDeclOnly(n *ast.FuncDecl) {
+	if !hasPrototype(n) || w.groupDecls {
+		// methods can't be declared on their own, and with groupDecls the prototypes are already printed
+		return
+	}
+
+	w.printAnonTypes(n.Type)
+	w.newLine()
+	w.printDoc(n.Doc, n.Name)
+	w.printPrototype(n)
+}
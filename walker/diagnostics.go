@@ -0,0 +1,194 @@
+package walkngo
+
+import (
+	"bytes"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	SEVERITY_ERROR   = "error"
+	SEVERITY_WARNING = "warning"
+)
+
+// ANSI escape sequences, as used by gcc
+const (
+	ANSI_BOLD    = "\033[01m"
+	ANSI_RED     = "\033[01;31m"
+	ANSI_MAGENTA = "\033[01;35m"
+	ANSI_GREEN   = "\033[01;32m"
+	ANSI_RESET   = "\033[m"
+)
+
+//
+// Diagnostics prints the problems found converting the files in the gcc format,
+// so that they can be followed in editors and CI logs, with the offending line:
+//
+//	foo.go:12:2: warning: unsupported node *ast.SelectStmt
+//	   12 |	select {
+//	      |	^
+//
+// The constructs that were not converted (WalkErrors) are warnings and parse errors are errors.
+//
+type Diagnostics struct {
+	W     io.Writer
+	Color bool // use ANSI colors
+	Quiet bool // only print the errors
+
+	file  string   // the file of the last snippet
+	lines [][]byte // the lines of file
+}
+
+//
+// Print prints a diagnostic, with the source line if the position is known
+//
+func (d *Diagnostics) Print(severity string, pos token.Position, msg string) {
+	if d.Quiet && severity != SEVERITY_ERROR {
+		return
+	}
+
+	color := ANSI_MAGENTA
+	if severity == SEVERITY_ERROR {
+		color = ANSI_RED
+	}
+
+	locus := "walkngo"
+	if pos.IsValid() {
+		locus = pos.String()
+	}
+
+	fmt.Fprintf(d.W, "%s: %s %s\n", d.colored(ANSI_BOLD, locus), d.colored(color, severity+":"), msg)
+
+	if line, ok := d.sourceLine(pos); ok {
+		// keep the tabs before the column, so that the caret is aligned
+		caret := []byte(strings.Repeat(" ", min(pos.Column-1, len(line))))
+		for i := range caret {
+			if line[i] == '\t' {
+				caret[i] = '\t'
+			}
+		}
+
+		lineno := fmt.Sprintf("%5d", pos.Line)
+		fmt.Fprintf(d.W, "%s | %s\n", lineno, line)
+		fmt.Fprintf(d.W, "%s | %s%s\n", strings.Repeat(" ", len(lineno)), caret, d.colored(ANSI_GREEN, "^"))
+	}
+}
+
+//
+// Diagnostic is a problem found converting a file, for the tools (see Problems)
+//
+type Diagnostic struct {
+	Severity  string `json:"severity"` // SEVERITY_ERROR or SEVERITY_WARNING
+	Line      int    `json:"line,omitempty"`
+	Column    int    `json:"column,omitempty"`
+	Message   string `json:"message"`
+	Construct string `json:"construct,omitempty"` // what was not converted
+}
+
+//
+// Problems returns the problems in the error returned by WalkFile, with the same severity used by PrintError
+// (an empty list for a nil error)
+//
+func Problems(err error) []Diagnostic {
+	diags := []Diagnostic{}
+
+	switch errs := err.(type) {
+	case nil:
+
+	case WalkErrors:
+		for _, e := range errs {
+			diags = append(diags, Diagnostic{SEVERITY_WARNING, e.Pos.Line, e.Pos.Column, e.Msg, e.Construct})
+		}
+
+	case scanner.ErrorList:
+		for _, e := range errs {
+			diags = append(diags, Diagnostic{SEVERITY_ERROR, e.Pos.Line, e.Pos.Column, e.Msg, ""})
+		}
+
+	default:
+		diags = append(diags, Diagnostic{SEVERITY_ERROR, 0, 0, err.Error(), ""})
+	}
+
+	return diags
+}
+
+//
+// PrintError prints the error returned by WalkFile: the list of constructs not converted (warnings),
+// the parse errors or any other error
+//
+func (d *Diagnostics) PrintError(err error) {
+	switch errs := err.(type) {
+	case WalkErrors:
+		for _, e := range errs {
+			d.Print(SEVERITY_WARNING, e.Pos, e.Msg)
+		}
+
+	case scanner.ErrorList:
+		for _, e := range errs {
+			d.Print(SEVERITY_ERROR, e.Pos, e.Msg)
+		}
+
+	default:
+		d.Print(SEVERITY_ERROR, token.Position{}, err.Error())
+	}
+}
+
+func (d *Diagnostics) colored(color, text string) string {
+	if !d.Color {
+		return text
+	}
+
+	return color + text + ANSI_RESET
+}
+
+//
+// sourceLine returns the line at pos (reading the file if it's not the one of the previous diagnostic)
+//
+func (d *Diagnostics) sourceLine(pos token.Position) ([]byte, bool) {
+	if !pos.IsValid() || len(pos.Filename) == 0 {
+		return nil, false
+	}
+
+	if pos.Filename != d.file {
+		src, err := os.ReadFile(pos.Filename)
+		if err != nil {
+			return nil, false
+		}
+
+		d.file, d.lines = pos.Filename, bytes.Split(src, []byte("\n"))
+	}
+
+	if pos.Line < 1 || pos.Line > len(d.lines) {
+		return nil, false
+	}
+
+	return bytes.TrimRight(d.lines[pos.Line-1], "\r"), true
+}
+
+//
+// UseColor returns true if the diagnostics written to f should be colored for the mode (always, never or auto:
+// only if f is a terminal and the NO_COLOR environment variable is not set), and false for an invalid mode
+//
+func UseColor(mode string, f *os.File) (color, ok bool) {
+	switch mode {
+	case "always":
+		return true, true
+
+	case "never":
+		return false, true
+
+	case "auto":
+		if len(os.Getenv("NO_COLOR")) > 0 {
+			return false, true
+		}
+
+		info, err := f.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, true
+	}
+
+	return false, false
+}
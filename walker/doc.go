@@ -0,0 +1,34 @@
+package walkngo
+
+import (
+	"go/ast"
+)
+
+//
+// printDoc prints the doc comment of a declaration, if any of the declared names is exported
+//
+func (w *GoWalker) printDoc(doc *ast.CommentGroup, names ...*ast.Ident) {
+	if doc == nil || !w.comments {
+		return
+	}
+
+	if hasExported(names) {
+		w.p.PrintDoc(doc.Text())
+	}
+}
+
+//
+// specDoc returns the doc comment of a type or value spec, or the one of the declaration
+// when it is not a group (type T ... instead of type ( T ... )). Local declarations are not documented
+//
+func (w *GoWalker) specDoc(doc *ast.CommentGroup) *ast.CommentGroup {
+	if !w.isTopLevel() {
+		return nil
+	}
+
+	if decl, ok := w.parent().(*ast.GenDecl); ok && doc == nil && !decl.Lparen.IsValid() {
+		return decl.Doc
+	}
+
+	return doc
+}
@@ -0,0 +1,42 @@
+package walkngo
+
+import (
+	"fmt"
+	"go/token"
+)
+
+//
+// WalkError describes a construct that the walker couldn't convert
+//
+type WalkError struct {
+	Pos       token.Position
+	Msg       string
+	Construct string // what was not converted (i.e. "select statements", "generics"), see Summary
+}
+
+func (e *WalkError) Error() string {
+	if e.Pos.IsValid() {
+		return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+	}
+
+	return e.Msg
+}
+
+//
+// WalkErrors is the list of errors collected while walking a file.
+//
+// WalkFile returns it when the output is only a "best effort" conversion,
+// so that callers can tell it apart from parse errors and from a clean run.
+//
+type WalkErrors []*WalkError
+
+func (l WalkErrors) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+
+	return fmt.Sprintf("%s (and %d more errors)", l[0], len(l)-1)
+}
@@ -0,0 +1,37 @@
+package walkngo
+
+import (
+	"go/doc"
+	"strings"
+)
+
+//
+// Example is a testable example (func ExampleXxx with an "// Output:" comment) of a _test.go file
+//
+type Example struct {
+	Func      string // name of the example function
+	Output    string // expected output
+	Unordered bool   // the output lines can be in any order ("// Unordered output:")
+}
+
+//
+// Examples returns the testable examples of the last file walked (only _test.go files have examples).
+// The examples without an output comment are compiled but not run by go test, so they are not returned
+//
+func (w *GoWalker) Examples() []Example {
+	if w.file == nil || !strings.HasSuffix(w.fset.File(w.file.Pos()).Name(), "_test.go") {
+		return nil
+	}
+
+	var examples []Example
+
+	for _, ex := range doc.Examples(w.file) {
+		if len(ex.Output) == 0 && !ex.EmptyOutput {
+			continue
+		}
+
+		examples = append(examples, Example{Func: "Example" + ex.Name, Output: ex.Output, Unordered: ex.Unordered})
+	}
+
+	return examples
+}
@@ -0,0 +1,95 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+//
+// Export is an exported declaration of the converted file (see Exports)
+//
+type Export struct {
+	Kind     token.Token // token.FUNC, token.TYPE, token.VAR or token.CONST
+	Name     string      // the name in the output (as renamed by pragmas)
+	Receiver string      // the receiver type, for methods
+	Fields   []string    // the exported fields, for struct types
+}
+
+//
+// Exports returns the exported declarations of the last file walked (generic declarations excluded),
+// that can be used to generate bindings for the converted package
+//
+func (w *GoWalker) Exports() []Export {
+	return w.exports
+}
+
+//
+// exportFunc records an exported function or method
+//
+func (w *GoWalker) exportFunc(n *ast.FuncDecl, name string) {
+	if !n.Name.IsExported() || n.Type.TypeParams != nil {
+		return
+	}
+
+	if n.Recv == nil || len(n.Recv.List) == 0 {
+		w.exports = append(w.exports, Export{Kind: token.FUNC, Name: name})
+		return
+	}
+
+	rtype := n.Recv.List[0].Type
+	if star, ok := rtype.(*ast.StarExpr); ok {
+		rtype = star.X
+	}
+
+	if id, ok := rtype.(*ast.Ident); ok { // methods of generic types are not exported
+		w.exports = append(w.exports, Export{Kind: token.FUNC, Name: name, Receiver: w.identName(id)})
+	}
+}
+
+//
+// exportType records an exported type, with the exported fields of struct types
+//
+func (w *GoWalker) exportType(n *ast.TypeSpec) {
+	if !w.isTopLevel() || !n.Name.IsExported() || n.TypeParams != nil {
+		return
+	}
+
+	export := Export{Kind: token.TYPE, Name: w.identName(n.Name)}
+
+	if st, ok := n.Type.(*ast.StructType); ok {
+		export.Fields = []string{}
+
+		for _, f := range st.Fields.List {
+			for _, id := range f.Names {
+				// atomic fields can't be copied
+				if id.IsExported() && !w.atomicFields[id.Name] {
+					export.Fields = append(export.Fields, w.mangle(id.Name))
+				}
+			}
+		}
+	}
+
+	w.exports = append(w.exports, export)
+}
+
+//
+// exportValues records the exported constants and variables of a value spec
+//
+func (w *GoWalker) exportValues(tok token.Token, n *ast.ValueSpec) {
+	if !w.isTopLevel() {
+		return
+	}
+
+	for _, id := range n.Names {
+		if id.IsExported() {
+			w.exports = append(w.exports, Export{Kind: tok, Name: w.identName(id)})
+		}
+	}
+}
+
+//
+// isTopLevel returns true if the spec being visited is a package level declaration (file, decl, spec)
+//
+func (w *GoWalker) isTopLevel() bool {
+	return len(w.parents) == 3
+}
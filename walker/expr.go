@@ -0,0 +1,79 @@
+package walkngo
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"io"
+	"strings"
+
+	"github.com/raff/walkngo/printer"
+)
+
+// the name of the source file for the expressions (in the positions of the errors)
+const EXPRESSION = "expr"
+
+//
+// WalkExpr converts a single Go expression, printing only its translation (i.e. a > 1 && strings.HasPrefix(s, "x")).
+// As for WalkSnippet, the standard packages can be used without importing them and the other names are left as they are
+//
+func (w *GoWalker) WalkExpr(code string) error {
+	w.reset(EXPRESSION)
+
+	if _, err := parser.ParseExprFrom(w.fset, EXPRESSION, code, 0); err != nil {
+		return err
+	}
+
+	// the line directive makes the positions relative to the expression
+	src := []byte("package main\n\nfunc main() {\n_ =\n//line " + EXPRESSION + ":1:1\n" + code + "\n}\n")
+	f, err := parser.ParseFile(w.fset, EXPRESSION, src, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	w.prepare(f, src, true)
+
+	w.setOutput(io.Discard) // only the package name is needed, not the prologue
+	w.p.PrintPackage(f.Name.Name)
+	w.setOutput(w.pipe)
+
+	main := f.Decls[0].(*ast.FuncDecl)
+	assign := main.Body.List[0].(*ast.AssignStmt)
+
+	w.parents = []ast.Node{f, main, main.Body, assign}
+	w.p.PushContext()
+	w.symbols.Push()
+
+	w.p.Print(w.parseExpr(assign.Rhs[0]))
+
+	w.symbols.Pop()
+	w.p.PopContext()
+
+	w.Flush()
+
+	if len(w.errors) > 0 {
+		return w.errors
+	}
+
+	return nil
+}
+
+//
+// ConvertExpr converts a single Go expression to the target language (c, go, rust or swift), with the default options,
+// for the tools embedding an expression language with the Go syntax. The constructs not converted are reported
+// with a WalkErrors, as for WalkFile
+//
+func ConvertExpr(src, target string) (string, error) {
+	p, err := printer.New(target)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+
+	w := NewWalker(p, &out, false)
+	err = w.WalkExpr(src)
+	w.Flush()
+
+	return strings.TrimSpace(out.String()), err
+}
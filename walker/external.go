@@ -0,0 +1,25 @@
+package walkngo
+
+import (
+	"go/ast"
+)
+
+// construct of the functions without body (see printExternal)
+const EXTERNAL_FUNCS = "external functions"
+
+//
+// printExternal prints a function declared without body (implemented in assembly, or with //go:linkname):
+// the Go signature as a comment and the prototype, so that the callers still compile and the function
+// can be provided by hand. Methods can't be declared on their own, only the signature is printed
+//
+func (w *GoWalker) printExternal(n *ast.FuncDecl) {
+	w.addError(n, EXTERNAL_FUNCS, "function %s has no body (assembly or linkname), only declared", n.Name.Name)
+
+	w.newLine()
+	w.printDoc(n.Doc, n.Name)
+	w.printSignature(n)
+
+	if n.Recv == nil && n.Type.TypeParams == nil {
+		w.printPrototype(n)
+	}
+}
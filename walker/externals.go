@@ -0,0 +1,81 @@
+package walkngo
+
+import (
+	"go/ast"
+	"path"
+	"sort"
+	"strconv"
+)
+
+// package of the builtin functions, implemented by the runtime
+const BUILTIN = "builtin"
+
+var builtinFuncs = map[string]bool{
+	"append": true, "cap": true, "clear": true, "close": true, "complex": true, "copy": true, "delete": true,
+	"imag": true, "len": true, "make": true, "max": true, "min": true, "new": true, "panic": true,
+	"print": true, "println": true, "real": true, "recover": true,
+}
+
+//
+// Externals returns the symbols referenced by the last file walked and defined elsewhere, sorted by name:
+// the members of the imported packages (by import path) and the builtin functions (BUILTIN), provided by the runtime
+// or by the shims of the imported packages
+//
+func (w *GoWalker) Externals() map[string][]string {
+	if w.file == nil {
+		return nil
+	}
+
+	packages := map[string]string{} // import path by name
+	for _, imp := range w.file.Imports {
+		ipath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		name := path.Base(ipath)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+
+		if name != "_" && name != "." {
+			packages[name] = ipath
+		}
+	}
+
+	seen := map[string]map[string]bool{}
+	add := func(ipath, name string) {
+		if seen[ipath] == nil {
+			seen[ipath] = map[string]bool{}
+		}
+
+		seen[ipath][name] = true
+	}
+
+	ast.Inspect(w.file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if id, ok := n.X.(*ast.Ident); ok && id.Obj == nil && len(packages[id.Name]) > 0 {
+				add(packages[id.Name], n.Sel.Name)
+			}
+
+		case *ast.CallExpr:
+			if id, ok := n.Fun.(*ast.Ident); ok && id.Obj == nil && builtinFuncs[id.Name] {
+				add(BUILTIN, id.Name)
+			}
+		}
+
+		return true
+	})
+
+	externals := map[string][]string{}
+	for ipath, names := range seen {
+		for name := range names {
+			externals[ipath] = append(externals[ipath], name)
+		}
+
+		sort.Strings(externals[ipath])
+	}
+
+	return externals
+}
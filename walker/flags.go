@@ -0,0 +1,193 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+)
+
+//
+// collectFlags finds the types used as sets of flags: integer types without methods, declared in the file with a block
+// of constants of the type, the first one 1 << iota and the others repeating it (const ( Read Perm = 1 << iota; Write; Exec )).
+// The printers can declare them as enums (see PrintEnum)
+//
+func (w *GoWalker) collectFlags(f *ast.File) {
+	w.flags = map[*ast.TypeSpec]*ast.GenDecl{}
+
+	for _, d := range f.Decls {
+		decl, ok := d.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+
+		spec := flagsType(decl)
+		if spec == nil || w.flags[spec] != nil || len(w.methods[spec.Name.Name]) > 0 {
+			continue
+		}
+
+		w.flags[spec] = decl
+	}
+}
+
+//
+// flagsType returns the type of a block of flag constants (see collectFlags), or nil
+//
+func flagsType(decl *ast.GenDecl) *ast.TypeSpec {
+	if decl.Tok != token.CONST || len(decl.Specs) < 2 {
+		return nil
+	}
+
+	first := decl.Specs[0].(*ast.ValueSpec)
+	if len(first.Names) != 1 || len(first.Values) != 1 || !isShiftIota(first.Values[0]) {
+		return nil
+	}
+
+	id, ok := first.Type.(*ast.Ident)
+	if !ok || id.Obj == nil || id.Obj.Kind != ast.Typ {
+		return nil
+	}
+
+	spec, ok := id.Obj.Decl.(*ast.TypeSpec)
+	if !ok || spec.TypeParams != nil || spec.Assign.IsValid() {
+		return nil
+	}
+
+	if t, ok := spec.Type.(*ast.Ident); !ok || t.Obj != nil || !integerTypes[t.Name] {
+		return nil
+	}
+
+	for _, s := range decl.Specs[1:] {
+		if v := s.(*ast.ValueSpec); len(v.Names) != 1 || v.Type != nil || v.Values != nil {
+			return nil
+		}
+	}
+
+	return spec
+}
+
+//
+// isShiftIota returns true if expr is 1 << iota
+//
+func isShiftIota(expr ast.Expr) bool {
+	b, ok := expr.(*ast.BinaryExpr)
+	if !ok || b.Op != token.SHL {
+		return false
+	}
+
+	one, ok := b.X.(*ast.BasicLit)
+	if !ok || one.Value != "1" {
+		return false
+	}
+
+	id, ok := b.Y.(*ast.Ident)
+	return ok && id.Name == "iota" && id.Obj == nil
+}
+
+//
+// printFlags prints the constants of a type used as a set of flags, with their values (1 << 0, 1 << 1, ...),
+// skipping the blank ones. The comments of the constants are printed before them
+//
+func (w *GoWalker) printFlags(decl *ast.GenDecl, spec *ast.TypeSpec) {
+	var names, values []string
+	var comments []*ast.CommentGroup
+
+	for i, s := range decl.Specs {
+		v := s.(*ast.ValueSpec)
+		w.declareSpec(token.CONST, v)
+
+		if v.Doc != nil && w.comments && hasExported(v.Names) {
+			comments = append(comments, v.Doc) // not returned by nodeComments
+		}
+
+		before, after := w.nodeComments(v)
+		comments = append(append(comments, before...), after...)
+
+		id := v.Names[0]
+		if id.Name == "_" {
+			continue
+		}
+
+		if id.IsExported() {
+			// the specs are not visited (see exportValues)
+			w.exports = append(w.exports, Export{Kind: token.CONST, Name: w.identName(id)})
+		}
+
+		names = append(names, w.identName(id))
+		values = append(values, w.p.FormatBinary("1", "<<", strconv.Itoa(i)))
+	}
+
+	w.printComments(comments)
+	w.p.PrintEnum(w.identName(spec.Name), w.parseExpr(spec.Type), names, values)
+}
+
+// the assignments converting the untyped constants for the types used as sets of flags (see flagsValues)
+var flagsAssign = map[token.Token]bool{
+	token.ASSIGN:         true,
+	token.OR_ASSIGN:      true,
+	token.AND_ASSIGN:     true,
+	token.XOR_ASSIGN:     true,
+	token.AND_NOT_ASSIGN: true,
+}
+
+//
+// flagsValues converts the untyped constants assigned to the variables of the types used as sets of flags
+// (var p Perm = 2, p = 0, p |= 4) to the type, since the enums of C++ don't convert from int (see PrintEnum).
+// types are the types of the variables, for each value (or nil if not known)
+//
+func (w *GoWalker) flagsValues(types, values []ast.Expr) []ast.Expr {
+	var converted []ast.Expr
+
+	for i, v := range values {
+		if i < len(types) && w.isFlagsType(types[i]) && isUntypedConst(v) {
+			id := types[i].(*ast.Ident)
+			v = &ast.CallExpr{Fun: &ast.Ident{NamePos: v.Pos(), Name: id.Name, Obj: id.Obj}, Lparen: v.Pos(), Args: []ast.Expr{v}, Rparen: v.End()}
+		}
+
+		converted = append(converted, v)
+	}
+
+	return converted
+}
+
+//
+// isFlagsType returns true if t is a type used as a set of flags (see collectFlags)
+//
+func (w *GoWalker) isFlagsType(t ast.Expr) bool {
+	id, ok := t.(*ast.Ident)
+	if !ok || id.Obj == nil {
+		return false
+	}
+
+	spec, ok := id.Obj.Decl.(*ast.TypeSpec)
+	return ok && w.flags[spec] != nil
+}
+
+//
+// isUntypedConst returns true for the integer constants without a type: literals, untyped constants
+// and the expressions combining them (1 << 3 | 1)
+//
+func isUntypedConst(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return e.Kind == token.INT || e.Kind == token.CHAR
+
+	case *ast.ParenExpr:
+		return isUntypedConst(e.X)
+
+	case *ast.UnaryExpr:
+		return (e.Op == token.SUB || e.Op == token.ADD || e.Op == token.XOR) && isUntypedConst(e.X)
+
+	case *ast.BinaryExpr:
+		return isUntypedConst(e.X) && isUntypedConst(e.Y)
+
+	case *ast.Ident:
+		if e.Obj == nil || e.Obj.Kind != ast.Con {
+			return false
+		}
+
+		typ, value := declaration(e)
+		return typ == nil && value != nil && isUntypedConst(value)
+	}
+
+	return false
+}
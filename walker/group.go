@@ -0,0 +1,102 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"github.com/raff/walkngo/printer"
+)
+
+//
+// SetGroupDecls enables printing the declarations grouped by kind (imports, types, constants and variables, functions),
+// with the declarations of the functions before the definitions, instead of the source order
+//
+func (w *GoWalker) SetGroupDecls(enable bool) {
+	w.groupDecls = enable
+}
+
+//
+// groupDecls returns the declarations sorted by kind: imports, types, constants and variables, functions.
+// Within each group the declarations are in source order
+//
+func groupDecls(decls []ast.Decl) []ast.Decl {
+	rank := func(d ast.Decl) int {
+		if gen, ok := d.(*ast.GenDecl); ok {
+			switch gen.Tok {
+			case token.IMPORT:
+				return 0
+			case token.TYPE:
+				return 1
+			}
+
+			return 2
+		}
+
+		return 3
+	}
+
+	grouped := append([]ast.Decl{}, decls...)
+	sort.SliceStable(grouped, func(i, j int) bool {
+		return rank(grouped[i]) < rank(grouped[j])
+	})
+
+	return grouped
+}
+
+//
+// printPrototypes prints the declarations of the functions (not the methods, main and init),
+// so that they can be called before their definition
+//
+func (w *GoWalker) printPrototypes(decls []ast.Decl) {
+	printed := false
+
+	for _, d := range decls {
+		fd, ok := d.(*ast.FuncDecl)
+		if !ok || !hasPrototype(fd) {
+			continue
+		}
+
+		if !printed {
+			w.newLine()
+			printed = true
+		}
+
+		w.printPrototype(fd)
+	}
+}
+
+//
+// hasPrototype returns true for the functions that can be declared before their definition
+// (not the methods, the generic functions, main and init)
+//
+func hasPrototype(fd *ast.FuncDecl) bool {
+	if fd.Recv != nil || fd.Body == nil || fd.Type.TypeParams != nil || fd.Name.Name == "main" || fd.Name.Name == "init" {
+		return false
+	}
+
+	prag, _ := parsePragmas(fd.Doc)
+	return !prag.skip && len(prag.inline) == 0
+}
+
+//
+// printPrototype prints the declaration of a function
+//
+func (w *GoWalker) printPrototype(fd *ast.FuncDecl) {
+	prag, _ := parsePragmas(fd.Doc)
+
+	name := w.identName(fd.Name)
+	if len(prag.name) > 0 {
+		name = prag.name
+	}
+
+	w.p.PushContext()
+	w.symbols.Push()
+	w.declareFields(fd.Type.Params)
+	w.declareFields(fd.Type.Results)
+	w.p.PrintPrototype(name,
+		w.parseFieldList(fd.Type.Params, printer.PARAM),
+		w.parseFieldList(fd.Type.Results, printer.RESULT))
+	w.symbols.Pop()
+	w.p.PopContext()
+}
@@ -0,0 +1,134 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/token"
+
+	"github.com/raff/walkngo/printer"
+)
+
+// the integer types, for conversions
+var integerTypes = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true, "rune": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true, "byte": true,
+}
+
+//
+// printRangeInt prints "for i := range n" (Go 1.22) as "for i := 0; i < n; i++",
+// for the targets that can't range over integers
+//
+func (w *GoWalker) printRangeInt(n *ast.RangeStmt) {
+	key, tok := n.Key, n.Tok
+	if id, ok := key.(*ast.Ident); key == nil || ok && id.Name == "_" {
+		key, tok = &ast.Ident{NamePos: n.For, Name: w.names.New("i")}, token.DEFINE
+	}
+
+	init := &ast.AssignStmt{
+		Lhs:    []ast.Expr{key},
+		TokPos: n.TokPos,
+		Tok:    tok,
+		Rhs:    []ast.Expr{&ast.BasicLit{ValuePos: n.X.Pos(), Kind: token.INT, Value: "0"}},
+	}
+	cond := &ast.BinaryExpr{X: key, OpPos: n.X.Pos(), Op: token.LSS, Y: n.X}
+	post := &ast.IncDecStmt{X: key, TokPos: n.X.End(), Tok: token.INC}
+
+	if tok == token.DEFINE {
+		w.checkLoopVars(n.Body, key)
+	}
+
+	w.newLine()
+	w.p.PrintFor(w.BufferVisit(init), w.parseExpr(cond), w.BufferVisit(post))
+	w.p.SameLine()
+	w.visit(n.Body)
+	w.p.Print("\n")
+}
+
+//
+// checkLoopVars reports the closures in the body of a three-clause loop that capture its variables:
+// since Go 1.22 each iteration has its own copy, and the targets without CAP_LOOPVAR share one
+//
+func (w *GoWalker) checkLoopVars(body *ast.BlockStmt, vars ...ast.Expr) {
+	if w.p.Capabilities().Has(printer.CAP_LOOPVAR) {
+		return
+	}
+
+	objs := map[*ast.Object]bool{}
+	for _, v := range vars {
+		if id, ok := v.(*ast.Ident); ok && id.Obj != nil {
+			objs[id.Obj] = true
+		}
+	}
+
+	if len(objs) == 0 {
+		return
+	}
+
+	ast.Inspect(body, func(node ast.Node) bool {
+		lit, ok := node.(*ast.FuncLit)
+		if !ok {
+			return true
+		}
+
+		captured := false
+		ast.Inspect(lit.Body, func(node ast.Node) bool {
+			if id, ok := node.(*ast.Ident); ok && objs[id.Obj] {
+				captured = true
+			}
+
+			return !captured
+		})
+
+		if captured {
+			w.supports(lit, printer.CAP_LOOPVAR)
+		}
+
+		return false // the nested closures are reported with the outer one
+	})
+}
+
+//
+// isInteger returns true if the expression is (likely) an integer.
+// Without type information this looks at literals, conversions, len and cap, and at how the variables were declared
+//
+func isInteger(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return e.Kind == token.INT || e.Kind == token.CHAR
+
+	case *ast.ParenExpr:
+		return isInteger(e.X)
+
+	case *ast.UnaryExpr:
+		return (e.Op == token.SUB || e.Op == token.ADD || e.Op == token.XOR) && isInteger(e.X)
+
+	case *ast.BinaryExpr:
+		switch e.Op {
+		case token.SHL, token.SHR:
+			return isInteger(e.X)
+
+		case token.ADD, token.SUB, token.MUL, token.QUO, token.REM, token.AND, token.OR, token.XOR, token.AND_NOT:
+			return isInteger(e.X) && isInteger(e.Y)
+		}
+
+	case *ast.CallExpr:
+		// len(x), cap(x) or a conversion as int64(x)
+		if fun, ok := e.Fun.(*ast.Ident); ok && fun.Obj == nil {
+			return fun.Name == "len" || fun.Name == "cap" || integerTypes[fun.Name]
+		}
+
+	case *ast.Ident:
+		typ, value := declaration(e)
+		if typ != nil {
+			return isIntegerType(typ)
+		}
+
+		return value != nil && isInteger(value)
+	}
+
+	return false
+}
+
+func isIntegerType(expr ast.Expr) bool {
+	id, ok := expr.(*ast.Ident)
+	return ok && id.Obj == nil && integerTypes[id.Name]
+}
@@ -0,0 +1,73 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/types"
+	"path"
+	"strconv"
+
+	"github.com/raff/walkngo/printer"
+)
+
+//
+// collectImportNames records the names the file uses for the imported packages
+// (they are resolved by the printer and are not mangled)
+//
+func (w *GoWalker) collectImportNames(f *ast.File) {
+	w.importNames = map[string]bool{}
+	w.mangled = map[string]string{}
+
+	for _, imp := range f.Imports {
+		if imp.Name != nil {
+			w.importNames[imp.Name.Name] = true
+		} else if ipath, err := strconv.Unquote(imp.Path.Value); err == nil {
+			w.importNames[path.Base(ipath)] = true
+		}
+	}
+}
+
+//
+// mangle returns the target name for a Go identifier (see Printer.MangleName),
+// recording it if it was renamed
+//
+func (w *GoWalker) mangle(name string) string {
+	mangled := w.p.MangleName(name)
+	if mangled != name {
+		w.mangled[name] = mangled
+	}
+
+	return mangled
+}
+
+//
+// Mangled returns the identifiers of the last file walked that were renamed
+// because they are reserved words in the target language (Go name -> target name)
+//
+func (w *GoWalker) Mangled() map[string]string {
+	return w.mangled
+}
+
+//
+// isPackageName returns true if the identifier refers to an imported package (by its name or its alias),
+// and not to a variable or a type declared in the scopes being visited that shadows it
+//
+func (w *GoWalker) isPackageName(id *ast.Ident) bool {
+	if id.Obj != nil {
+		return false
+	}
+
+	sym := w.symbols.Lookup(id.Name)
+	return sym == nil || sym.Kind == printer.SYM_PACKAGE
+}
+
+//
+// isPredeclared returns true if the identifier refers to a predeclared Go identifier (true, new, delete...)
+// or to an imported package, that the printer converts
+//
+func (w *GoWalker) isPredeclared(id *ast.Ident) bool {
+	if id.Obj != nil {
+		return false
+	}
+
+	return types.Universe.Lookup(id.Name) != nil || w.importNames[id.Name] || (w.cgo && id.Name == "C")
+}
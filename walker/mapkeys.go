@@ -0,0 +1,88 @@
+package walkngo
+
+import (
+	"go/ast"
+)
+
+//
+// collectMapKeys finds the struct types declared in the file and used as map keys (map[Point]int),
+// with the struct types of their fields, that need comparison operators in the targets (see PrintComparable)
+//
+func (w *GoWalker) collectMapKeys(f *ast.File) {
+	w.mapKeys = map[*ast.TypeSpec]bool{}
+
+	var add func(expr ast.Expr)
+	add = func(expr ast.Expr) {
+		spec := structSpec(expr)
+		if spec == nil || w.mapKeys[spec] {
+			return
+		}
+
+		w.mapKeys[spec] = true
+
+		for _, field := range spec.Type.(*ast.StructType).Fields.List {
+			add(field.Type)
+		}
+	}
+
+	ast.Inspect(f, func(node ast.Node) bool {
+		if m, ok := node.(*ast.MapType); ok {
+			add(m.Key)
+		}
+
+		return true
+	})
+}
+
+//
+// structSpec returns the declaration of a struct type, for a type name declared in the file
+//
+func structSpec(expr ast.Expr) *ast.TypeSpec {
+	id, ok := expr.(*ast.Ident)
+	if !ok || id.Obj == nil || id.Obj.Kind != ast.Typ {
+		return nil
+	}
+
+	spec, ok := id.Obj.Decl.(*ast.TypeSpec)
+	if !ok || spec.TypeParams != nil {
+		return nil
+	}
+
+	if _, ok := spec.Type.(*ast.StructType); !ok {
+		return nil
+	}
+
+	return spec
+}
+
+//
+// comparableFields returns the names of the fields compared by ==, in the target (the blank fields are ignored)
+//
+func (w *GoWalker) comparableFields(st *ast.StructType) (fields []string) {
+	fields = []string{}
+
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			// embedded: the name is the type name
+			t := f.Type
+			if star, ok := t.(*ast.StarExpr); ok {
+				t = star.X
+			}
+
+			switch t := t.(type) {
+			case *ast.Ident:
+				fields = append(fields, w.mangle(t.Name))
+			case *ast.SelectorExpr:
+				fields = append(fields, w.mangle(t.Sel.Name))
+			}
+		}
+
+		for _, n := range f.Names {
+			if n.Name != "_" {
+				fields = append(fields, w.mangle(n.Name))
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,62 @@
+package walkngo
+
+import (
+	"go/ast"
+
+	"github.com/raff/walkngo/printer"
+)
+
+//
+// collectMethods records the methods declared in the file, by receiver type name
+//
+func (w *GoWalker) collectMethods(f *ast.File) {
+	w.methods = map[string][]*ast.FuncDecl{}
+
+	for _, d := range f.Decls {
+		if fd, ok := d.(*ast.FuncDecl); ok && fd.Recv != nil && len(fd.Recv.List) > 0 {
+			name := receiverType(fd.Recv.List[0].Type)
+			w.methods[name] = append(w.methods[name], fd)
+		}
+	}
+}
+
+//
+// namedMethods returns the signatures of the methods of a named type that is not an interface
+// (type MyInt int, type Point struct{ X, Y int }), declared in the same file, or nil if there are none
+// (see PrintNamedType and PrintStruct)
+//
+func (w *GoWalker) namedMethods(spec *ast.TypeSpec) (methods []printer.Method) {
+	if _, ok := spec.Type.(*ast.InterfaceType); ok {
+		return nil
+	}
+
+	if spec.TypeParams != nil || spec.Assign.IsValid() {
+		return nil
+	}
+
+	for _, fd := range w.methods[spec.Name.Name] {
+		prag, _ := parsePragmas(fd.Doc)
+		if prag.skip || len(prag.inline) > 0 {
+			continue
+		}
+
+		name := w.mangle(fd.Name.Name)
+		if len(prag.name) > 0 {
+			name = prag.name
+		}
+
+		w.p.PushContext()
+		w.symbols.Push()
+		w.declareFields(fd.Type.Params)
+		w.declareFields(fd.Type.Results)
+		methods = append(methods, printer.Method{
+			Name:    name,
+			Params:  w.parseFieldList(fd.Type.Params, printer.PARAM),
+			Results: w.parseFieldList(fd.Type.Results, printer.RESULT),
+		})
+		w.symbols.Pop()
+		w.p.PopContext()
+	}
+
+	return
+}
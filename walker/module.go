@@ -0,0 +1,327 @@
+package walkngo
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"hash/fnv"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/raff/walkngo/printer"
+)
+
+//
+// Module is a Go module, used to resolve the imports of packages in the same module
+//
+type Module struct {
+	Path     string        // module path, as declared in go.mod
+	Dir      string        // directory containing go.mod
+	Vendor   *VendorFilter // vendored packages to convert (nil to ignore the vendor folder)
+	Protobuf bool          // map the packages of protoc generated code to the C++ generated headers
+
+	flattened map[string]int // number of packages by flattened path, to detect collisions (see Namespace)
+}
+
+//
+// FindModule looks for go.mod in dir and its parents and returns the module it declares,
+// or nil if dir is not in a module
+//
+func FindModule(dir string) (*Module, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			mpath := modulePath(data)
+			if len(mpath) == 0 {
+				return nil, fmt.Errorf("%s: missing module declaration", filepath.Join(dir, "go.mod"))
+			}
+
+			return &Module{Path: mpath, Dir: dir}, nil
+		}
+
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+
+		dir = parent
+	}
+}
+
+//
+// modulePath returns the path in the module directive of a go.mod file
+//
+func modulePath(gomod []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(gomod))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+
+		if p, err := strconv.Unquote(fields[1]); err == nil {
+			return p
+		}
+
+		return fields[1]
+	}
+
+	return ""
+}
+
+//
+// Contains returns true if the import path is a package of the module
+//
+func (m *Module) Contains(ipath string) bool {
+	return ipath == m.Path || strings.HasPrefix(ipath, m.Path+"/")
+}
+
+//
+// PackageDir returns the directory of a package of the module, or of a vendored package
+//
+func (m *Module) PackageDir(ipath string) string {
+	if !m.Contains(ipath) {
+		return filepath.Join(m.Dir, "vendor", filepath.FromSlash(ipath))
+	}
+
+	return filepath.Join(m.Dir, filepath.FromSlash(strings.TrimPrefix(ipath[len(m.Path):], "/")))
+}
+
+//
+// ImportPath returns the import path of the package in dir, or false if dir is not in the module
+//
+func (m *Module) ImportPath(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(m.Dir, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	if rel == "." {
+		return m.Path, true
+	}
+
+	rel = filepath.ToSlash(rel)
+	if strings.HasPrefix(rel, "vendor/") {
+		// vendored package, the import path is the path in the vendor folder
+		return strings.TrimPrefix(rel, "vendor/"), true
+	}
+
+	return path.Join(m.Path, rel), true
+}
+
+//
+// Namespace returns the target namespace for a package of the module.
+// It's the path relative to the module (with '_' as separator) so that packages with the same name
+// in different folders don't collide, and the last element of the module path for the root package.
+// For vendored packages it's the full import path.
+//
+// Packages whose paths are the same once flattened (i.e. "a/b_c" and "a_b/c") get a suffix
+// with the FNV-1a hash of the import path ("a_b_c_1c2d3e4f"), that doesn't depend on the other packages
+//
+func (m *Module) Namespace(ipath string) string {
+	ns := m.flatten(ipath)
+
+	if m.flattened == nil {
+		m.flattened = map[string]int{}
+
+		packages, _ := m.Packages()
+		vendored, _ := m.VendoredPackages()
+
+		seen := map[string]bool{}
+		for _, p := range append(packages, vendored...) {
+			if !seen[p] {
+				seen[p] = true
+				m.flattened[m.flatten(p)]++
+			}
+		}
+	}
+
+	if m.flattened[ns] > 1 {
+		h := fnv.New32a()
+		h.Write([]byte(ipath))
+		ns = fmt.Sprintf("%s_%08x", ns, h.Sum32())
+	}
+
+	return ns
+}
+
+func (m *Module) flatten(ipath string) string {
+	rel := ipath
+	if m.Contains(ipath) {
+		rel = strings.TrimPrefix(ipath[len(m.Path):], "/")
+	}
+
+	if len(rel) == 0 {
+		rel = path.Base(m.Path)
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '.' || r == '-' {
+			return '_'
+		}
+
+		return r
+	}, rel)
+}
+
+//
+// Packages returns the import paths of all the packages in the module
+// (skipping hidden, testdata and vendor folders and nested modules)
+//
+func (m *Module) Packages() ([]string, error) {
+	var packages []string
+
+	err := filepath.Walk(m.Dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			name := info.Name()
+			if p != m.Dir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor") {
+				return filepath.SkipDir
+			}
+
+			if _, err := os.Stat(filepath.Join(p, "go.mod")); p != m.Dir && err == nil {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if strings.HasSuffix(p, ".go") && !strings.HasSuffix(p, "_test.go") {
+			if ipath, ok := m.ImportPath(filepath.Dir(p)); ok && (len(packages) == 0 || packages[len(packages)-1] != ipath) {
+				packages = append(packages, ipath)
+			}
+		}
+
+		return nil
+	})
+
+	return packages, err
+}
+
+//
+// ImportMap returns the import mappings for the packages of the module,
+// so that references to them use the module namespaces (or, with Protobuf, the namespaces
+// of the C++ code generated by protoc for the packages containing only generated code)
+//
+func (m *Module) ImportMap() (printer.ImportMap, error) {
+	packages, err := m.Packages()
+	if err != nil {
+		return nil, err
+	}
+
+	vendored, err := m.VendoredPackages()
+	if err != nil {
+		return nil, err
+	}
+
+	imports := printer.ImportMap{}
+
+	for _, p := range append(packages, vendored...) {
+		if m.Protobuf {
+			if pm, ok := ProtoImportMapping(m.PackageDir(p)); ok {
+				imports[p] = pm
+				continue
+			}
+		}
+
+		ns := m.Namespace(p)
+		imports[p] = printer.ImportMapping{Namespace: ns, Module: ns}
+	}
+
+	return imports, nil
+}
+
+//
+// SetModule enables module aware import resolution: imports of packages in the module
+// (including relative imports) are recorded, to be retrieved with Dependencies
+//
+func (w *GoWalker) SetModule(m *Module) {
+	w.module = m
+}
+
+//
+// resolveImport returns the import path for an import spec, resolving relative imports,
+// and records the imports of packages in the module (and of the vendored packages to convert)
+//
+func (w *GoWalker) resolveImport(spec string, filename string) string {
+	ipath, err := strconv.Unquote(spec)
+	if err != nil || w.module == nil {
+		return spec
+	}
+
+	if strings.HasPrefix(ipath, "./") || strings.HasPrefix(ipath, "../") {
+		if mpath, ok := w.module.ImportPath(filepath.Join(filepath.Dir(filename), ipath)); ok {
+			ipath, spec = mpath, strconv.Quote(mpath)
+		}
+	}
+
+	if w.module.Contains(ipath) || w.module.Vendored(ipath) {
+		if w.deps == nil {
+			w.deps = map[string]bool{}
+		}
+
+		w.deps[w.module.PackageDir(ipath)] = true
+	}
+
+	return spec
+}
+
+//
+// Dependencies returns the folders of the packages of the module imported by the files walked so far
+//
+func (w *GoWalker) Dependencies() []string {
+	deps := make([]string, 0, len(w.deps))
+	for d := range w.deps {
+		deps = append(deps, d)
+	}
+
+	sort.Strings(deps)
+	return deps
+}
+
+//
+// fileNamespace returns the namespace for the declarations of a file:
+// none for package main, the module namespace for packages in the module and the package name otherwise
+//
+func (w *GoWalker) fileNamespace(f *ast.File) string {
+	if f.Name.Name == "main" {
+		return ""
+	}
+
+	if w.module != nil {
+		filename := w.fset.Position(f.Pos()).Filename
+		if ipath, ok := w.module.ImportPath(filepath.Dir(filename)); ok {
+			return w.mangle(w.module.Namespace(ipath))
+		}
+	}
+
+	return w.mangle(f.Name.Name)
+}
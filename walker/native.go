@@ -0,0 +1,294 @@
+package walkngo
+
+import (
+	"bytes"
+	"fmt"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/raff/walkngo/printer"
+)
+
+// C types for the numeric Go types
+var nativeNumbers = map[types.BasicKind]string{
+	types.Int:     "long long",
+	types.Int8:    "signed char",
+	types.Int16:   "short",
+	types.Int32:   "int",
+	types.Int64:   "long long",
+	types.Uint:    "unsigned long long",
+	types.Uint8:   "unsigned char",
+	types.Uint16:  "unsigned short",
+	types.Uint32:  "unsigned int",
+	types.Uint64:  "unsigned long long",
+	types.Uintptr: "unsigned long long",
+	types.Float32: "float",
+	types.Float64: "double",
+}
+
+// cgo names for the C types
+var cgoNames = map[string]string{
+	"long long":          "C.longlong",
+	"signed char":        "C.schar",
+	"short":              "C.short",
+	"int":                "C.int",
+	"unsigned long long": "C.ulonglong",
+	"unsigned char":      "C.uchar",
+	"unsigned short":     "C.ushort",
+	"unsigned int":       "C.uint",
+	"float":              "C.float",
+	"double":             "C.double",
+}
+
+//
+// SetNative sets the import paths of the packages that should be kept as native Go code.
+// The functions used by the converted code can be retrieved with NativeFuncs, to generate
+// a cgo shim (NativeShim) and the target adapter.
+//
+func (w *GoWalker) SetNative(paths ...string) {
+	w.native = map[string]bool{}
+
+	for _, p := range paths {
+		w.native[p] = true
+	}
+}
+
+//
+// useNative records a reference to pkg.name for a native package
+//
+func (w *GoWalker) useNative(path, name string) {
+	if w.nativeUsed == nil {
+		w.nativeUsed = map[string]map[string]bool{}
+	}
+
+	if w.nativeUsed[path] == nil {
+		w.nativeUsed[path] = map[string]bool{}
+	}
+
+	w.nativeUsed[path][name] = true
+}
+
+//
+// NativeFuncs returns the functions of native packages used in the files walked so far,
+// with the signature resolved from the package source.
+//
+// Names that are not functions, or functions with unsupported signatures, are returned as errors.
+//
+func (w *GoWalker) NativeFuncs() (funcs []printer.NativeFunc, errs []error) {
+	imp := importer.ForCompiler(token.NewFileSet(), "source", nil)
+
+	paths := make([]string, 0, len(w.nativeUsed))
+	for p := range w.nativeUsed {
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		pkg, err := imp.Import(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		names := make([]string, 0, len(w.nativeUsed[path]))
+		for n := range w.nativeUsed[path] {
+			names = append(names, n)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			f, err := nativeFunc(pkg, name)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s.%s: %v", path, name, err))
+				continue
+			}
+
+			funcs = append(funcs, f)
+		}
+	}
+
+	return
+}
+
+func nativeFunc(pkg *types.Package, name string) (f printer.NativeFunc, err error) {
+	fn, ok := pkg.Scope().Lookup(name).(*types.Func)
+	if !ok {
+		return f, fmt.Errorf("only functions can be called from native packages")
+	}
+
+	sig := fn.Type().(*types.Signature)
+	if sig.Variadic() {
+		return f, fmt.Errorf("variadic functions are not supported")
+	}
+
+	if sig.Results().Len() > 1 {
+		return f, fmt.Errorf("multiple results are not supported")
+	}
+
+	f = printer.NativeFunc{Package: pkg.Name(), Path: pkg.Path(), Name: name}
+
+	for i := 0; i < sig.Params().Len(); i++ {
+		p := sig.Params().At(i)
+
+		pname := p.Name()
+		if len(pname) == 0 || pname == "_" || pname == "r" || pname == "rlen" {
+			pname = fmt.Sprintf("a%d", i)
+		}
+
+		v, err := nativeValue(p.Type(), pname, false)
+		if err != nil {
+			return f, err
+		}
+
+		f.Params = append(f.Params, v)
+	}
+
+	if sig.Results().Len() == 1 {
+		v, err := nativeValue(sig.Results().At(0).Type(), "", true)
+		if err != nil {
+			return f, err
+		}
+
+		f.Result = &v
+	}
+
+	return f, nil
+}
+
+func nativeValue(t types.Type, name string, result bool) (v printer.NativeValue, err error) {
+	v = printer.NativeValue{Name: name, GoType: t.String()}
+
+	switch t := t.(type) {
+	case *types.Basic:
+		if t.Kind() == types.Bool {
+			v.Kind = printer.NATIVE_BOOL
+			return
+		}
+
+		if t.Kind() == types.String {
+			v.Kind = printer.NATIVE_STRING
+			return
+		}
+
+		if ctype, ok := nativeNumbers[t.Kind()]; ok {
+			v.Kind, v.CType = printer.NATIVE_NUMBER, ctype
+			return
+		}
+
+	case *types.Slice:
+		if isByte(t.Elem()) {
+			v.Kind = printer.NATIVE_BYTES
+			return
+		}
+
+	case *types.Array:
+		if result && isByte(t.Elem()) {
+			v.Kind = printer.NATIVE_BYTES
+			return
+		}
+	}
+
+	return v, fmt.Errorf("unsupported type %s", t)
+}
+
+func isByte(t types.Type) bool {
+	b, ok := t.(*types.Basic)
+	return ok && b.Kind() == types.Byte
+}
+
+//
+// NativeShim returns the source of a Go program that exports (via cgo) the native functions,
+// to be built with "go build -buildmode=c-shared"
+//
+func NativeShim(funcs []printer.NativeFunc) string {
+	var body bytes.Buffer
+
+	imports := map[string]bool{}
+
+	for _, f := range funcs {
+		imports[f.Path] = true
+
+		var params, args []string
+
+		for _, p := range f.Params {
+			switch p.Kind {
+			case printer.NATIVE_NUMBER:
+				params = append(params, fmt.Sprintf("%s %s", p.Name, cgoNames[p.CType]))
+				args = append(args, fmt.Sprintf("%s(%s)", p.GoType, p.Name))
+
+			case printer.NATIVE_BOOL:
+				params = append(params, fmt.Sprintf("%s C.int", p.Name))
+				args = append(args, fmt.Sprintf("%s != 0", p.Name))
+
+			case printer.NATIVE_STRING:
+				params = append(params, fmt.Sprintf("%s *C.char, %s_len C.int", p.Name, p.Name))
+				args = append(args, fmt.Sprintf("C.GoStringN(%s, %s_len)", p.Name, p.Name))
+
+			case printer.NATIVE_BYTES:
+				params = append(params, fmt.Sprintf("%s *C.char, %s_len C.int", p.Name, p.Name))
+				args = append(args, fmt.Sprintf("C.GoBytes(unsafe.Pointer(%s), %s_len)", p.Name, p.Name))
+				imports["unsafe"] = true
+			}
+		}
+
+		call := fmt.Sprintf("%s.%s(%s)", f.Package, f.Name, strings.Join(args, ", "))
+		result := ""
+
+		if f.Result != nil {
+			switch f.Result.Kind {
+			case printer.NATIVE_NUMBER:
+				result = " " + cgoNames[f.Result.CType]
+				call = fmt.Sprintf("return %s(%s)", cgoNames[f.Result.CType], call)
+
+			case printer.NATIVE_BOOL:
+				result = " C.int"
+				call = fmt.Sprintf("if %s {\n\t\treturn 1\n\t}\n\n\treturn 0", call)
+
+			case printer.NATIVE_STRING, printer.NATIVE_BYTES:
+				params = append(params, "rlen *C.int")
+				result = " *C.char"
+
+				value := "r[:]"
+				if f.Result.Kind == printer.NATIVE_STRING {
+					value = "[]byte(r)"
+				}
+
+				call = fmt.Sprintf("r := %s\n\t*rlen = C.int(len(r))\n\treturn (*C.char)(C.CBytes(%s))", call, value)
+			}
+		}
+
+		fmt.Fprintf(&body, "\n//export %s\n", f.Symbol())
+		fmt.Fprintf(&body, "func %s(%s)%s {\n\t%s\n}\n", f.Symbol(), strings.Join(params, ", "), result, call)
+	}
+
+	paths := make([]string, 0, len(imports))
+	for p := range imports {
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	var b bytes.Buffer
+
+	fmt.Fprintln(&b, "// generated by walkngo: cgo shim for packages kept as native Go code")
+	fmt.Fprintln(&b, "// build with: go build -buildmode=c-shared -o libwalkngo_native.so walkngo_native.go")
+	fmt.Fprintln(&b, "package main")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "// #include <stdlib.h>")
+	fmt.Fprintln(&b, `import "C"`)
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "import (")
+	for _, p := range paths {
+		fmt.Fprintf(&b, "\t%q\n", p)
+	}
+	fmt.Fprintln(&b, ")")
+	b.Write(body.Bytes())
+	fmt.Fprintln(&b, "\nfunc main() {}")
+
+	return b.String()
+}
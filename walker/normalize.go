@@ -0,0 +1,375 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/raff/walkngo/printer"
+)
+
+// Normalization is a set of desugaring steps applied to the AST before printing
+type Normalization int
+
+const (
+	NORMALIZE_ASSIGN_OPS  Normalization = 1 << iota // x += y -> x = x + y
+	NORMALIZE_RANGE_CHAN                            // for v := range ch -> for { v, ok := <-ch; if !ok { break }; ... }
+	NORMALIZE_SWITCH_INIT                           // switch init; tag {} -> { init; switch tag {} }
+	NORMALIZE_IF_INIT                               // if init; cond {} -> { init; if cond {} }
+	NORMALIZE_WAITGROUP                             // wg.Add(1); go func() { defer wg.Done(); ... }() -> wg.Go(func() { ... })
+
+	NORMALIZE_NONE Normalization = 0
+	NORMALIZE_ALL                = NORMALIZE_ASSIGN_OPS | NORMALIZE_RANGE_CHAN | NORMALIZE_SWITCH_INIT | NORMALIZE_IF_INIT
+
+	// NORMALIZE_WAITGROUP is not part of NORMALIZE_ALL: WaitGroup.Go requires Go 1.25 (or the C++ runtime)
+)
+
+var normalizationNames = map[string]Normalization{
+	"assign":    NORMALIZE_ASSIGN_OPS,
+	"range":     NORMALIZE_RANGE_CHAN,
+	"switch":    NORMALIZE_SWITCH_INIT,
+	"if":        NORMALIZE_IF_INIT,
+	"waitgroup": NORMALIZE_WAITGROUP,
+	"all":       NORMALIZE_ALL,
+	"none":      NORMALIZE_NONE,
+}
+
+//
+// ParseNormalization converts a comma separated list of names (assign, range, switch, if, waitgroup, all, none)
+// to a Normalization
+//
+func ParseNormalization(list string) (Normalization, bool) {
+	var norm Normalization
+
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+
+		n, ok := normalizationNames[name]
+		if !ok {
+			return norm, false
+		}
+
+		norm |= n
+	}
+
+	return norm, true
+}
+
+//
+// normalizer rewrites the AST of a file so that printers deal with a smaller statement vocabulary
+//
+type normalizer struct {
+	steps Normalization
+	names *printer.Names // for temporary variables
+}
+
+//
+// normalize rewrites the file in place
+//
+func (n *normalizer) normalize(f *ast.File) {
+	if n.steps == NORMALIZE_NONE {
+		return
+	}
+
+	ast.Inspect(f, func(node ast.Node) bool {
+		switch s := node.(type) {
+		case *ast.BlockStmt:
+			s.List = n.normalizeList(s.List)
+
+		case *ast.CaseClause:
+			s.Body = n.normalizeList(s.Body)
+
+		case *ast.CommClause:
+			s.Body = n.normalizeList(s.Body)
+
+		case *ast.IfStmt:
+			if s.Else != nil {
+				s.Else = n.normalizeStmt(s.Else)
+			}
+		}
+
+		return true
+	})
+}
+
+func (n *normalizer) normalizeList(list []ast.Stmt) []ast.Stmt {
+	if n.steps&NORMALIZE_WAITGROUP != 0 {
+		list = waitGroupGo(list)
+	}
+
+	for i, s := range list {
+		list[i] = n.normalizeStmt(s)
+	}
+
+	return list
+}
+
+func (n *normalizer) normalizeStmt(stmt ast.Stmt) ast.Stmt {
+	switch s := stmt.(type) {
+	case *ast.AssignStmt:
+		if n.steps&NORMALIZE_ASSIGN_OPS != 0 && s.Tok >= token.ADD_ASSIGN && s.Tok <= token.AND_NOT_ASSIGN {
+			return &ast.AssignStmt{
+				Lhs:    s.Lhs,
+				TokPos: s.TokPos,
+				Tok:    token.ASSIGN,
+				Rhs: []ast.Expr{&ast.BinaryExpr{
+					X:     s.Lhs[0],
+					OpPos: s.TokPos,
+					Op:    s.Tok - token.ADD_ASSIGN + token.ADD,
+					Y:     parenthesize(s.Rhs[0]),
+				}},
+			}
+		}
+
+	case *ast.RangeStmt:
+		if n.steps&NORMALIZE_RANGE_CHAN != 0 && isChan(s.X) {
+			return n.rangeChan(s)
+		}
+
+	case *ast.SwitchStmt:
+		if n.steps&NORMALIZE_SWITCH_INIT != 0 && s.Init != nil {
+			init := s.Init
+			s.Init = nil
+			return block(s, init, s)
+		}
+
+	case *ast.TypeSwitchStmt:
+		if n.steps&NORMALIZE_SWITCH_INIT != 0 && s.Init != nil {
+			init := s.Init
+			s.Init = nil
+			return block(s, init, s)
+		}
+
+	case *ast.IfStmt:
+		if n.steps&NORMALIZE_IF_INIT != 0 && s.Init != nil {
+			init := s.Init
+			s.Init = nil
+			return block(s, init, s)
+		}
+	}
+
+	return stmt
+}
+
+//
+// rangeChan converts "for k := range ch { body }" to
+//
+//	for {
+//	    k, ok := <-ch
+//	    if !ok {
+//	        break
+//	    }
+//	    body
+//	}
+//
+func (n *normalizer) rangeChan(s *ast.RangeStmt) ast.Stmt {
+	pos := s.Pos()
+
+	ok := ast.NewIdent(n.names.New("ok_"))
+	ok.NamePos = pos
+
+	var recv ast.Stmt
+	var assign ast.Stmt
+
+	key := s.Key
+	if key == nil {
+		key = &ast.Ident{NamePos: pos, Name: "_"}
+	}
+
+	if s.Tok == token.ASSIGN {
+		// assign to existing variable, via a temporary
+		tmp := ast.NewIdent(n.names.New("v_"))
+		tmp.NamePos = pos
+
+		recv = receive(pos, tmp, ok, s.X)
+		assign = &ast.AssignStmt{Lhs: []ast.Expr{key}, TokPos: pos, Tok: token.ASSIGN, Rhs: []ast.Expr{tmp}}
+	} else {
+		recv = receive(pos, key, ok, s.X)
+	}
+
+	check := &ast.IfStmt{
+		If:   pos,
+		Cond: &ast.UnaryExpr{OpPos: pos, Op: token.NOT, X: ok},
+		Body: &ast.BlockStmt{Lbrace: pos, List: []ast.Stmt{&ast.BranchStmt{TokPos: pos, Tok: token.BREAK}}},
+	}
+
+	list := []ast.Stmt{recv, check}
+	if assign != nil {
+		list = append(list, assign)
+	}
+
+	list = append(list, s.Body.List...)
+
+	return &ast.ForStmt{
+		For:  s.For,
+		Body: &ast.BlockStmt{Lbrace: s.Body.Lbrace, List: list, Rbrace: s.Body.Rbrace},
+	}
+}
+
+//
+// waitGroupGo converts the goroutines started as
+//
+//	wg.Add(1)
+//	go func() {
+//	    defer wg.Done()
+//	    body
+//	}()
+//
+// to wg.Go(func() { body }), where the runtime takes care of Add and Done
+//
+func waitGroupGo(list []ast.Stmt) []ast.Stmt {
+	var ret []ast.Stmt
+
+	for i := 0; i < len(list); i++ {
+		if i+1 < len(list) {
+			if stmt := waitGroupStmt(list[i], list[i+1]); stmt != nil {
+				ret = append(ret, stmt)
+				i++
+				continue
+			}
+		}
+
+		ret = append(ret, list[i])
+	}
+
+	return ret
+}
+
+//
+// waitGroupStmt returns wg.Go(func() { body }) if add and next are wg.Add(1) and go func() { defer wg.Done(); body }(),
+// nil otherwise
+//
+func waitGroupStmt(add, next ast.Stmt) ast.Stmt {
+	es, ok := add.(*ast.ExprStmt)
+	if !ok {
+		return nil
+	}
+
+	wg := waitGroupCall(es.X, "Add", "1")
+	if wg == nil {
+		return nil
+	}
+
+	g, ok := next.(*ast.GoStmt)
+	if !ok || len(g.Call.Args) > 0 {
+		return nil
+	}
+
+	lit, ok := g.Call.Fun.(*ast.FuncLit)
+	if !ok || len(lit.Body.List) == 0 {
+		return nil
+	}
+
+	d, ok := lit.Body.List[0].(*ast.DeferStmt)
+	if !ok {
+		return nil
+	}
+
+	if done := waitGroupCall(d.Call, "Done", ""); done == nil || types.ExprString(done) != types.ExprString(wg) {
+		return nil
+	}
+
+	return &ast.ExprStmt{X: &ast.CallExpr{
+		Fun:    &ast.SelectorExpr{X: wg, Sel: &ast.Ident{NamePos: g.Go, Name: "Go"}},
+		Lparen: g.Go,
+		Args: []ast.Expr{&ast.FuncLit{
+			Type: lit.Type,
+			Body: &ast.BlockStmt{Lbrace: lit.Body.Lbrace, List: lit.Body.List[1:], Rbrace: lit.Body.Rbrace},
+		}},
+		Rparen: g.End(),
+	}}
+}
+
+//
+// waitGroupCall returns x for the call x.method(arg) (arg is an integer literal, or empty for no arguments)
+//
+func waitGroupCall(expr ast.Expr, method, arg string) ast.Expr {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != method {
+		return nil
+	}
+
+	if arg == "" {
+		if len(call.Args) == 0 {
+			return sel.X
+		}
+
+		return nil
+	}
+
+	if len(call.Args) == 1 {
+		if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.INT && lit.Value == arg {
+			return sel.X
+		}
+	}
+
+	return nil
+}
+
+//
+// receive returns "v, ok := <-ch"
+//
+func receive(pos token.Pos, v, ok, ch ast.Expr) ast.Stmt {
+	return &ast.AssignStmt{
+		Lhs:    []ast.Expr{v, ok},
+		TokPos: pos,
+		Tok:    token.DEFINE,
+		Rhs:    []ast.Expr{&ast.UnaryExpr{OpPos: pos, Op: token.ARROW, X: ch}},
+	}
+}
+
+//
+// block returns a block statement (positioned as stmt) containing the list of statements
+//
+func block(stmt ast.Stmt, list ...ast.Stmt) *ast.BlockStmt {
+	return &ast.BlockStmt{Lbrace: stmt.Pos(), List: list, Rbrace: stmt.End()}
+}
+
+//
+// parenthesize wraps binary expressions in parenthesis, to preserve precedence when used as an operand
+//
+func parenthesize(expr ast.Expr) ast.Expr {
+	if _, ok := expr.(*ast.BinaryExpr); ok {
+		return &ast.ParenExpr{Lparen: expr.Pos(), X: expr, Rparen: expr.End()}
+	}
+
+	return expr
+}
+
+//
+// isChan returns true if the expression is (likely) a channel.
+// Without type information this looks at how the variable was declared.
+//
+func isChan(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return isChan(e.X)
+
+	case *ast.CallExpr:
+		// make(chan T)
+		if fun, ok := e.Fun.(*ast.Ident); ok && fun.Name == "make" && len(e.Args) > 0 {
+			_, ok := e.Args[0].(*ast.ChanType)
+			return ok
+		}
+
+	case *ast.Ident:
+		typ, value := declaration(e)
+		if typ != nil {
+			_, ok := typ.(*ast.ChanType)
+			return ok
+		}
+
+		return value != nil && isChan(value)
+	}
+
+	return false
+}
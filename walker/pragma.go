@@ -0,0 +1,157 @@
+package walkngo
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+)
+
+const PRAGMA_PREFIX = "//walkngo:"
+
+//
+// pragmas are the directives found in the doc comment of a declaration:
+//
+//	//walkngo:skip               don't convert the declaration
+//	//walkngo:inline-c "code"    emit code (a Go string literal or the rest of the line) instead of the declaration
+//	//walkngo:name foo_bar       rename the declared identifier
+//	//walkngo:header <x.h>       emit #include <x.h> before the declaration
+//
+// inline-c and header are meant for the C++ printer and are emitted as they are.
+//
+type pragmas struct {
+	skip    bool
+	inline  []string
+	name    string
+	headers []string
+}
+
+//
+// pragmas returns the pragmas in a doc comment, recording an error for unknown ones
+//
+func (w *GoWalker) pragmas(doc *ast.CommentGroup) pragmas {
+	p, unknown := parsePragmas(doc)
+
+	for _, c := range unknown {
+		w.addError(c, "unknown pragmas", "unknown pragma %q", c.Text)
+	}
+
+	return p
+}
+
+//
+// parsePragmas collects the pragmas in a doc comment
+//
+func parsePragmas(doc *ast.CommentGroup) (p pragmas, unknown []*ast.Comment) {
+	if doc == nil {
+		return
+	}
+
+	for _, c := range doc.List {
+		if !strings.HasPrefix(c.Text, PRAGMA_PREFIX) {
+			continue
+		}
+
+		directive, arg, _ := strings.Cut(c.Text[len(PRAGMA_PREFIX):], " ")
+		arg = strings.TrimSpace(arg)
+
+		switch directive {
+		case "skip":
+			p.skip = true
+
+		case "inline-c":
+			if code, err := strconv.Unquote(arg); err == nil {
+				arg = code
+			}
+
+			p.inline = append(p.inline, arg)
+
+		case "name":
+			p.name = arg
+
+		case "header":
+			p.headers = append(p.headers, arg)
+
+		default:
+			unknown = append(unknown, c)
+		}
+	}
+
+	return
+}
+
+//
+// applyPragmas prints headers and inline code for a declaration
+// and returns true if the declaration itself should not be converted
+//
+func (w *GoWalker) applyPragmas(p pragmas) bool {
+	if len(p.headers)+len(p.inline) > 0 {
+		w.p.Print("\n")
+	}
+
+	for _, h := range p.headers {
+		w.p.Print("#include " + h + "\n")
+	}
+
+	for _, code := range p.inline {
+		w.p.Print(code + "\n")
+	}
+
+	return p.skip || len(p.inline) > 0
+}
+
+//
+// collectRenames records the identifiers renamed with "//walkngo:name",
+// so that all the references to the declared object are renamed
+//
+func (w *GoWalker) collectRenames(f *ast.File) {
+	w.renames = map[*ast.Object]string{}
+
+	rename := func(doc *ast.CommentGroup, id *ast.Ident) {
+		if p, _ := parsePragmas(doc); len(p.name) > 0 && id.Obj != nil {
+			w.renames[id.Obj] = p.name
+		}
+	}
+
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			rename(d.Doc, d.Name)
+
+		case *ast.GenDecl:
+			for _, s := range d.Specs {
+				switch s := s.(type) {
+				case *ast.TypeSpec:
+					rename(s.Doc, s.Name)
+					if len(d.Specs) == 1 {
+						rename(d.Doc, s.Name)
+					}
+
+				case *ast.ValueSpec:
+					if len(s.Names) == 1 {
+						rename(s.Doc, s.Names[0])
+						if len(d.Specs) == 1 {
+							rename(d.Doc, s.Names[0])
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+//
+// identName returns the name of an identifier, as renamed by pragmas or mangled (see mangle)
+//
+func (w *GoWalker) identName(id *ast.Ident) string {
+	if id.Obj != nil {
+		if name, ok := w.renames[id.Obj]; ok {
+			return name
+		}
+	}
+
+	if w.isPredeclared(id) {
+		return id.Name
+	}
+
+	return w.mangle(id.Name)
+}
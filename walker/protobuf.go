@@ -0,0 +1,259 @@
+package walkngo
+
+//
+// protoc generated code (--protobuf): the .pb.go files are not converted and the packages containing
+// only generated code are mapped to the headers generated by protoc for C++
+//
+
+import (
+	"bufio"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/raff/walkngo/printer"
+)
+
+const (
+	PROTOC_GEN_GO   = "protoc-gen-go"
+	PROTOC_GEN_GRPC = "protoc-gen-go-grpc"
+)
+
+//
+// ProtoPackages are the dependency manager packages for the generated C++ code
+//
+var ProtoPackages = map[string]map[string]string{
+	PROTOC_GEN_GO:   {"conan": "protobuf/3.21.12", "vcpkg": "protobuf"},
+	PROTOC_GEN_GRPC: {"conan": "grpc/1.54.3", "vcpkg": "grpc"},
+}
+
+//
+// protoFile describes a generated Go file
+//
+type protoFile struct {
+	source string // the .proto file (i.e. "foo/v1/foo.proto")
+	pkg    string // the proto package (i.e. "foo.v1"), from the file descriptor
+}
+
+//
+// ProtoGenerator returns the protoc plugin (protoc-gen-go or protoc-gen-go-grpc) that generated the Go file,
+// from the "// Code generated by ... DO NOT EDIT." comment before the package clause, or an empty string
+//
+func ProtoGenerator(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+
+		if !strings.HasPrefix(line, "// Code generated by ") || !strings.HasSuffix(line, "DO NOT EDIT.") {
+			continue
+		}
+
+		if fields := strings.Fields(line); len(fields) > 4 {
+			switch gen := strings.TrimSuffix(fields[4], "."); gen {
+			case PROTOC_GEN_GO, PROTOC_GEN_GRPC:
+				return gen
+			}
+		}
+	}
+
+	return ""
+}
+
+//
+// IsProtoGenerated returns true if the Go file was generated by protoc
+//
+func IsProtoGenerated(path string) bool {
+	return len(ProtoGenerator(path)) > 0
+}
+
+//
+// parseProtoFile returns the .proto file and package of a generated Go file
+//
+func parseProtoFile(path string) (*protoFile, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	pf := &protoFile{}
+
+	for _, cg := range f.Comments {
+		if cg.Pos() > f.Package {
+			break
+		}
+
+		for _, c := range cg.List {
+			if source, ok := strings.CutPrefix(c.Text, "// source: "); ok {
+				pf.source = strings.TrimSpace(source)
+			}
+		}
+	}
+
+	for _, d := range f.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && (gd.Tok == token.CONST || gd.Tok == token.VAR) {
+			for _, s := range gd.Specs {
+				vs := s.(*ast.ValueSpec)
+				if len(vs.Names) == 1 && len(vs.Values) == 1 && strings.HasSuffix(vs.Names[0].Name, "_rawDesc") {
+					pf.pkg = descriptorPackage(rawDescriptor(vs.Values[0]))
+				}
+			}
+		}
+	}
+
+	return pf, nil
+}
+
+//
+// rawDescriptor returns the serialized file descriptor, as a string ("..." + "...")
+// or as a byte slice ([]byte{0x0a, ...}, older versions of protoc-gen-go)
+//
+func rawDescriptor(expr ast.Expr) []byte {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if s, err := strconv.Unquote(e.Value); err == nil {
+			return []byte(s)
+		}
+
+	case *ast.BinaryExpr:
+		if e.Op == token.ADD {
+			return append(rawDescriptor(e.X), rawDescriptor(e.Y)...)
+		}
+
+	case *ast.ParenExpr:
+		return rawDescriptor(e.X)
+
+	case *ast.CompositeLit:
+		var desc []byte
+
+		for _, elt := range e.Elts {
+			lit, ok := elt.(*ast.BasicLit)
+			if !ok {
+				return nil
+			}
+
+			b, err := strconv.ParseUint(lit.Value, 0, 8)
+			if err != nil {
+				return nil
+			}
+
+			desc = append(desc, byte(b))
+		}
+
+		return desc
+	}
+
+	return nil
+}
+
+//
+// descriptorPackage returns the package (field 2) of a serialized FileDescriptorProto
+//
+func descriptorPackage(desc []byte) string {
+	varint := func() (uint64, bool) {
+		var v uint64
+
+		for shift := uint(0); len(desc) > 0 && shift < 64; shift += 7 {
+			b := desc[0]
+			desc = desc[1:]
+
+			v |= uint64(b&0x7f) << shift
+			if b < 0x80 {
+				return v, true
+			}
+		}
+
+		return 0, false
+	}
+
+	for len(desc) > 0 {
+		tag, ok := varint()
+		if !ok || tag&7 != 2 { // name and package are the first fields, both length delimited
+			return ""
+		}
+
+		n, ok := varint()
+		if !ok || n > uint64(len(desc)) {
+			return ""
+		}
+
+		if tag>>3 == 2 {
+			return string(desc[:n])
+		}
+
+		desc = desc[n:]
+	}
+
+	return ""
+}
+
+//
+// ProtoImportMapping returns the mapping of a package containing only protoc generated code
+// to the C++ headers generated by protoc ("foo.pb.h" and "foo.grpc.pb.h" for foo.proto),
+// with the proto package as namespace. It returns false if the package contains hand-written files
+//
+func ProtoImportMapping(dir string) (printer.ImportMapping, bool) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return printer.ImportMapping{}, false
+	}
+
+	var headers []string
+	var ns string
+
+	packages := map[string]string{}
+
+	for _, f := range files {
+		if strings.HasSuffix(f, "_test.go") {
+			continue
+		}
+
+		gen := ProtoGenerator(f)
+		if len(gen) == 0 {
+			return printer.ImportMapping{}, false
+		}
+
+		pf, err := parseProtoFile(f)
+		if err != nil || len(pf.source) == 0 {
+			return printer.ImportMapping{}, false
+		}
+
+		ext := ".pb.h"
+		if gen == PROTOC_GEN_GRPC {
+			ext = ".grpc.pb.h"
+		}
+
+		headers = append(headers, strconv.Quote(strings.TrimSuffix(pf.source, ".proto")+ext))
+
+		if len(pf.pkg) > 0 {
+			ns = strings.ReplaceAll(pf.pkg, ".", "::")
+		}
+
+		for k, v := range ProtoPackages[gen] {
+			if _, ok := packages[k]; !ok || gen == PROTOC_GEN_GRPC { // grpc requires protobuf
+				packages[k] = v
+			}
+		}
+	}
+
+	if len(headers) == 0 {
+		return printer.ImportMapping{}, false
+	}
+
+	sort.Strings(headers)
+	return printer.ImportMapping{Include: headers[0], Includes: headers[1:], Namespace: ns, Packages: packages}, true
+}
@@ -0,0 +1,53 @@
+package walkngo
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"time"
+)
+
+//
+// Provenance describes how the converted files were generated.
+// It's printed as a header at the top of each output file, to track the generated code in a repository:
+//
+//	// Code generated by walkngo 0.1 from foo.go. DO NOT EDIT.
+//	//source: foo.go
+//	//sha256: 9f86d081884c7d65...
+//	//generated: 2024-01-02T15:04:05Z
+//	//command: walkngo --lang=c foo.go
+//
+type Provenance struct {
+	Version string    // walkngo version
+	Command string    // command line used
+	Time    time.Time // generation time (zero to omit it, for reproducible output)
+}
+
+//
+// SetProvenance enables printing the provenance header in the output files (nil to only print the source file)
+//
+func (w *GoWalker) SetProvenance(p *Provenance) {
+	w.provenance = p
+}
+
+//
+// printHeader prints the header of the output file for the source file filename
+//
+func (w *GoWalker) printHeader(filename string, src []byte) {
+	p := w.provenance
+	if p == nil {
+		w.p.Print(fmt.Sprintf("//source: %s\n", filename))
+		return
+	}
+
+	w.p.Print(fmt.Sprintf("// Code generated by walkngo %s from %s. DO NOT EDIT.\n", p.Version, filename))
+	w.p.Print(fmt.Sprintf("//source: %s\n", filename))
+	w.p.Print(fmt.Sprintf("//sha256: %x\n", sha256.Sum256(src)))
+
+	if !p.Time.IsZero() {
+		w.p.Print(fmt.Sprintf("//generated: %s\n", p.Time.UTC().Format(time.RFC3339)))
+	}
+
+	if len(p.Command) > 0 {
+		w.p.Print(fmt.Sprintf("//command: %s\n", p.Command))
+	}
+}
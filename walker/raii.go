@@ -0,0 +1,137 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+//
+// SetRAII enables converting the deferred calls that release the resource acquired by the previous statement
+// (mu.Lock(); defer mu.Unlock(), or f, err := os.Open(name); if err != nil { ... }; defer f.Close()) to RAII guards
+// (see PrintGuard). Only the deferred calls in the body of a function are converted, since the guards are released
+// at the end of the block
+//
+func (w *GoWalker) SetRAII(enable bool) {
+	w.raii = enable
+}
+
+//
+// collectGuards finds the deferred calls to convert to RAII guards (true for the mutexes, see SetRAII)
+//
+func (w *GoWalker) collectGuards(f *ast.File) {
+	w.guards = map[*ast.DeferStmt]bool{}
+
+	if !w.raii {
+		return
+	}
+
+	ast.Inspect(f, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncDecl:
+			if n.Body != nil {
+				w.findGuards(n.Body.List)
+			}
+
+		case *ast.FuncLit:
+			w.findGuards(n.Body.List)
+		}
+
+		return true
+	})
+}
+
+func (w *GoWalker) findGuards(list []ast.Stmt) {
+	for i, s := range list {
+		d, ok := s.(*ast.DeferStmt)
+		if !ok || i == 0 || len(d.Call.Args) > 0 {
+			continue
+		}
+
+		sel, ok := d.Call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			continue
+		}
+
+		prev := list[i-1]
+
+		switch sel.Sel.Name {
+		case "Unlock":
+			if isMethodCall(prev, sel.X, "Lock") {
+				w.guards[d] = true
+			}
+
+		case "RUnlock":
+			if isMethodCall(prev, sel.X, "RLock") {
+				w.guards[d] = false
+			}
+
+		case "Close":
+			if isErrCheck(prev) && i > 1 {
+				prev = list[i-2]
+			}
+
+			if isAcquired(prev, sel.X) {
+				w.guards[d] = false
+			}
+		}
+	}
+}
+
+//
+// isMethodCall returns true if stmt is recv.method()
+//
+func isMethodCall(stmt ast.Stmt, recv ast.Expr, method string) bool {
+	s, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return false
+	}
+
+	call, ok := s.X.(*ast.CallExpr)
+	if !ok || len(call.Args) > 0 {
+		return false
+	}
+
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == method && types.ExprString(sel.X) == types.ExprString(recv)
+}
+
+//
+// isErrCheck returns true if stmt is if err != nil { ... }
+//
+func isErrCheck(stmt ast.Stmt) bool {
+	s, ok := stmt.(*ast.IfStmt)
+	if !ok || s.Init != nil || s.Else != nil {
+		return false
+	}
+
+	cond, ok := s.Cond.(*ast.BinaryExpr)
+	if !ok || cond.Op != token.NEQ {
+		return false
+	}
+
+	id, ok := cond.Y.(*ast.Ident)
+	return ok && id.Name == "nil"
+}
+
+//
+// isAcquired returns true if stmt assigns the result of a call to recv (f, err := os.Open(name))
+//
+func isAcquired(stmt ast.Stmt, recv ast.Expr) bool {
+	s, ok := stmt.(*ast.AssignStmt)
+	if !ok || len(s.Rhs) != 1 {
+		return false
+	}
+
+	if _, ok := s.Rhs[0].(*ast.CallExpr); !ok {
+		return false
+	}
+
+	for _, lhs := range s.Lhs {
+		if types.ExprString(lhs) == types.ExprString(recv) {
+			return true
+		}
+	}
+
+	return false
+}
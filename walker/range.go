@@ -0,0 +1,125 @@
+package walkngo
+
+import (
+	"fmt"
+	"go/ast"
+	"io"
+)
+
+//
+// Range is a range of a source file (the selection or the cursor in an editor), with lines and columns
+// starting from 1 as in the positions of the diagnostics. An empty end is the same as the start.
+//
+type Range struct {
+	Line      int `json:"line"`
+	Column    int `json:"column"`
+	EndLine   int `json:"endLine,omitempty"`
+	EndColumn int `json:"endColumn,omitempty"`
+}
+
+func (r Range) String() string {
+	return fmt.Sprintf("%d:%d", r.Line, r.Column)
+}
+
+//
+// WalkRange converts the Go source src, as if read from filename, printing only the translation
+// of the top level declaration containing r (without the package, imports and headers).
+// The rest of the file is still used to resolve the names, and only the problems in the declaration are returned.
+//
+func (w *GoWalker) WalkRange(filename string, src []byte, r Range) error {
+	w.reset(filename)
+
+	pf, err := w.Parse(filename, src)
+	if err != nil {
+		return err
+	}
+
+	f := pf.File
+
+	start, end, ok := w.rangeOffsets(f, r)
+	if !ok {
+		return fmt.Errorf("%s:%s: invalid range", filename, r)
+	}
+
+	var decl ast.Decl
+	for _, d := range f.Decls {
+		from := d.Pos()
+		if fd, ok := d.(*ast.FuncDecl); ok && fd.Doc != nil {
+			from = fd.Doc.Pos()
+		} else if gd, ok := d.(*ast.GenDecl); ok && gd.Doc != nil {
+			from = gd.Doc.Pos()
+		}
+
+		if w.fset.Position(from).Offset <= start && end <= w.fset.Position(d.End()).Offset {
+			decl = d
+			break
+		}
+	}
+
+	if decl == nil {
+		return fmt.Errorf("%s:%s: no declaration in range", filename, r)
+	}
+
+	w.Analyze(pf)
+
+	w.setOutput(io.Discard) // only the package name is needed, not the prologue
+	w.p.PrintPackage(f.Name.Name)
+	w.setOutput(w.pipe)
+
+	w.parents = []ast.Node{f}
+	w.visit(decl)
+
+	w.Flush()
+	w.addSyntaxErrors()
+
+	// the problems in the other declarations (syntax errors) are not relevant
+	from, to := w.fset.Position(decl.Pos()).Line, w.fset.Position(decl.End()).Line
+
+	var errors WalkErrors
+	for _, e := range w.errors {
+		if !e.Pos.IsValid() || (from <= e.Pos.Line && e.Pos.Line <= to) {
+			errors = append(errors, e)
+		}
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+//
+// rangeOffsets returns the offsets in the source of the start and the end of r
+//
+func (w *GoWalker) rangeOffsets(f *ast.File, r Range) (start, end int, ok bool) {
+	tf := w.fset.File(f.Pos())
+
+	offset := func(line, column int) (int, bool) {
+		if line < 1 || line > tf.LineCount() || column < 1 {
+			return 0, false
+		}
+
+		lineStart := tf.Offset(tf.LineStart(line))
+		lineEnd := tf.Size()
+		if line < tf.LineCount() {
+			lineEnd = tf.Offset(tf.LineStart(line+1)) - 1
+		}
+
+		return min(lineStart+column-1, lineEnd), true
+	}
+
+	if r.EndLine == 0 {
+		r.EndLine, r.EndColumn = r.Line, r.Column
+	}
+
+	if start, ok = offset(r.Line, r.Column); !ok {
+		return
+	}
+
+	if end, ok = offset(r.EndLine, max(r.EndColumn, 1)); !ok || end < start {
+		return 0, 0, false
+	}
+
+	return start, end, true
+}
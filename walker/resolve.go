@@ -0,0 +1,379 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+//
+// resolver sets the objects of the identifiers (ast.Ident.Obj) like go/parser does, for the files parsed with
+// parser.SkipObjectResolution: go/parser gives up on the files with more than 1000 nested scopes, like the long
+// else if chains of the generated parsers and lexers (see parseFile). The package level declarations are declared
+// first, the imported packages, the builtins and the fields are not resolved (Obj is nil) and File.Unresolved is not set
+//
+type resolver struct {
+	scope *ast.Scope
+}
+
+func resolveObjects(f *ast.File) {
+	r := &resolver{scope: ast.NewScope(nil)}
+	f.Scope = r.scope
+
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.GenDecl:
+			for i, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.ValueSpec:
+					r.declare(valueKind(d.Tok), s, i, s.Names...)
+
+				case *ast.TypeSpec:
+					r.declare(ast.Typ, s, nil, s.Name)
+				}
+			}
+
+		case *ast.FuncDecl:
+			if d.Recv == nil && d.Name.Name != "init" {
+				r.declare(ast.Fun, d, nil, d.Name)
+			}
+		}
+	}
+
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.ValueSpec:
+					r.expr(s.Type)
+					r.exprs(s.Values)
+
+				case *ast.TypeSpec:
+					r.open()
+					r.declareFields(ast.Typ, s.TypeParams)
+					r.expr(s.Type)
+					r.close()
+				}
+			}
+
+		case *ast.FuncDecl:
+			r.open()
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				r.receiverParams(d.Recv.List[0])
+			}
+			r.declareFields(ast.Typ, d.Type.TypeParams)
+			r.funcType(d.Type, d.Recv)
+			if d.Body != nil {
+				r.stmts(d.Body.List)
+			}
+			r.close()
+		}
+	}
+}
+
+func valueKind(tok token.Token) ast.ObjKind {
+	if tok == token.CONST {
+		return ast.Con
+	}
+
+	return ast.Var
+}
+
+func (r *resolver) open() {
+	r.scope = ast.NewScope(r.scope)
+}
+
+func (r *resolver) close() {
+	r.scope = r.scope.Outer
+}
+
+func (r *resolver) declare(kind ast.ObjKind, decl, data any, names ...*ast.Ident) {
+	for _, id := range names {
+		if id.Name == "_" {
+			continue
+		}
+
+		obj := ast.NewObj(kind, id.Name)
+		obj.Decl, obj.Data = decl, data
+		r.scope.Insert(obj)
+		id.Obj = obj
+	}
+}
+
+func (r *resolver) declareFields(kind ast.ObjKind, fields *ast.FieldList) {
+	if fields == nil {
+		return
+	}
+
+	for _, f := range fields.List {
+		r.declare(kind, f, nil, f.Names...)
+		r.expr(f.Type)
+	}
+}
+
+//
+// receiverParams declares the type parameters of a generic receiver (func (l *List[T]) ...)
+//
+func (r *resolver) receiverParams(recv *ast.Field) {
+	t := recv.Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+
+	var params []ast.Expr
+	switch x := t.(type) {
+	case *ast.IndexExpr:
+		params = []ast.Expr{x.Index}
+	case *ast.IndexListExpr:
+		params = x.Indices
+	}
+
+	for _, p := range params {
+		if id, ok := p.(*ast.Ident); ok {
+			r.declare(ast.Typ, recv, nil, id)
+		}
+	}
+}
+
+//
+// funcType resolves the types of receiver, parameters and results and declares their names (in the current scope)
+//
+func (r *resolver) funcType(ftype *ast.FuncType, recv *ast.FieldList) {
+	for _, fields := range []*ast.FieldList{recv, ftype.Params, ftype.Results} {
+		if fields != nil {
+			for _, f := range fields.List {
+				r.expr(f.Type)
+			}
+		}
+	}
+
+	for _, fields := range []*ast.FieldList{recv, ftype.Params, ftype.Results} {
+		if fields != nil {
+			for _, f := range fields.List {
+				r.declare(ast.Var, f, nil, f.Names...)
+			}
+		}
+	}
+}
+
+func (r *resolver) exprs(list []ast.Expr) {
+	for _, e := range list {
+		r.expr(e)
+	}
+}
+
+func (r *resolver) expr(expr ast.Expr) {
+	if expr == nil {
+		return
+	}
+
+	ast.Inspect(expr, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Ident:
+			if obj := r.lookup(n.Name); obj != nil {
+				n.Obj = obj
+			}
+
+		case *ast.SelectorExpr:
+			r.expr(n.X)
+			return false
+
+		case *ast.FuncLit:
+			r.open()
+			r.funcType(n.Type, nil)
+			r.stmts(n.Body.List)
+			r.close()
+			return false
+
+		case *ast.FuncType:
+			r.open()
+			r.declareFields(ast.Typ, n.TypeParams)
+			r.funcType(n, nil)
+			r.close()
+			return false
+
+		case *ast.Field:
+			// struct fields and interface methods: only the types are resolved
+			r.expr(n.Type)
+			return false
+		}
+
+		return true
+	})
+}
+
+func (r *resolver) lookup(name string) *ast.Object {
+	for s := r.scope; s != nil; s = s.Outer {
+		if obj := s.Lookup(name); obj != nil {
+			return obj
+		}
+	}
+
+	return nil
+}
+
+func (r *resolver) stmts(list []ast.Stmt) {
+	for _, s := range list {
+		r.stmt(s)
+	}
+}
+
+func (r *resolver) stmt(stmt ast.Stmt) {
+	switch s := stmt.(type) {
+	case *ast.BlockStmt:
+		r.open()
+		r.stmts(s.List)
+		r.close()
+
+	case *ast.DeclStmt:
+		d, ok := s.Decl.(*ast.GenDecl)
+		if !ok {
+			break
+		}
+
+		for i, spec := range d.Specs {
+			switch sp := spec.(type) {
+			case *ast.ValueSpec:
+				r.exprs(sp.Values)
+				r.expr(sp.Type)
+				r.declare(valueKind(d.Tok), sp, i, sp.Names...)
+
+			case *ast.TypeSpec:
+				r.declare(ast.Typ, sp, nil, sp.Name)
+				r.open()
+				r.declareFields(ast.Typ, sp.TypeParams)
+				r.expr(sp.Type)
+				r.close()
+			}
+		}
+
+	case *ast.AssignStmt:
+		r.exprs(s.Rhs)
+		if s.Tok == token.DEFINE {
+			r.define(s, s.Lhs)
+		} else {
+			r.exprs(s.Lhs)
+		}
+
+	case *ast.LabeledStmt:
+		r.stmt(s.Stmt)
+
+	case *ast.ExprStmt:
+		r.expr(s.X)
+
+	case *ast.SendStmt:
+		r.expr(s.Chan)
+		r.expr(s.Value)
+
+	case *ast.IncDecStmt:
+		r.expr(s.X)
+
+	case *ast.GoStmt:
+		r.expr(s.Call)
+
+	case *ast.DeferStmt:
+		r.expr(s.Call)
+
+	case *ast.ReturnStmt:
+		r.exprs(s.Results)
+
+	case *ast.IfStmt:
+		r.open()
+		if s.Init != nil {
+			r.stmt(s.Init)
+		}
+		r.expr(s.Cond)
+		r.stmt(s.Body)
+		if s.Else != nil {
+			r.stmt(s.Else)
+		}
+		r.close()
+
+	case *ast.CaseClause:
+		r.exprs(s.List)
+		r.open()
+		r.stmts(s.Body)
+		r.close()
+
+	case *ast.SwitchStmt:
+		r.open()
+		if s.Init != nil {
+			r.stmt(s.Init)
+		}
+		r.expr(s.Tag)
+		r.stmts(s.Body.List)
+		r.close()
+
+	case *ast.TypeSwitchStmt:
+		r.open()
+		if s.Init != nil {
+			r.stmt(s.Init)
+		}
+		r.stmt(s.Assign)
+		r.stmts(s.Body.List)
+		r.close()
+
+	case *ast.CommClause:
+		r.open()
+		if s.Comm != nil {
+			r.stmt(s.Comm)
+		}
+		r.stmts(s.Body)
+		r.close()
+
+	case *ast.SelectStmt:
+		r.stmts(s.Body.List)
+
+	case *ast.ForStmt:
+		r.open()
+		if s.Init != nil {
+			r.stmt(s.Init)
+		}
+		r.expr(s.Cond)
+		if s.Post != nil {
+			r.stmt(s.Post)
+		}
+		r.stmt(s.Body)
+		r.close()
+
+	case *ast.RangeStmt:
+		r.open()
+		r.expr(s.X)
+
+		var lhs []ast.Expr
+		for _, e := range []ast.Expr{s.Key, s.Value} {
+			if e != nil {
+				lhs = append(lhs, e)
+			}
+		}
+
+		if s.Tok == token.DEFINE {
+			// the declaration is an assignment from the range, like for go/parser
+			r.define(&ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, TokPos: s.TokPos, Rhs: []ast.Expr{&ast.UnaryExpr{Op: token.RANGE, X: s.X}}}, lhs)
+		} else {
+			r.exprs(lhs)
+		}
+
+		r.stmt(s.Body)
+		r.close()
+	}
+}
+
+//
+// define declares the new variables of a short variable declaration (the others are resolved)
+//
+func (r *resolver) define(decl *ast.AssignStmt, lhs []ast.Expr) {
+	for _, e := range lhs {
+		id, ok := e.(*ast.Ident)
+		if !ok {
+			r.expr(e)
+			continue
+		}
+
+		if obj := r.scope.Lookup(id.Name); obj != nil {
+			id.Obj = obj
+		} else {
+			r.declare(ast.Var, decl, nil, id)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/parser"
+	"io"
+	"strings"
+)
+
+// the name of the source file for the snippets (in the positions of the errors)
+const SNIPPET = "snippet"
+
+//
+// WalkSnippet converts a snippet of Go code, printing only its translation (without the package, imports and headers).
+// The snippet can be a list of statements, converted as the body of a function, or a list of declarations:
+//
+//	x := []int{1, 2}; for _, v := range x { println(v) }
+//	func add(a, b int) int { return a + b }
+//
+// The snippet can use the standard packages without importing them (they are resolved by name, as fmt or strings)
+//
+func (w *GoWalker) WalkSnippet(code string) error {
+	w.reset(SNIPPET)
+
+	// the line directive makes the positions relative to the snippet
+	src := []byte("package main\n\nfunc main() {\n//line " + SNIPPET + ":1:1\n" + code + "\n}\n")
+	f, err := parser.ParseFile(w.fset, SNIPPET, src, parser.ParseComments)
+	stmts := err == nil
+
+	if !stmts {
+		w.reset(SNIPPET)
+
+		src = []byte("package main\n\n//line " + SNIPPET + ":1:1\n" + code + "\n")
+
+		var derr error
+		if f, derr = parser.ParseFile(w.fset, SNIPPET, src, parser.ParseComments); derr != nil {
+			if isDeclaration(code) {
+				return derr
+			}
+
+			return err // report the errors as statements
+		}
+	}
+
+	w.prepare(f, src, true)
+
+	w.setOutput(io.Discard) // only the package name is needed, not the prologue
+	w.p.PrintPackage(f.Name.Name)
+	w.setOutput(w.pipe)
+
+	if stmts {
+		main := f.Decls[0].(*ast.FuncDecl)
+
+		w.parents = []ast.Node{f, main, main.Body}
+		w.p.PushContext()
+		w.symbols.Push()
+
+		for _, s := range main.Body.List {
+			w.visit(s)
+		}
+
+		w.symbols.Pop()
+		w.p.PopContext()
+	} else {
+		w.parents = []ast.Node{f}
+
+		for _, d := range f.Decls {
+			w.visit(d)
+		}
+	}
+
+	w.Flush()
+	w.addSyntaxErrors()
+
+	if len(w.errors) > 0 {
+		return w.errors
+	}
+
+	return nil
+}
+
+//
+// isDeclaration returns true if the snippet starts with a keyword that can only introduce a top level declaration
+//
+func isDeclaration(code string) bool {
+	for _, keyword := range []string{"package", "import", "func"} {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(code), keyword); ok && (len(rest) == 0 || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '(') {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,71 @@
+package walkngo
+
+import (
+	"go/ast"
+	"strings"
+
+	"github.com/raff/walkngo/printer"
+)
+
+//
+// SetSourceComments enables printing the original Go source of each statement and declaration
+// as a comment above its translation
+//
+func (w *GoWalker) SetSourceComments(enable bool) {
+	w.sourceComments = enable
+}
+
+//
+// printSource prints the source of node as a comment.
+// Only the first line is printed for statements containing a body (that will print their own comments).
+//
+func (w *GoWalker) printSource(node ast.Node) {
+	switch node.(type) {
+	case *ast.BlockStmt, *ast.EmptyStmt:
+		return
+
+	case ast.Stmt, ast.Decl:
+		// ok
+
+	default:
+		return
+	}
+
+	start := w.fset.PositionFor(node.Pos(), false)
+	end := w.fset.PositionFor(node.End(), false)
+
+	if !start.IsValid() || !end.IsValid() || end.Offset > len(w.src) || start.Offset >= end.Offset {
+		// synthetic (normalized) nodes
+		return
+	}
+
+	// the indentation of the first line, removed from the following ones
+	indent := string(w.src[start.Offset-start.Column+1 : start.Offset])
+	lines := strings.Split(string(w.src[start.Offset:end.Offset]), "\n")
+
+	switch node.(type) {
+	case *ast.FuncDecl, *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt,
+		*ast.SelectStmt, *ast.CaseClause, *ast.CommClause, *ast.LabeledStmt:
+		lines = lines[:1]
+	}
+
+	w.emptyLineBefore(node)
+
+	for _, line := range lines {
+		w.p.PrintLevel(printer.NONE, "//", strings.TrimRight(strings.TrimPrefix(line, indent), " \t"))
+		w.p.Print("\n")
+	}
+}
+
+//
+// emptyLineBefore prints the empty line preceding declarations and compound statements,
+// before the comments above them
+//
+func (w *GoWalker) emptyLineBefore(node ast.Node) {
+	switch node.(type) {
+	case *ast.FuncDecl, *ast.GenDecl, *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt,
+		*ast.LabeledStmt:
+		w.newLine()
+		w.lineDone = true
+	}
+}
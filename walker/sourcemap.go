@@ -0,0 +1,25 @@
+package walkngo
+
+import (
+	"github.com/raff/walkngo/printer"
+)
+
+//
+// SetSourceMap enables recording the Go source position of each line of the output (see SourceMap)
+//
+func (w *GoWalker) SetSourceMap(enable bool) {
+	w.smap = nil
+	if enable {
+		w.smap = &printer.SourceMap{}
+	}
+
+	w.pipe.SetSourceMap(w.smap)
+}
+
+//
+// SourceMap returns the source map of the last file walked, or nil if not enabled.
+// The generated file name (SourceMap.File) is left to the caller
+//
+func (w *GoWalker) SourceMap() *printer.SourceMap {
+	return w.smap
+}
@@ -0,0 +1,14 @@
+package walkngo
+
+import (
+	"go/ast"
+)
+
+//
+// isString returns true if the expression is (likely) a string, indexed as bytes (see FormatStringIndex).
+// Without type information this looks at how the variables and the fields were declared.
+//
+func isString(expr ast.Expr) bool {
+	id, ok := exprType(expr).(*ast.Ident)
+	return ok && id.Name == "string" && id.Obj == nil
+}
@@ -0,0 +1,102 @@
+package walkngo
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+//
+// Summary collects the constructs that were not converted in a run (see WalkError.Construct),
+// counting them by construct and by file, to gauge the porting effort
+//
+type Summary struct {
+	counts map[string]int            // occurrences by construct
+	files  map[string]map[string]int // occurrences by construct and file
+}
+
+//
+// Add records the errors of a converted file
+//
+func (s *Summary) Add(filename string, errs WalkErrors) {
+	if s.counts == nil {
+		s.counts = map[string]int{}
+		s.files = map[string]map[string]int{}
+	}
+
+	for _, e := range errs {
+		construct := e.Construct
+		if len(construct) == 0 {
+			construct = "other errors"
+		}
+
+		if s.files[construct] == nil {
+			s.files[construct] = map[string]int{}
+		}
+
+		s.counts[construct]++
+		s.files[construct][filename]++
+	}
+}
+
+//
+// Len returns the number of constructs not converted
+//
+func (s *Summary) Len() int {
+	n := 0
+	for _, c := range s.counts {
+		n += c
+	}
+
+	return n
+}
+
+//
+// Write prints the summary, the most frequent constructs first, with the files containing them:
+//
+//	not translated: 12x select statements, 3x generics
+//	  select statements: a.go (10), b.go (2)
+//	  generics: c.go (3)
+//
+func (s *Summary) Write(w io.Writer) {
+	if s.Len() == 0 {
+		return
+	}
+
+	constructs := make([]string, 0, len(s.counts))
+	for c := range s.counts {
+		constructs = append(constructs, c)
+	}
+
+	sort.Slice(constructs, func(i, j int) bool {
+		ci, cj := constructs[i], constructs[j]
+		if s.counts[ci] != s.counts[cj] {
+			return s.counts[ci] > s.counts[cj]
+		}
+
+		return ci < cj
+	})
+
+	totals := make([]string, len(constructs))
+	for i, c := range constructs {
+		totals[i] = fmt.Sprintf("%dx %s", s.counts[c], c)
+	}
+
+	fmt.Fprintf(w, "not translated: %s\n", strings.Join(totals, ", "))
+
+	for _, c := range constructs {
+		files := make([]string, 0, len(s.files[c]))
+		for f := range s.files[c] {
+			files = append(files, f)
+		}
+
+		sort.Strings(files)
+
+		for i, f := range files {
+			files[i] = fmt.Sprintf("%s (%d)", f, s.files[c][f])
+		}
+
+		fmt.Fprintf(w, "  %s: %s\n", c, strings.Join(files, ", "))
+	}
+}
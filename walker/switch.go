@@ -0,0 +1,248 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+
+	"github.com/raff/walkngo/printer"
+)
+
+//
+// isNativeSwitch returns true if the switch can be printed as it is: the target has CAP_SWITCH_ANY,
+// or the tag is an integer (or all the values are integer or character literals)
+//
+func (w *GoWalker) isNativeSwitch(n *ast.SwitchStmt) bool {
+	if w.p.Capabilities().Has(printer.CAP_SWITCH_ANY) {
+		return true
+	}
+
+	if n.Tag == nil {
+		return false
+	}
+
+	if isInteger(n.Tag) {
+		return true
+	}
+
+	for _, s := range n.Body.List {
+		for _, e := range s.(*ast.CaseClause).List {
+			if !isIntegerConst(e) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+//
+// isIntegerConst returns true for integer and character literals, and for the constants declared in the file
+// with an integer literal or iota (const ( A = iota; B; C ))
+//
+func isIntegerConst(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return e.Kind == token.INT || e.Kind == token.CHAR
+
+	case *ast.Ident:
+		if e.Obj == nil || e.Obj.Kind != ast.Con {
+			return false
+		}
+
+		_, v := declaration(e)
+		if v == nil {
+			return true // repeats the previous expression, usually iota
+		}
+
+		if id, ok := v.(*ast.Ident); ok && id.Name == "iota" {
+			return true
+		}
+
+		if b, ok := v.(*ast.BinaryExpr); ok {
+			if id, ok := b.X.(*ast.Ident); ok && id.Name == "iota" {
+				return true // iota + 1, 1 << iota
+			}
+		}
+
+		return isIntegerConst(v)
+	}
+
+	return false
+}
+
+//
+// caseIndexSwitch converts a switch on strings (or any other value), or without tag, to a switch on the index of the
+// matching case, for the targets without CAP_SWITCH_ANY:
+//
+//	switch tag {                       switch func() int {
+//	case "a", "b":                         t := tag
+//	    ...                                if t == "a" || t == "b" { return 0 }
+//	case "c":                  ->          if t == "c" { return 1 }
+//	    fallthrough                        return -1
+//	default:                           }() {
+//	    ...                            case 0: ...
+//	}                                  case 1: fallthrough
+//	                                   default: ...
+//	                                   }
+//
+// The tag is evaluated once and the cases in order, and break, continue and fallthrough keep working.
+//
+func (w *GoWalker) caseIndexSwitch(n *ast.SwitchStmt) *ast.SwitchStmt {
+	pos := n.Switch
+
+	var tag ast.Expr
+	var list []ast.Stmt
+
+	if n.Tag != nil {
+		tag = &ast.Ident{NamePos: pos, Name: w.names.New("tag")}
+		list = append(list, &ast.AssignStmt{Lhs: []ast.Expr{tag}, TokPos: pos, Tok: token.DEFINE, Rhs: []ast.Expr{n.Tag}})
+	}
+
+	var clauses []ast.Stmt
+
+	for _, s := range n.Body.List {
+		clause := s.(*ast.CaseClause)
+		if clause.List == nil {
+			clauses = append(clauses, clause) // default
+			continue
+		}
+
+		index := &ast.BasicLit{ValuePos: clause.Case, Kind: token.INT, Value: strconv.Itoa(len(clauses))}
+
+		var cond ast.Expr
+		for _, e := range clause.List {
+			if tag != nil {
+				e = &ast.BinaryExpr{X: tag, OpPos: e.Pos(), Op: token.EQL, Y: e}
+			} else {
+				e = parenthesize(e)
+			}
+
+			if cond == nil {
+				cond = e
+			} else {
+				cond = &ast.BinaryExpr{X: cond, OpPos: e.Pos(), Op: token.LOR, Y: e}
+			}
+		}
+
+		list = append(list, &ast.IfStmt{
+			If:   clause.Case,
+			Cond: cond,
+			Body: &ast.BlockStmt{Lbrace: clause.Case, List: []ast.Stmt{&ast.ReturnStmt{Return: clause.Case, Results: []ast.Expr{index}}}},
+		})
+
+		clauses = append(clauses, &ast.CaseClause{Case: clause.Case, List: []ast.Expr{index}, Colon: clause.Colon, Body: clause.Body})
+	}
+
+	list = append(list, &ast.ReturnStmt{Return: n.Body.Rbrace, Results: []ast.Expr{&ast.BasicLit{ValuePos: n.Body.Rbrace, Kind: token.INT, Value: "-1"}}})
+
+	index := &ast.CallExpr{
+		Fun: &ast.FuncLit{
+			Type: &ast.FuncType{
+				Func:    pos,
+				Params:  &ast.FieldList{},
+				Results: &ast.FieldList{List: []*ast.Field{{Type: &ast.Ident{NamePos: pos, Name: "int"}}}},
+			},
+			Body: &ast.BlockStmt{Lbrace: pos, List: list, Rbrace: pos},
+		},
+		Lparen: pos,
+		Rparen: pos,
+	}
+
+	return &ast.SwitchStmt{
+		Switch: n.Switch,
+		Init:   n.Init,
+		Tag:    index,
+		Body:   &ast.BlockStmt{Lbrace: n.Body.Lbrace, List: clauses, Rbrace: n.Body.Rbrace},
+	}
+}
+
+//
+// ifElseSwitch converts a switch without tag (or switch true) to a chain of if else, for the targets without
+// CAP_SWITCH_ANY: the conditions are tested in the order of the cases and the default is the last else.
+// It returns nil if a case breaks out of the switch or falls through, that caseIndexSwitch preserves
+//
+func ifElseSwitch(n *ast.SwitchStmt) ast.Stmt {
+	if n.Tag != nil {
+		if id, ok := n.Tag.(*ast.Ident); !ok || id.Name != "true" || id.Obj != nil {
+			return nil
+		}
+	}
+
+	var first, last *ast.IfStmt
+	var other *ast.CaseClause
+
+	for _, s := range n.Body.List {
+		clause := s.(*ast.CaseClause)
+		if breaksSwitch(clause.Body) {
+			return nil
+		}
+
+		if clause.List == nil {
+			other = clause
+			continue
+		}
+
+		var cond ast.Expr
+		for _, e := range clause.List {
+			e = parenthesize(e)
+			if cond == nil {
+				cond = e
+			} else {
+				cond = &ast.BinaryExpr{X: cond, OpPos: e.Pos(), Op: token.LOR, Y: e}
+			}
+		}
+
+		stmt := &ast.IfStmt{If: clause.Case, Cond: cond, Body: caseBlock(clause, n.Body.Rbrace)}
+		if first == nil {
+			first = stmt
+			first.Init = n.Init
+		} else {
+			last.Else = stmt
+		}
+		last = stmt
+	}
+
+	if first == nil {
+		return nil // only the default
+	}
+
+	if other != nil {
+		last.Else = caseBlock(other, n.Body.Rbrace)
+	}
+
+	return first
+}
+
+//
+// caseBlock returns the body of a case clause as a block
+//
+func caseBlock(clause *ast.CaseClause, end token.Pos) *ast.BlockStmt {
+	return &ast.BlockStmt{Lbrace: clause.Colon, List: clause.Body, Rbrace: end}
+}
+
+//
+// breaksSwitch returns true if the statements of a case contain a break out of the switch, or a fallthrough
+//
+func breaksSwitch(list []ast.Stmt) bool {
+	found := false
+
+	for _, s := range list {
+		ast.Inspect(s, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.BranchStmt:
+				if (n.Tok == token.BREAK && n.Label == nil) || n.Tok == token.FALLTHROUGH {
+					found = true
+				}
+
+			case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt, *ast.FuncLit:
+				// the breaks inside are for these statements
+				return false
+			}
+
+			return !found
+		})
+	}
+
+	return found
+}
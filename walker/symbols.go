@@ -0,0 +1,93 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path"
+	"strconv"
+
+	"github.com/raff/walkngo/printer"
+)
+
+//
+// declareFile adds the package level names to the symbol table.
+//
+// Package level declarations are visible to the whole file, so they are
+// declared upfront instead of when they are visited.
+//
+func (w *GoWalker) declareFile(f *ast.File) {
+	for _, imp := range f.Imports {
+		if imp.Name != nil {
+			w.symbols.Declare(imp.Name.Name, printer.SYM_PACKAGE, "")
+		} else if p, err := strconv.Unquote(imp.Path.Value); err == nil {
+			w.symbols.Declare(path.Base(p), printer.SYM_PACKAGE, "")
+		}
+	}
+
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil {
+				w.symbols.Declare(d.Name.Name, printer.SYM_FUNC, types.ExprString(d.Type))
+			}
+
+		case *ast.GenDecl:
+			for _, s := range d.Specs {
+				w.declareSpec(d.Tok, s)
+			}
+		}
+	}
+}
+
+//
+// declareSpec adds the names declared by a type, const or var spec to the current scope
+//
+func (w *GoWalker) declareSpec(tok token.Token, spec ast.Spec) {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		w.symbols.Declare(s.Name.Name, printer.SYM_TYPE, types.ExprString(s.Type))
+
+	case *ast.ValueSpec:
+		kind := printer.SYM_VAR
+		if tok == token.CONST {
+			kind = printer.SYM_CONST
+		}
+
+		typ := ""
+		if s.Type != nil {
+			typ = types.ExprString(s.Type)
+		}
+
+		for _, n := range s.Names {
+			w.symbols.Declare(n.Name, kind, typ)
+		}
+	}
+}
+
+//
+// declareFields adds receivers, parameters or results to the current scope
+//
+func (w *GoWalker) declareFields(l *ast.FieldList) {
+	if l == nil {
+		return
+	}
+
+	for _, f := range l.List {
+		for _, n := range f.Names {
+			w.symbols.Declare(n.Name, printer.SYM_VAR, types.ExprString(f.Type))
+		}
+	}
+}
+
+//
+// declareVars adds the variables defined by a short variable declaration (or range) to the current scope.
+// Names already declared in the current scope are assigned, not redeclared.
+//
+func (w *GoWalker) declareVars(exprs ...ast.Expr) {
+	for _, e := range exprs {
+		if id, ok := e.(*ast.Ident); ok && !w.symbols.IsLocal(id.Name) {
+			w.symbols.Declare(id.Name, printer.SYM_VAR, "")
+		}
+	}
+}
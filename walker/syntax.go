@@ -0,0 +1,220 @@
+package walkngo
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/raff/walkngo/printer"
+)
+
+// construct of the syntax errors (see Summary)
+const SYNTAX_ERRORS = "syntax errors"
+
+// maximum number of declarations that can't be parsed, before giving up on the file
+const MAX_BROKEN_DECLS = 20
+
+// keywords starting a top level declaration
+var declKeywords = [][]byte{[]byte("func"), []byte("type"), []byte("var"), []byte("const"), []byte("import")}
+
+//
+// parseFile parses a Go source. With syntax errors the declarations that can be parsed are still converted:
+// each declaration containing an error is blanked out and the source is parsed again, the errors are returned
+// (to be added to the errors of the file) and the declarations that couldn't be parsed are marked in the output (see printBroken)
+//
+func (w *GoWalker) parseFile(filename string, src []byte) (*ast.File, WalkErrors, error) {
+	const mode = parser.ParseComments | parser.AllErrors
+
+	f, err := parser.ParseFile(w.fset, filename, src, mode)
+	if err == nil {
+		return f, nil, nil
+	}
+
+	if isScopeDepthError(err) {
+		// not a syntax error: resolve the identifiers without the limit of go/parser
+		if f, err = parser.ParseFile(w.fset, filename, src, mode|parser.SkipObjectResolution); err == nil {
+			resolveObjects(f)
+			return f, nil, nil
+		}
+	}
+
+	decls := declStarts(src)
+	text := bytes.Clone(src)
+	broken := map[int]bool{} // by index in decls
+	var errors WalkErrors
+
+	for len(broken) < MAX_BROKEN_DECLS {
+		list, ok := err.(scanner.ErrorList)
+		if !ok || len(list) == 0 {
+			return nil, nil, err
+		}
+
+		first := list[0]
+
+		i := sort.SearchInts(decls, first.Pos.Offset+1) - 1
+		if i < 0 {
+			// nothing to convert without the package clause
+			return nil, nil, err
+		}
+
+		if len(errors) == 0 || errors[len(errors)-1].Pos != first.Pos {
+			errors = append(errors, &WalkError{Pos: first.Pos, Msg: "syntax error: " + first.Msg, Construct: SYNTAX_ERRORS})
+		}
+
+		// an error on the first line of a declaration is usually caused by the previous one, not terminated
+		if onFirstLine(src, decls[i], first.Pos.Offset) && i > 0 && !broken[i-1] {
+			i--
+		}
+
+		// and so is an error in a declaration already blanked out (at the end of the file)
+		for i >= 0 && broken[i] {
+			i--
+		}
+
+		if i < 0 {
+			return nil, nil, err
+		}
+
+		broken[i] = true
+		blank(text, decls[i], declEnd(decls, i, len(src)))
+
+		if f, err = parser.ParseFile(token.NewFileSet(), filename, text, mode); err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// parse again for the positions in fset, then mark the broken declarations
+	if f, err = parser.ParseFile(w.fset, filename, text, mode); err != nil {
+		return nil, nil, err
+	}
+
+	tf := w.fset.File(f.Pos())
+
+	for i := range broken {
+		end := declEnd(decls, i, len(src))
+		for end > decls[i] && isSpace(src[end-1]) {
+			end--
+		}
+
+		f.Decls = append(f.Decls, &ast.BadDecl{From: tf.Pos(decls[i]), To: tf.Pos(end)})
+	}
+
+	sort.Slice(f.Decls, func(i, j int) bool { return f.Decls[i].Pos() < f.Decls[j].Pos() })
+
+	return f, errors, nil
+}
+
+//
+// isScopeDepthError returns true if the only error is go/parser giving up on the object resolution
+// (more than 1000 nested scopes, see resolveObjects)
+//
+func isScopeDepthError(err error) bool {
+	list, ok := err.(scanner.ErrorList)
+	if !ok || len(list) == 0 {
+		return false
+	}
+
+	for _, e := range list {
+		if !strings.Contains(e.Msg, "exceeded max scope depth") {
+			return false
+		}
+	}
+
+	return true
+}
+
+//
+// declStarts returns the offsets of the lines starting a top level declaration
+//
+func declStarts(src []byte) (starts []int) {
+	for offset := 0; offset < len(src); {
+		line := src[offset:]
+		if nl := bytes.IndexByte(line, '\n'); nl >= 0 {
+			line = line[:nl+1]
+		}
+
+		for _, k := range declKeywords {
+			if bytes.HasPrefix(line, k) && len(line) > len(k) && (isSpace(line[len(k)]) || line[len(k)] == '(') {
+				starts = append(starts, offset)
+				break
+			}
+		}
+
+		offset += len(line)
+	}
+
+	return
+}
+
+//
+// declEnd returns the end offset of the declaration i (the start of the next one)
+//
+func declEnd(decls []int, i, size int) int {
+	if i+1 < len(decls) {
+		return decls[i+1]
+	}
+
+	return size
+}
+
+//
+// onFirstLine returns true if offset is on the line starting at start
+//
+func onFirstLine(src []byte, start, offset int) bool {
+	return bytes.IndexByte(src[start:offset], '\n') < 0
+}
+
+//
+// blank replaces the text between start and end with spaces, keeping the newlines (and the positions)
+//
+func blank(text []byte, start, end int) {
+	for i := start; i < end; i++ {
+		if text[i] != '\n' {
+			text[i] = ' '
+		}
+	}
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n'
+}
+
+//
+// printBroken prints the first line of a declaration or statement that couldn't be parsed as a comment,
+// followed (for statements) by a placeholder that fails at run time
+//
+func (w *GoWalker) printBroken(node ast.Node) {
+	start := w.fset.PositionFor(node.Pos(), false)
+	end := w.fset.PositionFor(node.End(), false)
+
+	text := ""
+	if start.IsValid() && end.IsValid() && start.Offset < end.Offset && end.Offset <= len(w.src) {
+		lines := strings.Split(string(w.src[start.Offset:end.Offset]), "\n")
+
+		text = strings.TrimSpace(lines[0])
+		if len(lines) > 1 {
+			text += " ..."
+		}
+	}
+
+	if _, ok := node.(ast.Decl); ok {
+		w.newLine()
+	}
+
+	w.p.PrintLevel(printer.NONE, w.fallback(node, "syntax error, not converted: "+strings.Replace(text, "*/", "* /", -1)))
+	w.p.Print("\n")
+
+	if _, ok := node.(ast.Stmt); ok {
+		w.p.PrintStmt("", w.unsupported(node))
+	} else {
+		w.skipped = append(w.skipped, node)
+	}
+}
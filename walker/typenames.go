@@ -0,0 +1,31 @@
+package walkngo
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+//
+// usesTypeNames returns true if the file imports reflect or has a format string with %T,
+// so that the Go names of the types are printed after their definitions (see PrintTypeName)
+//
+func usesTypeNames(f *ast.File) bool {
+	for _, imp := range f.Imports {
+		if imp.Path.Value == `"reflect"` {
+			return true
+		}
+	}
+
+	found := false
+
+	ast.Inspect(f, func(node ast.Node) bool {
+		if lit, ok := node.(*ast.BasicLit); ok && lit.Kind == token.STRING && strings.Contains(lit.Value, "%T") {
+			found = true
+		}
+
+		return !found
+	})
+
+	return found
+}
@@ -0,0 +1,134 @@
+package walkngo
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+//
+// VendorFilter selects the vendored packages to convert. Patterns are import paths,
+// with "..." matching any sequence of characters (as in "go list", i.e. "github.com/pkg/..."):
+// a package is converted if it matches one of the Include patterns (or Include is empty)
+// and none of the Exclude patterns.
+//
+type VendorFilter struct {
+	Include []string
+	Exclude []string
+}
+
+//
+// Match returns true if the vendored package should be converted
+//
+func (f *VendorFilter) Match(ipath string) bool {
+	for _, p := range f.Exclude {
+		if matchPattern(p, ipath) {
+			return false
+		}
+	}
+
+	if len(f.Include) == 0 {
+		return true
+	}
+
+	for _, p := range f.Include {
+		if matchPattern(p, ipath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//
+// matchPattern matches an import path against a pattern where "..." matches anything
+// (and "x/..." also matches x)
+//
+func matchPattern(pattern, ipath string) bool {
+	if strings.HasSuffix(pattern, "/...") && ipath == strings.TrimSuffix(pattern, "/...") {
+		return true
+	}
+
+	parts := strings.Split(pattern, "...")
+	if len(parts) == 1 {
+		return pattern == ipath
+	}
+
+	if !strings.HasPrefix(ipath, parts[0]) {
+		return false
+	}
+
+	rest := ipath[len(parts[0]):]
+	for _, p := range parts[1 : len(parts)-1] {
+		i := strings.Index(rest, p)
+		if i < 0 {
+			return false
+		}
+
+		rest = rest[i+len(p):]
+	}
+
+	return strings.HasSuffix(rest, parts[len(parts)-1])
+}
+
+//
+// Vendored returns true if the import path is a package in the vendor folder of the module
+// that should be converted
+//
+func (m *Module) Vendored(ipath string) bool {
+	if m.Vendor == nil || m.Contains(ipath) || !m.Vendor.Match(ipath) {
+		return false
+	}
+
+	info, err := os.Stat(m.PackageDir(ipath))
+	return err == nil && info.IsDir()
+}
+
+//
+// VendoredPackages returns the import paths of the vendored packages to convert
+//
+func (m *Module) VendoredPackages() ([]string, error) {
+	if m.Vendor == nil {
+		return nil, nil
+	}
+
+	vendor := filepath.Join(m.Dir, "vendor")
+	if _, err := os.Stat(vendor); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var packages []string
+
+	err := filepath.Walk(vendor, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if name := info.Name(); p != vendor && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !strings.HasSuffix(p, ".go") || strings.HasSuffix(p, "_test.go") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(vendor, filepath.Dir(p))
+		if err != nil {
+			return nil
+		}
+
+		ipath := path.Clean(filepath.ToSlash(rel))
+		if m.Vendor.Match(ipath) && (len(packages) == 0 || packages[len(packages)-1] != ipath) {
+			packages = append(packages, ipath)
+		}
+
+		return nil
+	})
+
+	return packages, err
+}
@@ -1,13 +1,21 @@
 package walkngo
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"go/ast"
-	"go/parser"
+	"go/format"
 	"go/token"
+	"go/types"
 	"io"
+	"log/slog"
+	"os"
+	"path"
+	"reflect"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/raff/walkngo/printer"
 )
@@ -16,156 +24,888 @@ import (
 // GoWalker is the context for the AST visitor
 //
 type GoWalker struct {
-	p      printer.Printer
-	parent ast.Node
-	flush  bool
-	buffer bytes.Buffer
-	writer io.Writer
-	debug  bool
+	p       printer.Printer
+	parents []ast.Node        // the nodes being visited (the current one is the last)
+	out     *bufio.Writer     // the (buffered) output writer
+	pipe    *printer.Pipeline // post-processing steps, writing to out
+	current io.Writer         // where the printer is currently writing (pipe or a BufferVisit buffer)
+	writer  io.Writer
+	debug   bool
+
+	fset      *token.FileSet // positions of all the files parsed (see Parse)
+	file      *ast.File      // the file being converted
+	src       []byte         // source of the file being converted
+	pos       token.Position // position of the statement being printed
+	errors    WalkErrors
+	skipped   []ast.Node // nodes replaced by placeholders (see unsupported)
+	logger    *slog.Logger
+	normalize Normalization          // desugaring steps applied before printing
+	names     printer.Names          // names for temporaries introduced by the walker
+	symbols   printer.SymbolTable    // names declared in the scopes being visited
+	renames   map[*ast.Object]string // identifiers renamed by pragmas
+
+	mangled     map[string]string              // identifiers renamed because they are reserved words in the target language
+	anonTypes   map[string]string              // names of the anonymous types in the function signatures, by type (see collectAnonTypes)
+	anonDone    map[string]bool                // anonymous types already declared
+	mapKeys     map[*ast.TypeSpec]bool         // struct types used as map keys (see collectMapKeys)
+	methods     map[string][]*ast.FuncDecl     // methods declared in the file, by receiver type (see collectMethods)
+	guards      map[*ast.DeferStmt]bool        // deferred calls converted to RAII guards (see collectGuards)
+	flags       map[*ast.TypeSpec]*ast.GenDecl // types used as sets of flags, with their constants (see collectFlags)
+	typeNames   bool                           // the file prints or reflects on the type names (see usesTypeNames)
+	importNames map[string]bool                // names of the packages imported by the current file
+
+	atomicVars   map[*ast.Object]bool // variables used with sync/atomic functions
+	atomicFields map[string]bool      // names of the struct fields used with sync/atomic functions
+
+	native      map[string]bool            // import paths of packages kept as native Go code
+	nativeLocal map[string]string          // local name -> import path of native packages in the current file
+	nativeUsed  map[string]map[string]bool // native functions referenced, by import path
+
+	module *Module         // the module of the files being converted, for import resolution
+	deps   map[string]bool // folders of the module packages imported
+
+	pkgName   string   // package name of the file being converted
+	namespace string   // target namespace of the file being converted (empty for main)
+	imports   []string // import paths of the file being converted
+	exports   []Export // exported declarations of the file being converted
+
+	sourceComments bool // print the Go source as comments
+	groupDecls     bool // print the declarations grouped by kind (see SetGroupDecls)
+	annotate       bool // print the Go signature of the functions (see SetAnnotate)
+	declsFallback  bool // convert only the declarations of the files with problems (see SetDeclsFallback)
+	declsOnly      bool // converting only the declarations
+	raii           bool // convert the deferred calls releasing a resource to RAII guards (see SetRAII)
+	cgo            bool // the file imports "C" and the printer passes cgo code through
+	lineDone       bool // the empty line before the current node was already printed
+
+	comments bool           // print the comments of the Go source
+	cmap     ast.CommentMap // comments of the file being converted, by node
+
+	smap  *printer.SourceMap   // source positions of the output lines (nil if disabled)
+	check *printer.SyntaxCheck // sanity check of the output (nil if disabled)
+
+	provenance *Provenance // printed in the header of the output files (see SetProvenance)
 }
 
 func NewWalker(p printer.Printer, out io.Writer, debug bool) *GoWalker {
-	w := GoWalker{p: p, flush: true, writer: out, debug: debug}
-	p.SetWriter(&w.buffer)
+	w := GoWalker{p: p, out: bufio.NewWriter(out), writer: out, debug: debug, comments: true, fset: token.NewFileSet()}
+	w.pipe = printer.NewPipeline(w.out, printer.DefaultTransformers(p)...)
+	w.setOutput(w.pipe)
+	p.SetSymbols(&w.symbols)
 	return &w
 }
 
+//
+// AddTransformer adds post-processing steps to be applied to the generated output
+//
+func (w *GoWalker) AddTransformer(t ...printer.Transformer) {
+	w.pipe.Add(t...)
+}
+
+//
+// SetNormalization selects the desugaring steps applied to the AST before printing
+//
+func (w *GoWalker) SetNormalization(n Normalization) {
+	w.normalize = n
+}
+
+//
+// SetLogger sets the logger for the notes (fallbacks taken and types guessed, at info level)
+// and debug events (from the walker and the printer), nil to disable logging
+//
+func (w *GoWalker) SetLogger(logger *slog.Logger) {
+	w.logger = logger
+	w.p.SetLogger(logger)
+}
+
 func (w *GoWalker) SetWriter(writer io.Writer) (old io.Writer) {
 	w.Flush()
 
 	old, w.writer = w.writer, writer
+	w.out.Reset(writer)
 	return
 }
 
+func (w *GoWalker) setOutput(out io.Writer) {
+	w.current = out
+	w.p.SetWriter(out)
+}
+
+//
+// WalkFile parses and converts the input file.
+//
+// If some constructs couldn't be converted the (best effort) output is still generated
+// and the returned error is a WalkErrors with the list of problems found.
+//
 func (w *GoWalker) WalkFile(filename string) error {
-	fset := token.NewFileSet() // positions are relative to fset
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		w.reset(filename)
+		return err
+	}
+
+	return w.WalkSource(filename, src)
+}
+
+//
+// WalkSource converts the Go source src, as if read from filename (see WalkFile).
+// It runs the three stages of the conversion: Parse, Analyze and Emit
+//
+func (w *GoWalker) WalkSource(filename string, src []byte) error {
+	w.reset(filename)
 
-	f, err := parser.ParseFile(fset, filename, nil, 0)
+	pf, err := w.Parse(filename, src)
 	if err != nil {
 		return err
 	}
 
-	w.p.Reset()
-	w.p.Print(fmt.Sprintf("//source: %s\n", filename))
+	w.Analyze(pf)
+	return w.Emit(pf)
+}
+
+//
+// ParsedFile is a Go file parsed by Parse, that can be retained by the passes that need it
+// without parsing it again (the positions are in the FileSet of the walker)
+//
+type ParsedFile struct {
+	Filename string
+	Src      []byte
+	File     *ast.File
+
+	errors   WalkErrors // syntax errors of the declarations that couldn't be parsed (see parseFile)
+	analyzed bool       // the AST was already normalized
+}
+
+//
+// Parse parses the Go source src, as if read from filename. The declarations with syntax errors
+// are reported (as WalkErrors) when the file is converted
+//
+func (w *GoWalker) Parse(filename string, src []byte) (*ParsedFile, error) {
+	f, errors, err := w.parseFile(filename, src)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParsedFile{Filename: filename, Src: src, File: f, errors: errors}, nil
+}
+
+//
+// Analyze prepares the conversion of a parsed file, clearing the state of the last file walked:
+// it collects the names, imports and symbols and applies the normalization (only the first time,
+// since it changes the AST)
+//
+func (w *GoWalker) Analyze(pf *ParsedFile) {
+	w.reset(pf.Filename)
+	w.errors = append(w.errors, pf.errors...)
+	w.prepare(pf.File, pf.Src, !pf.analyzed)
+	pf.analyzed = true
+}
+
+//
+// Emit converts the file analyzed last (see Analyze), writing the translation to the output
+//
+func (w *GoWalker) Emit(pf *ParsedFile) error {
+	if w.file != pf.File {
+		return fmt.Errorf("%s: not analyzed", pf.Filename)
+	}
+
+	if w.declsFallback {
+		w.emitDeclsFallback(pf)
+	} else {
+		w.emit(pf)
+	}
+
+	if len(w.errors) > 0 {
+		return w.errors
+	}
 
-	ast.Walk(w, f)
 	return nil
 }
 
+func (w *GoWalker) emit(pf *ParsedFile) {
+	w.printHeader(pf.Filename, pf.Src)
+	if w.declsOnly {
+		w.p.Print("//declarations only: the file couldn't be fully converted, the function bodies and the methods are omitted\n")
+	}
+
+	w.visit(pf.File)
+	w.Flush()
+	w.addSyntaxErrors()
+}
+
+//
+// FileSet returns the positions of the files parsed by the walker
+//
+func (w *GoWalker) FileSet() *token.FileSet {
+	return w.fset
+}
+
+//
+// SetFileSet sets the FileSet used to parse the files, to share it with other tools
+//
+func (w *GoWalker) SetFileSet(fset *token.FileSet) {
+	w.fset = fset
+}
+
+//
+// reset clears the state of the last file walked, before walking filename
+//
+func (w *GoWalker) reset(filename string) {
+	w.file, w.errors, w.skipped = nil, nil, nil
+	w.pkgName, w.namespace, w.imports, w.exports, w.mangled = "", "", nil, nil, nil
+
+	if w.smap != nil {
+		w.smap.Reset("", filename)
+	}
+}
+
+//
+// prepare collects what's needed to convert the parsed file (names, imports, symbols),
+// applies the normalization (if normalize is true) and resets the printer
+//
+func (w *GoWalker) prepare(f *ast.File, src []byte, normalize bool) {
+	w.file, w.src = f, src
+	w.pkgName = f.Name.Name
+
+	w.names.Reset()
+	w.names.Reserve(identifiers(f)...)
+	w.collectRenames(f)
+	w.collectImportNames(f)
+	w.collectAtomics(f)
+	w.collectAnonTypes(f)
+	w.collectMapKeys(f)
+	w.collectMethods(f)
+	w.collectGuards(f)
+	w.collectFlags(f)
+	w.typeNames = usesTypeNames(f)
+
+	w.cgo = false
+	w.nativeLocal = map[string]string{}
+	for _, imp := range f.Imports {
+		if isCgoImport(imp) {
+			w.cgo = w.p.Capabilities().Has(printer.CAP_CGO)
+		}
+
+		if ipath, _ := strconv.Unquote(imp.Path.Value); w.native[ipath] {
+			if imp.Name != nil {
+				w.nativeLocal[imp.Name.Name] = ipath
+			} else {
+				w.nativeLocal[path.Base(ipath)] = ipath
+			}
+		}
+	}
+
+	w.cmap = ast.NewCommentMap(w.fset, f, f.Comments)
+
+	if normalize {
+		norm := normalizer{steps: w.normalize, names: &w.names}
+		norm.normalize(f)
+	}
+
+	w.p.Reset()
+	w.pipe.Reset()
+	w.p.ReserveNames(identifiers(f)...)
+
+	w.symbols.Reset()
+	w.declareFile(f)
+
+	w.parents = nil
+	w.pos = token.Position{}
+}
+
+//
+// PackageName returns the package name of the last file walked
+//
+func (w *GoWalker) PackageName() string {
+	return w.pkgName
+}
+
+//
+// Namespace returns the target namespace of the last file walked (empty for package main)
+//
+func (w *GoWalker) Namespace() string {
+	return w.namespace
+}
+
 //
-// Implement the Visitor interface for GoWalker
+// Imports returns the import paths of the last file walked (relative imports are resolved with SetModule)
 //
-func (w *GoWalker) Visit(node ast.Node) (ret ast.Visitor) {
+func (w *GoWalker) Imports() []string {
+	return w.imports
+}
+
+//
+// Errors returns the errors collected while walking the last file
+//
+func (w *GoWalker) Errors() WalkErrors {
+	return w.errors
+}
+
+func (w *GoWalker) addError(node ast.Node, construct, format string, args ...interface{}) {
+	var pos token.Position
+
+	if w.fset != nil && node != nil {
+		pos = w.fset.Position(node.Pos())
+	}
+
+	w.errors = append(w.errors, &WalkError{Pos: pos, Msg: fmt.Sprintf(format, args...), Construct: construct})
+
+	if w.logger != nil {
+		w.logger.Info("fallback taken", "pos", pos.String(), "reason", w.errors[len(w.errors)-1].Msg)
+	}
+}
+
+//
+// supports returns true if the printer can convert the construct,
+// otherwise it records an "unsupported by target" error for the node
+//
+func (w *GoWalker) supports(node ast.Node, c printer.Capability) bool {
+	if w.p.Capabilities().Has(c) {
+		return true
+	}
+
+	w.addError(node, c.String(), "%s not supported by target", c)
+	return false
+}
+
+//
+// printUnsupported prints the original source for a statement that the printer cannot convert,
+// followed by a placeholder statement that fails at run time (see unsupported)
+//
+func (w *GoWalker) printUnsupported(node ast.Node) {
+	var src bytes.Buffer
+
+	if err := format.Node(&src, w.fset, node); err != nil {
+		src.WriteString(fmt.Sprintf("%T", node))
+	}
+
+	w.p.PrintLevel(printer.NONE, w.fallback(node, "unsupported: "+strings.Replace(src.String(), "*/", "* /", -1)))
+	w.p.Print("\n")
+	w.p.PrintStmt("", w.unsupported(node))
+}
+
+//
+// position returns the position of the node in the Go source, if known
+//
+func (w *GoWalker) position(node ast.Node) token.Position {
+	if v := reflect.ValueOf(node); w.fset != nil && node != nil && !(v.Kind() == reflect.Pointer && v.IsNil()) {
+		return w.fset.Position(node.Pos())
+	}
+
+	return token.Position{}
+}
+
+//
+// fallback returns a comment for a node that is not converted, with the position of the node
+// in the Go source (file:line:col, as for WalkError), if known
+//
+func (w *GoWalker) fallback(node ast.Node, text string) string {
+	if pos := w.position(node); pos.IsValid() {
+		return fmt.Sprintf("/* %s: %s */", pos, text)
+	}
+
+	return fmt.Sprintf("/* %s */", text)
+}
+
+//
+// unsupported returns a placeholder for a node that is not converted, that compiles
+// and fails at run time reporting the construct and its position (see Printer.FormatUnsupported)
+//
+func (w *GoWalker) unsupported(node ast.Node) string {
+	w.skipped = append(w.skipped, node)
+
+	what := constructName(node)
+	if pos := w.position(node); pos.IsValid() {
+		what += " at " + pos.String()
+	}
+
+	return w.p.FormatUnsupported(what)
+}
+
+//
+// constructName returns a readable name for the type of a node (i.e. "select statement" for *ast.SelectStmt)
+//
+func constructName(node ast.Node) string {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+
+	var words []string
+
+	start := 0
+	for i, r := range name {
+		if i > start && unicode.IsUpper(r) {
+			words = append(words, name[start:i])
+			start = i
+		}
+	}
+
+	words = append(words, name[start:])
+
+	for i, word := range words {
+		switch word {
+		case "Stmt":
+			words[i] = "statement"
+		case "Expr":
+			words[i] = "expression"
+		case "Lit":
+			words[i] = "literal"
+		case "Decl":
+			words[i] = "declaration"
+		default:
+			words[i] = strings.ToLower(word)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+//
+// identifiers returns the list of all the identifiers used in the file
+//
+func identifiers(f *ast.File) (names []string) {
+	seen := map[string]bool{}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && !seen[id.Name] {
+			seen[id.Name] = true
+			names = append(names, id.Name)
+		}
+
+		return true
+	})
+
+	return
+}
+
+//
+// isUnion returns true if the expression is a type union or approximation element (A | ~B)
+//
+func isUnion(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.BinaryExpr:
+		return e.Op == token.OR
+	case *ast.UnaryExpr:
+		return e.Op == token.TILDE
+	}
+
+	return false
+}
+
+//
+// visit converts a node (and its children), keeping track of the parent nodes
+//
+func (w *GoWalker) visit(node ast.Node) {
 	if node == nil {
 		return
 	}
 
 	if w.debug {
-		w.p.Print(fmt.Sprintf("/* Node: %#v */\n", node))
+		w.p.Print(w.fallback(node, fmt.Sprintf("Node: %#v", node)) + "\n")
 	}
 
-	pparent := w.parent
-	w.parent = node
+	if w.logger != nil {
+		w.logger.Debug("node visited", "node", fmt.Sprintf("%T", node), "pos", w.fset.Position(node.Pos()).String())
+	}
+
+	var after []*ast.CommentGroup
+
+	if w.current == w.pipe {
+		// positions are not updated for buffered fragments, that are embedded in the current statement
+		w.pos = w.fset.Position(node.Pos())
+		w.p.SetPosition(w.pos)
+		w.pipe.Mark(w.pos)
 
+		if !w.p.IsSameLine() {
+			var before []*ast.CommentGroup
+
+			before, after = w.nodeComments(node)
+			if len(before) > 0 {
+				w.emptyLineBefore(node)
+				w.printComments(before)
+			}
+
+			if w.sourceComments {
+				w.printSource(node)
+			}
+		}
+	}
+
+	w.parents = append(w.parents, node)
+	w.visitNode(node)
+	w.parents = w.parents[:len(w.parents)-1]
+	w.lineDone = false
+
+	w.printComments(after)
+}
+
+//
+// newLine prints the empty line that precedes declarations and compound statements,
+// unless it was already printed (before the source comment)
+//
+func (w *GoWalker) newLine() {
+	if w.lineDone {
+		w.lineDone = false
+		return
+	}
+
+	w.p.Print("\n")
+}
+
+//
+// parent returns the parent of the node being visited (nil for the root)
+//
+func (w *GoWalker) parent() ast.Node {
+	if len(w.parents) < 2 {
+		return nil
+	}
+
+	return w.parents[len(w.parents)-2]
+}
+
+//
+// visitNode dispatches the node to the proper printer calls
+//
+func (w *GoWalker) visitNode(node ast.Node) {
 	switch n := node.(type) {
 	case *ast.File:
 		w.p.PrintPackage(n.Name.String())
-		for _, d := range n.Decls {
-			w.Visit(d)
+
+		ns, opened := w.fileNamespace(n), false
+		w.namespace = ns
+
+		decls, prototypes, imported := n.Decls, false, false
+		if w.groupDecls {
+			decls = groupDecls(decls)
+		}
+
+		for _, d := range decls {
+			gen, ok := d.(*ast.GenDecl)
+			isImport := ok && gen.Tok == token.IMPORT
+
+			if !imported && !isImport {
+				w.p.PrintImportsEnd()
+				imported = true
+			}
+
+			if !opened && len(ns) > 0 && !isImport {
+				w.p.PrintNamespace(ns)
+				opened = true
+			}
+
+			if _, ok := d.(*ast.FuncDecl); ok && w.groupDecls && !prototypes {
+				w.printPrototypes(decls)
+				prototypes = true
+			}
+
+			w.visit(d)
+			w.out.Flush()
+		}
+
+		if !imported {
+			w.p.PrintImportsEnd()
+		}
+
+		if opened {
+			w.p.PrintNamespaceEnd(ns)
 		}
 
 	case *ast.ImportSpec:
-		w.p.PrintImport(w.parseExpr(n.Name), n.Path.Value)
+		if w.applyPragmas(w.pragmas(n.Doc)) {
+			break
+		}
+
+		if isCgoImport(n) && w.supports(n, printer.CAP_CGO) {
+			doc := n.Doc
+			if decl := w.parent().(*ast.GenDecl); doc == nil && !decl.Lparen.IsValid() {
+				// import "C" with the preamble as the doc of the declaration
+				doc = decl.Doc
+			}
+
+			w.printPreamble(doc)
+			break
+		}
+
+		ipath := w.resolveImport(n.Path.Value, w.fset.Position(n.Pos()).Filename)
+		if p, err := strconv.Unquote(ipath); err == nil {
+			w.imports = append(w.imports, p)
+		}
+
+		w.p.PrintImport(w.parseExpr(n.Name), ipath)
 
 	case *ast.TypeSpec:
-		w.p.PrintType(n.Name.String(), w.parseExpr(n.Type))
+		if w.applyPragmas(w.pragmas(n.Doc)) {
+			break
+		}
+
+		if n.TypeParams != nil {
+			w.supports(n, printer.CAP_GENERICS)
+		}
+		w.declareSpec(token.TYPE, n)
+		w.printDoc(w.specDoc(n.Doc), n.Name)
+		w.exportType(n)
+		typedef := w.parseExpr(n.Type)
+		if w.flags[n] != nil {
+			w.p.PrintEnum(w.identName(n.Name), typedef, nil, nil)
+		} else if methods := w.namedMethods(n); methods == nil {
+			w.p.PrintType(w.identName(n.Name), typedef)
+		} else if st, ok := n.Type.(*ast.StructType); ok {
+			w.p.UpdateLevel(printer.UP)
+			fields := w.parseFieldList(st.Fields, printer.FIELD)
+			w.p.UpdateLevel(printer.DOWN)
+			w.p.PrintStruct(w.identName(n.Name), fields, methods)
+		} else {
+			w.p.PrintNamedType(w.identName(n.Name), typedef, methods)
+		}
+
+		if st, ok := n.Type.(*ast.StructType); ok && n.TypeParams == nil {
+			if fields := structTags(st); fields != nil {
+				w.p.PrintStructTags(w.identName(n.Name), fields)
+			}
+
+			if w.mapKeys[n] {
+				w.p.PrintComparable(w.identName(n.Name), w.comparableFields(st))
+			}
+		}
+
+		if w.typeNames && n.TypeParams == nil && w.symbols.Depth() == 0 {
+			w.p.PrintTypeName(w.identName(n.Name), typedef, w.pkgName+"."+n.Name.Name)
+		}
 
 	case *ast.ValueSpec:
-		vtype := (pparent.(*ast.GenDecl)).Tok.String()
-		w.p.PrintValue(vtype, w.parseExpr(n.Type), w.parseNames(n.Names), w.parseExprList(n.Values), len(n.Names) > 1, len(n.Values) > 1)
+		if w.applyPragmas(w.pragmas(n.Doc)) {
+			break
+		}
+
+		tok := (w.parent().(*ast.GenDecl)).Tok
+		var types []ast.Expr
+		for range n.Values {
+			types = append(types, n.Type)
+		}
+
+		values := w.parseExprList(w.flagsValues(types, n.Values))
+		w.declareSpec(tok, n)
+
+		vtype := w.parseExpr(n.Type)
+		if n.Type != nil && w.isAtomicSpec(n) {
+			vtype = w.p.FormatAtomic(vtype)
+		}
+
+		w.printDoc(w.specDoc(n.Doc), n.Names...)
+		w.exportValues(tok, n)
+		w.p.PrintValue(tok.String(), vtype, w.parseNames(n.Names), values, len(n.Names) > 1, len(n.Values) > 1)
 
 	case *ast.GenDecl:
-		w.p.Print("\n")
+		if w.applyPragmas(w.pragmas(n.Doc)) {
+			break
+		}
+
+		if spec := flagsType(n); spec != nil && w.flags[spec] == n {
+			w.newLine()
+			w.printFlags(n, spec)
+			break
+		}
+
+		w.newLine()
 		w.p.PushContext()
 		for _, s := range n.Specs {
-			w.Visit(s)
+			w.visit(s)
 		}
 		w.p.PopContext()
 
 	case *ast.FuncDecl:
+		prag := w.pragmas(n.Doc)
+		if w.applyPragmas(prag) {
+			break
+		}
+
+		if n.Body == nil {
+			w.printExternal(n)
+			break
+		}
+
+		if w.declsOnly {
+			w.printDeclOnly(n)
+			break
+		}
+
+		name := w.identName(n.Name)
+		if len(prag.name) > 0 {
+			name = prag.name // methods are not resolved, rename the declaration
+		} else if n.Recv != nil {
+			name = w.mangle(n.Name.Name) // a method name never refers to a predeclared identifier
+		}
+
+		if n.Type.TypeParams != nil {
+			w.supports(n, printer.CAP_GENERICS)
+		}
+		w.printAnonTypes(n.Type)
 		w.p.PushContext()
-		w.p.Print("\n")
+		w.symbols.Push()
+		w.declareFields(n.Recv)
+		w.declareFields(n.Type.Params)
+		w.declareFields(n.Type.Results)
+		w.newLine()
+		w.printDoc(n.Doc, n.Name)
+		if w.annotate {
+			w.printSignature(n)
+		}
+		w.exportFunc(n, name)
 		w.p.PrintFunc(w.parseFieldList(n.Recv, printer.RECEIVER),
-			n.Name.String(),
+			name,
 			w.parseFieldList(n.Type.Params, printer.PARAM),
 			w.parseFieldList(n.Type.Results, printer.RESULT))
-		w.Visit(n.Body)
+		w.p.SameLine() // the opening brace follows the function header
+		w.visit(n.Body)
 		w.p.Print("\n")
+		w.symbols.Pop()
 		w.p.PopContext()
 
 	case *ast.BlockStmt:
+		w.symbols.Push()
 		w.p.PrintBlockStart(printer.CODE)
 		for _, i := range n.List {
-			w.Visit(i)
+			w.visit(i)
 		}
 		w.p.PrintBlockEnd(printer.CODE)
+		w.symbols.Pop()
+
+		switch w.parent().(type) {
+		case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+			// a block statement
+			w.p.Print("\n")
+		}
 
 	case *ast.IfStmt:
+		w.symbols.Push() // implicit scope for init
+		defer w.symbols.Pop()
+
 		if !w.p.IsSameLine() {
-			w.p.Print("\n")
+			w.newLine()
 		}
 		w.p.PrintIf(w.BufferVisit(n.Init), w.parseExpr(n.Cond))
 		w.p.SameLine()
-		w.Visit(n.Body)
+		w.visit(n.Body)
 		if n.Else != nil {
 			w.p.SameLine()
 			w.p.PrintElse()
 			w.p.SameLine()
-			w.Visit(n.Else)
+			w.visit(n.Else)
 		}
 		w.p.Print("\n")
 
 	case *ast.ForStmt:
-		w.p.Print("\n")
+		w.symbols.Push()
+		defer w.symbols.Pop()
+
+		if init, ok := n.Init.(*ast.AssignStmt); ok && init.Tok == token.DEFINE {
+			w.checkLoopVars(n.Body, init.Lhs...)
+		}
+
+		w.newLine()
 		w.p.PrintFor(w.BufferVisit(n.Init), w.parseExpr(n.Cond), w.BufferVisit(n.Post))
-		w.Visit(n.Body)
+		w.p.SameLine()
+		w.visit(n.Body)
 		w.p.Print("\n")
 
 	case *ast.SwitchStmt:
-		w.p.Print("\n")
+		w.symbols.Push()
+		defer w.symbols.Pop()
+
+		if !w.isNativeSwitch(n) {
+			if stmt := ifElseSwitch(n); stmt != nil {
+				w.visit(stmt)
+				break
+			}
+
+			n = w.caseIndexSwitch(n)
+		}
+
+		w.newLine()
 		w.p.PrintSwitch(w.BufferVisit(n.Init), w.parseExpr(n.Tag))
-		w.Visit(n.Body)
+		w.p.SameLine()
+		w.visit(n.Body)
 		w.p.Print("\n")
 
 	case *ast.TypeSwitchStmt:
-		w.p.Print("\n")
+		w.symbols.Push()
+		defer w.symbols.Pop()
+
+		w.newLine()
 		w.p.PrintSwitch(w.BufferVisit(n.Init), w.BufferVisit(n.Assign))
-		w.Visit(n.Body)
+		w.p.SameLine()
+		w.visit(n.Body)
 		w.p.Print("\n")
 
 	case *ast.CaseClause:
-		w.p.PrintCase(w.parseExprList(n.List))
+		w.symbols.Push()
+		defer w.symbols.Pop()
+
+		if len(n.List) > 1 && !w.p.Capabilities().Has(printer.CAP_SWITCH_ANY) {
+			// one label for each value
+			for _, e := range n.List {
+				w.p.PrintCase(w.parseExpr(e))
+			}
+		} else {
+			w.p.PrintCase(w.parseExprList(n.List))
+		}
 		w.p.UpdateLevel(printer.UP)
 		for _, i := range n.Body {
-			w.Visit(i)
+			w.visit(i)
 		}
 		w.p.PrintEndCase()
 		w.p.UpdateLevel(printer.DOWN)
 
 	case *ast.RangeStmt:
-		w.p.Print("\n")
-		w.p.PrintRange(w.parseExpr(n.Key), w.parseExpr(n.Value), w.parseExpr(n.X))
-		w.Visit(n.Body)
+		w.symbols.Push()
+		defer w.symbols.Pop()
+
+		if isInteger(n.X) && !w.p.Capabilities().Has(printer.CAP_RANGE_INT) {
+			w.printRangeInt(n)
+			break
+		}
+
+		x := w.parseExpr(n.X)
+		if n.Tok == token.DEFINE {
+			w.declareVars(n.Key, n.Value)
+		}
+
+		w.newLine()
+		w.p.PrintRange(w.parseExpr(n.Key), w.parseExpr(n.Value), x)
+		w.p.SameLine()
+		w.visit(n.Body)
 		w.p.Print("\n")
 
 	case *ast.BranchStmt:
-		w.p.PrintStmt(n.Tok.String(), w.parseExpr(n.Label))
+		if n.Tok != token.GOTO || w.supports(n, printer.CAP_GOTO) {
+			w.p.PrintStmt(n.Tok.String(), w.parseExpr(n.Label))
+		} else {
+			w.printUnsupported(n)
+		}
+
+	case *ast.LabeledStmt:
+		w.newLine()
+		w.p.PrintLabel(w.parseExpr(n.Label))
+		w.lineDone = true // no empty line between the label and the statement
+		w.visit(n.Stmt)
 
 	case *ast.DeferStmt:
-		w.p.PrintStmt("defer", w.parseExpr(n.Call))
+		if mutex, ok := w.guards[n]; ok && w.supports(n, printer.CAP_DEFER) {
+			if mutex {
+				w.p.PrintGuard(w.parseExpr(n.Call.Fun.(*ast.SelectorExpr).X), w.parseExpr(n.Call))
+			} else {
+				w.p.PrintGuard("", w.parseExpr(n.Call))
+			}
+		} else if w.supports(n, printer.CAP_DEFER) {
+			w.p.PrintStmt("defer", w.parseExpr(n.Call))
+		} else {
+			w.printUnsupported(n)
+		}
 
 	case *ast.GoStmt:
-		w.p.PrintStmt("go", w.parseExpr(n.Call))
+		if w.supports(n, printer.CAP_GOROUTINES) {
+			w.p.PrintStmt("go", w.parseExpr(n.Call))
+		} else {
+			w.printUnsupported(n)
+		}
 
 	case *ast.ReturnStmt:
 		w.p.PrintReturn(w.parseExprList(n.Results), len(n.Results) > 1)
@@ -174,52 +914,78 @@ func (w *GoWalker) Visit(node ast.Node) (ret ast.Visitor) {
 		w.p.PrintStmt("", w.parseExpr(n.X))
 
 	case *ast.DeclStmt:
-		w.Visit(n.Decl)
+		w.visit(n.Decl)
 
 	case *ast.AssignStmt:
-		w.p.PrintAssignment(w.parseExprList(n.Lhs), n.Tok.String(), w.parseExprList(n.Rhs), len(n.Lhs) > 1, len(n.Rhs) > 1)
+		var types []ast.Expr
+		if flagsAssign[n.Tok] && len(n.Lhs) == len(n.Rhs) {
+			for _, lhs := range n.Lhs {
+				types = append(types, exprType(lhs))
+			}
+		}
+
+		rhs := w.parseExprList(w.flagsValues(types, n.Rhs))
+		if n.Tok == token.DEFINE {
+			w.declareVars(n.Lhs...)
+		}
+
+		w.p.PrintAssignment(w.parseExprList(n.Lhs), n.Tok.String(), rhs, len(n.Lhs) > 1, len(n.Rhs) > 1)
 
 	case *ast.IncDecStmt:
-		w.p.PrintStmt("", w.parseExpr(n.X)+n.Tok.String())
+		w.p.PrintStmt("", w.p.FormatIncDec(w.parseExpr(n.X), n.Tok.String()))
 
 	case *ast.SendStmt:
-		w.p.PrintSend(w.parseExpr(n.Chan), w.parseExpr(n.Value))
+		if w.supports(n, printer.CAP_CHANNELS) {
+			w.p.PrintSend(w.parseExpr(n.Chan), w.parseExpr(n.Value))
+		} else {
+			w.printUnsupported(n)
+		}
 
 	case *ast.EmptyStmt:
 		w.p.PrintEmpty()
 
-	default:
-		w.p.Print(fmt.Sprintf("/* Node: %#v */\n", n))
-		ret = w
-	}
+	case *ast.BadDecl, *ast.BadStmt:
+		w.printBroken(n)
 
-	w.Flush()
+	default:
+		w.addError(n, constructName(n)+"s", "unsupported node %T", n)
 
-	w.parent = pparent
-	return
+		if _, ok := n.(ast.Stmt); ok {
+			w.printUnsupported(n)
+		} else {
+			w.p.Print(w.fallback(n, fmt.Sprintf("Node: %T", n)) + "\n")
+		}
+	}
 }
 
+//
+// Flush writes any buffered output to the output writer
+//
 func (w *GoWalker) Flush() {
-	if w.flush && w.buffer.Len() > 0 {
-		w.buffer.WriteTo(w.writer)
-		w.buffer.Reset()
-	}
+	w.pipe.Flush()
+	w.out.Flush()
 }
 
+//
+// BufferVisit converts node into a string instead of writing it to the output.
+// Only the fragments that need to be embedded in other statements or expressions
+// (init statements, function literals) are buffered, everything else is streamed.
+//
 func (w *GoWalker) BufferVisit(node ast.Node) (ret string) {
-	w.Flush()
+	var buffer bytes.Buffer
 
-	prev := w.flush
-	w.flush = false
+	prev := w.current
+	w.setOutput(&buffer)
+	w.p.SetPosition(token.Position{}) // no position information within fragments
 
-	w.Visit(node)
+	w.visit(node)
 
-	w.flush = prev
+	w.setOutput(prev)
+	w.p.SetPosition(w.pos)
 
-	ret = w.buffer.String()
-	w.buffer.Reset()
+	ret = buffer.String()
 
-	if prev == true {
+	if prev == w.pipe {
 		ret = strings.TrimSpace(ret)
 	}
 
@@ -232,7 +998,8 @@ func (w *GoWalker) parseExpr(expr interface{}) string {
 	}
 
 	if w.debug {
-		w.p.Print(fmt.Sprintf("/* Expr: %#v */\n", expr))
+		n, _ := expr.(ast.Node)
+		w.p.Print(w.fallback(n, fmt.Sprintf("Expr: %#v", expr)) + "\n")
 	}
 
 	switch expr := expr.(type) {
@@ -242,7 +1009,7 @@ func (w *GoWalker) parseExpr(expr interface{}) string {
 		if expr == nil {
 			return ""
 		}
-		return w.p.FormatIdent(expr.Name)
+		return w.p.FormatIdent(w.identName(expr))
 
 		// *thing
 	case *ast.StarExpr:
@@ -272,6 +1039,8 @@ func (w *GoWalker) parseExpr(expr interface{}) string {
 
 		// <-chan type
 	case *ast.ChanType:
+		w.supports(expr, printer.CAP_CHANNELS)
+
 		chdir := printer.CHAN_BIDI
 		if expr.Dir == ast.SEND {
 			chdir = printer.CHAN_SEND
@@ -293,7 +1062,7 @@ func (w *GoWalker) parseExpr(expr interface{}) string {
 
 		// type{list}
 	case *ast.CompositeLit:
-		return w.p.FormatCompositeLit(w.parseExpr(expr.Type), w.parseExprList(expr.Elts))
+		return w.parseCompositeLit(expr, expr.Type)
 
 		// ...type
 	case *ast.Ellipsis:
@@ -301,14 +1070,25 @@ func (w *GoWalker) parseExpr(expr interface{}) string {
 
 		// -3
 	case *ast.UnaryExpr:
+		if expr.Op == token.ARROW {
+			w.supports(expr, printer.CAP_CHANNELS)
+		}
 		return w.p.FormatUnary(expr.Op.String(), w.parseExpr(expr.X))
 
 		// 3 + 2
 	case *ast.BinaryExpr:
 		return w.p.FormatBinary(w.parseExpr(expr.X), expr.Op.String(), w.parseExpr(expr.Y))
 
+		// generic[T1, T2]
+	case *ast.IndexListExpr:
+		w.supports(expr, printer.CAP_GENERICS)
+		return w.p.FormatGeneric(w.parseExpr(expr.X), w.parseExprList(expr.Indices))
+
 		// array[index]
 	case *ast.IndexExpr:
+		if isString(expr.X) {
+			return w.p.FormatStringIndex(w.parseExpr(expr.X), w.parseExpr(expr.Index))
+		}
 		return w.p.FormatArrayIndex(w.parseExpr(expr.X), w.parseExpr(expr.Index))
 
 		// key: value
@@ -321,20 +1101,39 @@ func (w *GoWalker) parseExpr(expr interface{}) string {
 
 		// package.member
 	case *ast.SelectorExpr:
-		ident, isObj := expr.X.(*ast.Ident)
-		if isObj {
-			isObj = ident.Obj != nil
+		isObj := true // only an identifier can be a package
+		if ident, ok := expr.X.(*ast.Ident); ok {
+			isObj = !w.isPackageName(ident)
+
+			if w.cgo && ident.Name == "C" && !isObj {
+				return cgoName(expr.Sel.Name)
+			}
+
+			if ipath, ok := w.nativeLocal[ident.Name]; ok && !isObj {
+				w.useNative(ipath, expr.Sel.Name)
+			}
 		}
-		return w.p.FormatSelector(w.parseExpr(expr.X), w.parseExpr(expr.Sel), isObj)
+		return w.p.FormatSelector(w.parseExpr(expr.X), w.p.FormatIdent(w.mangle(expr.Sel.Name)), isObj)
 
 		// funcname(args)
 	case *ast.CallExpr:
 		_, funclit := expr.Fun.(*ast.FuncLit)
-		return w.p.FormatCall(w.parseExpr(expr.Fun), w.parseExprList(expr.Args)+printer.IfTrue("...", expr.Ellipsis > 0), funclit)
+		args := make([]string, len(expr.Args))
+		for i, a := range expr.Args {
+			args[i] = w.parseExpr(a)
+		}
+		if expr.Ellipsis.IsValid() && len(args) > 0 {
+			args[len(args)-1] = w.p.FormatSpread(args[len(args)-1])
+		}
+		return w.p.FormatCall(w.parseExpr(expr.Fun), strings.Join(args, ", "), funclit)
 
 		// name.(type)
 	case *ast.TypeAssertExpr:
-		return w.p.FormatTypeAssert(w.parseExpr(expr.X), w.exprOr(expr.Type, "type"))
+		return w.p.FormatTypeAssert(w.parseExpr(expr.X), w.parseExpr(expr.Type))
+
+		// an expression that couldn't be parsed (see parseFile)
+	case *ast.BadExpr:
+		return w.unsupported(expr)
 
 		// (expr)
 	case *ast.ParenExpr:
@@ -342,10 +1141,21 @@ func (w *GoWalker) parseExpr(expr interface{}) string {
 
 		// func(params) (ret) { body }
 	case *ast.FuncLit:
+		w.symbols.Push()
+		defer w.symbols.Pop()
+
+		w.declareFields(expr.Type.Params)
+		w.declareFields(expr.Type.Results)
 		return w.p.FormatFuncLit(w.parseExpr(expr.Type), w.BufferVisit(expr.Body))
 	}
 
-	return fmt.Sprintf("/* Expr: %#v */", expr)
+	n, ok := expr.(ast.Node)
+	if !ok {
+		return w.fallback(n, fmt.Sprintf("Expr: %T", expr))
+	}
+
+	w.addError(n, constructName(n)+"s", "unsupported expression %T", n)
+	return w.unsupported(n)
 }
 
 func (w *GoWalker) parseExprList(l []ast.Expr) string {
@@ -356,11 +1166,80 @@ func (w *GoWalker) parseExprList(l []ast.Expr) string {
 	return strings.Join(exprs, ", ")
 }
 
+//
+// parseCompositeLit parses a composite literal of type typ (for the elided types, the element type of the enclosing literal).
+// The keys of the struct literals are field names, mangled as in the declaration of the fields (see parseFieldList)
+//
+func (w *GoWalker) parseCompositeLit(lit *ast.CompositeLit, typ ast.Expr) string {
+	var ltype string
+	if name, ok := w.anonTypes[types.ExprString(lit.Type)]; ok && lit.Type != nil {
+		ltype = name
+	} else {
+		ltype = w.parseExpr(lit.Type)
+	}
+
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X // []*Point{{1, 2}}
+	}
+
+	if id, ok := typ.(*ast.Ident); ok && id.Obj != nil && id.Obj.Kind == ast.Typ {
+		if spec, ok := id.Obj.Decl.(*ast.TypeSpec); ok {
+			typ = spec.Type
+		}
+	}
+
+	var ktype, vtype ast.Expr
+	fieldKeys := true // the types declared in other packages are structs, for the keys that are identifiers
+
+	switch t := typ.(type) {
+	case *ast.ArrayType:
+		vtype, fieldKeys = t.Elt, false
+
+	case *ast.MapType:
+		ktype, vtype, fieldKeys = t.Key, t.Value, false
+	}
+
+	elts := []string{}
+	for _, e := range lit.Elts {
+		kv, ok := e.(*ast.KeyValueExpr)
+		if !ok {
+			elts = append(elts, w.parseElement(e, vtype))
+			continue
+		}
+
+		var key string
+		if id, ok := kv.Key.(*ast.Ident); ok && fieldKeys {
+			key = w.p.FormatIdent(w.mangle(id.Name))
+		} else {
+			key = w.parseElement(kv.Key, ktype)
+		}
+
+		elts = append(elts, w.p.FormatKeyValue(key, w.parseElement(kv.Value, vtype)))
+	}
+
+	return w.p.FormatCompositeLit(ltype, strings.Join(elts, ", "))
+}
+
+//
+// parseElement parses a key or an element of a composite literal, of type typ if it's a composite literal with the type elided
+//
+func (w *GoWalker) parseElement(e, typ ast.Expr) string {
+	if lit, ok := e.(*ast.CompositeLit); ok && lit.Type == nil {
+		return w.parseCompositeLit(lit, typ)
+	}
+
+	return w.parseExpr(e)
+}
+
 func (w *GoWalker) parseFieldList(l *ast.FieldList, ftype printer.FieldType) string {
 	buffer := bytes.NewBufferString("")
 
 	if l != nil {
 		for _, f := range l.List {
+			if ftype == printer.METHOD && len(f.Names) == 0 && isUnion(f.Type) {
+				w.supports(f, printer.CAP_UNIONS)
+			}
+
 			ptype := w.parseExpr(f.Type)
 
 			if len(f.Names) == 0 {
@@ -369,7 +1248,17 @@ func (w *GoWalker) parseFieldList(l *ast.FieldList, ftype printer.FieldType) str
 			}
 
 			for _, n := range f.Names {
-				buffer.WriteString(w.p.FormatPair(printer.Pair{n.Name, ptype}, ftype))
+				name := w.identName(n)
+				if ftype == printer.FIELD || ftype == printer.METHOD {
+					name = w.mangle(n.Name) // as in selectors, that are not resolved
+				}
+
+				if ftype == printer.FIELD && w.atomicFields[n.Name] {
+					buffer.WriteString(w.p.FormatPair(printer.Pair{name, w.p.FormatAtomic(ptype)}, ftype))
+					continue
+				}
+
+				buffer.WriteString(w.p.FormatPair(printer.Pair{name, ptype}, ftype))
 			}
 		}
 	}
@@ -377,20 +1266,55 @@ func (w *GoWalker) parseFieldList(l *ast.FieldList, ftype printer.FieldType) str
 	return w.p.Chop(buffer.String())
 }
 
+//
+// structTags returns the fields of a struct type, if any of them has a tag
+//
+func structTags(st *ast.StructType) (fields []printer.StructField) {
+	tagged := false
+
+	for _, f := range st.Fields.List {
+		var tag string
+		if f.Tag != nil {
+			tag, _ = strconv.Unquote(f.Tag.Value)
+			tagged = true
+		}
+
+		if len(f.Names) == 0 {
+			// embedded: the name is the type name
+			t := f.Type
+			if star, ok := t.(*ast.StarExpr); ok {
+				t = star.X
+			}
+
+			name := ""
+			switch t := t.(type) {
+			case *ast.Ident:
+				name = t.Name
+			case *ast.SelectorExpr:
+				name = t.Sel.Name
+			}
+
+			fields = append(fields, printer.StructField{Name: name, Tag: reflect.StructTag(tag), Embedded: true})
+		}
+
+		for _, n := range f.Names {
+			fields = append(fields, printer.StructField{Name: n.Name, Tag: reflect.StructTag(tag)})
+		}
+	}
+
+	if !tagged {
+		return nil
+	}
+
+	return
+}
+
 func (w *GoWalker) parseNames(v []*ast.Ident) string {
 	names := make([]string, len(v))
 
 	for i, n := range v {
-		names[i] = n.Name
+		names[i] = w.identName(n)
 	}
 
 	return strings.Join(names, ", ")
 }
-
-func (w *GoWalker) exprOr(expr ast.Expr, v string) string {
-	if expr != nil {
-		return w.parseExpr(expr)
-	} else {
-		return v
-	}
-}
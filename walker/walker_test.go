@@ -0,0 +1,92 @@
+package walkngo
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/raff/walkngo/printer"
+)
+
+//
+// TestWalkRecorded converts a small file with the RecorderPrinter and checks the calls to the printer
+// (the Format methods return the canonical form of the call, i.e. FormatBinary(a, +, b))
+//
+func TestWalkRecorded(t *testing.T) {
+	src := `package main
+
+func add(a, b int) int {
+	return a + b
+}
+`
+
+	expected := `Reset()
+Print("//source: add.go\n")
+PrintPackage("main")
+PrintImportsEnd()
+PushContext()
+Print("\n")
+FormatIdent("int")
+FormatPair("a", "int", 5)
+FormatPair("b", "int", 5)
+FormatIdent("int")
+FormatPair("", "int", 6)
+PrintFunc("", "add", "FormatPair(a, int, 5), FormatPair(b, int, 5)", "FormatPair(, int, 6)")
+SameLine()
+PrintBlockStart(7)
+FormatIdent("a")
+FormatIdent("b")
+FormatBinary("a", "+", "b")
+PrintReturn("FormatBinary(a, +, b)", false)
+PrintBlockEnd(7)
+Print("\n")
+PopContext()
+`
+
+	rec := &printer.RecorderPrinter{}
+	w := NewWalker(rec, io.Discard, false)
+
+	if err := w.WalkSource("add.go", []byte(src)); err != nil {
+		t.Fatal(err)
+	}
+
+	if log := rec.Log(); log != expected {
+		t.Errorf("unexpected calls:\n%s\nexpected:\n%s", log, expected)
+	}
+}
+
+//
+// TestKeyedLiteralNames checks that the keys of the struct literals are renamed like the fields, when they are reserved words
+// in C++, and that the keys of the maps are not field names
+//
+func TestKeyedLiteralNames(t *testing.T) {
+	src := `package main
+
+type class struct {
+	new    int
+	delete string
+}
+
+func main() {
+	c := class{new: 1, delete: "x"}
+	cs := []*class{{new: 2}}
+	m := map[bool]class{true: {delete: "y"}}
+	println(c.new, cs, m)
+}
+`
+
+	var out bytes.Buffer
+	w := NewWalker(&printer.CPrinter{}, &out, false)
+
+	if err := w.WalkSource("class.go", []byte(src)); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+
+	for _, expected := range []string{`{{new_, 1}, {delete_, "x"}}`, `{{{new_, 2}}}`, `{{true, {{delete_, "y"}}}}`} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("%s not found in:\n%s", expected, out.String())
+		}
+	}
+}
@@ -5,34 +5,65 @@ package main
 //
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/raff/walkngo/printer"
 	"github.com/raff/walkngo/walker"
 )
 
+// version of walkngo (set at build time with -ldflags "-X main.version=...")
+var version = "0.1"
+
 type Walker struct {
 	*walkngo.GoWalker
 
-	outdir string
-	prefix string
-	ext    string
+	outdir    string
+	prefix    string
+	ext       string
+	bazel     *BazelBuild
+	used      ImportSet // imports of the converted files
+	overrides Overrides // hand-written files copied instead of the converted ones
+	modules   ModuleUnits
+	pybind    PybindModules
+	names     NameMap                 // identifiers renamed in the converted files
+	summary   *walkngo.Summary        // constructs not converted
+	diag      *walkngo.Diagnostics    // where the problems found are reported
+	protobuf  bool                    // skip protoc generated files
+	verbosity Verbosity               // messages printed while converting
+	outnames  *OutputNames            // names of the output files (nil for the name of the source)
+	written   map[string]bool         // output files written in this run (the following sources are appended)
+	keeps     map[string]*KeepRegions // hand-edited regions of the output files, merged at the end of the run
+	coverage  *Coverage               // translation status of the converted files (nil if not requested)
+	examples  ExampleMains            // testable examples of the converted tests (nil if not converting to C++)
+	cache     *Cache                  // converted files of the previous runs (nil if not enabled)
+	newline   string                  // line terminator of the output files
+	bom       bool                    // start the output files with a byte order mark
+	externals Externals               // symbols used by the converted files and defined elsewhere (nil if not requested)
 }
 
 func (w Walker) Walk(path string, info os.FileInfo, err error) error {
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		return nil
 	}
 
-	var outpath string
+	var outpath, rel string
 
 	if len(w.outdir) > 0 {
-		outpath = filepath.Join(w.outdir, path[len(w.prefix):])
+		if r, err := filepath.Rel(w.prefix, path); err == nil {
+			rel, outpath = r, filepath.Join(w.outdir, r)
+		}
 	}
 
 	if info.IsDir() {
@@ -42,70 +73,890 @@ func (w Walker) Walk(path string, info os.FileInfo, err error) error {
 
 		if len(outpath) > 0 {
 			if err := os.MkdirAll(outpath, 0755); err != nil {
-				fmt.Println(err)
+				fmt.Fprintln(os.Stderr, err)
 			}
 		}
 	} else if strings.HasSuffix(path, ".go") {
+		if override, ext := findOverride(path, w.ext); len(override) > 0 {
+			if len(outpath) > 0 {
+				if outpath, err = w.outputPath(path, rel, ext); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					return nil
+				}
+			}
+
+			w.verbosity.Printf(VERBOSE, "copying %s", override)
+
+			if err := w.writeOverride(override, outpath); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+
+			pkgname, imports := sourceImports(path)
+			if w.bazel != nil && len(outpath) > 0 && len(pkgname) > 0 {
+				w.bazel.Add(outpath, pkgname, imports)
+			}
+
+			for _, i := range imports {
+				w.used[i] = true
+			}
+
+			if w.pybind != nil && len(outpath) > 0 {
+				w.pybind.Add(outpath, "", path, nil)
+			}
+
+			return nil
+		}
+
+		if w.protobuf && walkngo.IsProtoGenerated(path) {
+			// the C++ code is generated by protoc, see ProtoImportMapping
+			w.verbosity.Printf(VERBOSE, "skipping %s (generated by protoc)", path)
+			return nil
+		}
+
+		var out io.Writer
+
 		if len(outpath) > 0 {
-			outpath = outpath[:len(outpath)-2] + w.ext
-			f, err := os.Create(outpath)
+			if outpath, err = w.outputPath(path, rel, w.ext); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return nil
+			}
+
+			f, err := w.openOutput(outpath)
 			if err != nil {
-				fmt.Println(err)
-			} else {
-				w.SetWriter(f)
-				defer f.Close()
+				fmt.Fprintln(os.Stderr, err)
+				return nil
+			}
+
+			defer f.Close()
+			out = w.encode(f)
+		}
+
+		var key string
+		var e *CacheEntry
+
+		if w.cache != nil && out != nil {
+			if src, err := os.ReadFile(path); err == nil {
+				key = w.cache.Key(rel, src)
+				e = w.cache.Get(key)
 			}
 		}
 
-		if err := w.WalkFile(path); err != nil {
-			fmt.Println(err)
+		if e != nil {
+			w.verbosity.Printf(VERBOSE, "converting %s (cached)", path)
+
+			if _, err := io.WriteString(out, e.Output); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		} else {
+			e = w.convert(path, outpath, out, key)
+		}
+
+		if w.names != nil {
+			w.names.Add(path, e.Mangled)
+		}
+
+		if w.externals != nil {
+			w.externals.Add(path, e.Externals)
+		}
+
+		if w.bazel != nil && len(outpath) > 0 && len(e.Package) > 0 {
+			w.bazel.Add(outpath, e.Package, e.Imports)
+		}
+
+		if w.modules != nil && len(outpath) > 0 {
+			w.modules.Add(outpath, e.Namespace, path)
+		}
+
+		if w.pybind != nil && len(outpath) > 0 {
+			w.pybind.Add(outpath, e.Namespace, path, e.Exports)
+		}
+
+		if w.examples != nil && len(outpath) > 0 {
+			w.examples.Add(outpath, e.Namespace, e.Examples)
+		}
+
+		for _, i := range e.Imports {
+			w.used[i] = true
 		}
 	}
 
 	return nil
 }
 
+//
+// convert converts the Go source path to out (nil for stdout) and returns what the rest of the run needs
+// from the walker. With a cache key, the converted file is cached if there were no problems
+//
+func (w Walker) convert(path, outpath string, out io.Writer, key string) *CacheEntry {
+	var output bytes.Buffer
+
+	switch {
+	case out != nil && len(key) > 0:
+		w.SetWriter(io.MultiWriter(out, &output))
+	case out != nil:
+		w.SetWriter(out)
+	}
+
+	w.verbosity.Printf(VERBOSE, "converting %s", path)
+
+	err := w.WalkFile(path)
+	if err != nil {
+		w.diag.PrintError(err)
+
+		if errs, ok := err.(walkngo.WalkErrors); ok {
+			w.summary.Add(path, errs)
+		}
+	}
+
+	if sm := w.SourceMap(); sm != nil && len(outpath) > 0 {
+		sm.File = filepath.Base(outpath)
+		if err := sm.WriteFile(outpath + ".map"); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	if c := w.Coverage(); w.coverage != nil && c != nil {
+		*w.coverage = append(*w.coverage, c)
+	}
+
+	e := &CacheEntry{
+		Package:   w.PackageName(),
+		Namespace: w.Namespace(),
+		Imports:   w.Imports(),
+		Exports:   w.Exports(),
+		Examples:  w.Examples(),
+		Mangled:   w.Mangled(),
+		Externals: w.Externals(),
+	}
+
+	if len(key) > 0 && err == nil {
+		e.Output = output.String()
+
+		if err := w.cache.Put(key, e); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	return e
+}
+
+//
+// startProfile starts CPU profiling to name.cpu.pprof and returns a function that stops it
+// and writes the memory (allocations) profile to name.mem.pprof
+//
+func startProfile(name string) (func(), error) {
+	cpu, err := os.Create(name + ".cpu.pprof")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		cpu.Close()
+		return nil, err
+	}
+
+	return func() {
+		pprof.StopCPUProfile()
+		cpu.Close()
+
+		mem, err := os.Create(name + ".mem.pprof")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+
+		defer mem.Close()
+
+		runtime.GC() // get up-to-date statistics
+		if err := pprof.Lookup("allocs").WriteTo(mem, 0); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}, nil
+}
+
 func main() {
 	debug := flag.Bool("debug", false, "print AST nodes")
 	pdebug := flag.Bool("debug-printer", false, "print Printer calls")
+	logdebug := flag.Bool("log-debug", false, "log walker and printer debug events to stderr")
 	outd := flag.String("outdir", "", "create converted files in outdir")
 	lang := flag.String("lang", "go", "convert to specified language (go, c, rust)")
+	indent := flag.String("indent", "2", "indentation for each level (positive number of spaces or 'tab')")
+	width := flag.Int("width", 0, "wrap lines longer than width (0 for no limit)")
+	braces := flag.String("braces", "kr", "placement of opening braces and else (kr, allman)")
+	imports := flag.String("imports", "", "JSON file mapping Go import paths to target includes and symbols (c only)")
+	typemap := flag.String("types", "", "JSON file mapping Go types to target types (c only)")
+	regexpLib := flag.String("regexp", "std", "implementation of the regexp package (std for std::regex, re2 for RE2, c only)")
+	comments := flag.Bool("comments", true, "keep the comments of the Go source (--comments=false to drop them)")
+	sourcemap := flag.Bool("source-map", false, "write a JSON source map (file.ext.map) mapping each output line to the Go source position")
+	color := flag.String("color", "auto", "colorize the diagnostics (auto, always, never)")
+	coverage := flag.String("coverage", "", "write a report of the source ranges translated, partially translated and skipped (file.json or file.html)")
+	namemap := flag.String("name-map", "", "write a JSON file mapping the identifiers renamed because they are reserved words in the target language to their new names")
+	externals := flag.String("externals", "", "write a JSON file listing the symbols used by the converted files and defined elsewhere (imported package members and builtin functions)")
+	groupdecls := flag.Bool("group-decls", false, "print the declarations grouped by kind (types, constants and variables, functions, with forward declarations) instead of in source order")
+	declsFallback := flag.Bool("decls-fallback", false, "for the files that can't be fully converted, print only the declarations (types, constants, variables and function prototypes)")
+	raii := flag.Bool("raii", false, "convert the deferred calls releasing the resource just acquired (defer mu.Unlock(), defer f.Close()) to RAII guards (c only)")
+	timestamp := flag.Bool("timestamp", true, "include the generation time in the header of the output files (--timestamp=false for reproducible output)")
+	lineEndings := flag.String("line-endings", "lf", "line endings of the converted files (lf or crlf)")
+	bom := flag.Bool("bom", false, "start the converted files with a UTF-8 byte order mark")
+	layout := flag.String("layout", "file", "output files layout: one for each Go source (file) or one for each package (package)")
+	outname := flag.String("output-name", "", "template for the names of the output files, relative to outdir (i.e. {{.Dir}}/{{snake .Base}}.gen.{{.Ext}})")
+	checkSyntax := flag.Bool("check-syntax", true, "report the lines of the generated code that can't be valid C++ (c only, --check-syntax=false to disable)")
+	annotate := flag.Bool("annotate", false, "print the Go signature of each function as a comment above its translation")
+	srccomments := flag.Bool("source-comments", false, "print the Go source of each statement as a comment above its translation")
+	unsafe := flag.Bool("unsafe", false, "translate unsafe package operations to casts and raw pointer arithmetic (c only)")
+	externc := flag.Bool("extern-c", false, "add extern \"C\" wrappers for exported functions (c only)")
+	cxxmodules := flag.Bool("cxx-modules", false, "emit C++20 module units (export module) instead of include based files (c only)")
+	pybind := flag.Bool("pybind11", false, "write pybind11 bindings for the exported declarations of each converted package in outdir (c only)")
+	protobuf := flag.Bool("protobuf", false, "skip protoc generated files and, with --module, map the generated packages to the C++ headers generated by protoc (c only)")
+	module := flag.Bool("module", false, "resolve imports within the enclosing Go module and also convert the imported packages of the module")
+	vendor := flag.Bool("vendor", false, "with --module, also convert the imported packages in the vendor folder")
+	vendorInclude := flag.String("vendor-include", "", "comma separated list of vendored import path patterns to convert (i.e. github.com/pkg/...), default all")
+	vendorExclude := flag.String("vendor-exclude", "", "comma separated list of vendored import path patterns not to convert")
+	bazel := flag.Bool("bazel", false, "write a BUILD.bazel file for each converted package in outdir (c only)")
+	bazelRuntime := flag.String("bazel-runtime", "@walkngo//runtime/c:runtime", "label of the runtime library in the generated BUILD.bazel files")
+	manifest := flag.String("manifest", "", "write a dependency manager manifest for the converted code in outdir (conan or vcpkg, c only)")
+	native := flag.String("native", "", "comma separated list of import paths to keep as native Go code, called via a cgo shim (c only)")
+	linedirs := flag.Bool("line-directives", false, "emit #line directives pointing to the Go source (c only)")
+	quiet := flag.Bool("q", false, "only print the errors (not the constructs not converted)")
+	verbose := flag.Bool("v", false, "also print the files converted")
+	veryVerbose := flag.Bool("vv", false, "also print the files converted and notes for each construct (fallbacks taken, types guessed)")
+	cache := flag.Bool("cache", false, "skip the files not changed since the previous run (same source and options), caching the converted files in outdir/"+CACHE_DIR)
+	profile := flag.String("profile", "", "write CPU and memory profiles to <profile>.cpu.pprof and <profile>.mem.pprof")
+	normalize := flag.String("normalize", "none", "desugar before printing (comma separated list of assign, range, switch, if, or all, and waitgroup)")
 
 	flag.Parse()
 
-	var p printer.Printer
+	if flag.Arg(0) == "runtime" {
+		if err := runtimeCommand(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 
-	switch *lang {
-	case "c", "cc":
-		p = &printer.CPrinter{}
-		*lang = "cc"
+		return
+	}
 
-	case "go":
-		p = &printer.GoPrinter{}
-		*lang = "go"
+	if len(*profile) > 0 {
+		stop, err := startProfile(*profile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
 
-	case "rust", "rs":
-		p = &printer.RustPrinter{}
-		*lang = "rs"
+		defer stop()
+	}
 
-	case "swift":
-		p = &printer.SwiftPrinter{}
-		*lang = ".swift"
+	importMap := printer.CImports
 
+	switch *regexpLib {
+	case "std":
+	case "re2":
+		importMap = importMap.With(printer.RE2Imports)
 	default:
-		fmt.Println("unsupported language", *lang, "use c, go, rust or swift")
+		fmt.Fprintln(os.Stderr, "unsupported regexp implementation", *regexpLib, "use std or re2")
+		return
+	}
+
+	if len(*imports) > 0 {
+		m, err := printer.LoadImportMap(*imports)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+
+		importMap = importMap.With(m)
+	}
+
+	var mod *walkngo.Module
+
+	if *module {
+		dir := "."
+		if flag.NArg() > 0 {
+			dir = flag.Arg(0)
+			if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+				dir = filepath.Dir(dir)
+			}
+		}
+
+		m, err := walkngo.FindModule(dir)
+		if err == nil && m == nil {
+			err = fmt.Errorf("%s: not in a Go module (no go.mod found)", dir)
+		}
+
+		if err == nil && *vendor {
+			m.Vendor = &walkngo.VendorFilter{Include: splitList(*vendorInclude), Exclude: splitList(*vendorExclude)}
+		}
+
+		if err == nil {
+			m.Protobuf = *protobuf
+		}
+
+		if err == nil {
+			var im printer.ImportMap
+
+			if im, err = m.ImportMap(); err == nil {
+				importMap = importMap.With(im)
+			}
+		}
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+
+		mod = m
+	}
+
+	if *vendor && mod == nil {
+		fmt.Fprintln(os.Stderr, "--vendor requires --module")
+		return
+	}
+
+	var nativePaths []string
+
+	if len(*native) > 0 {
+		if len(*outd) == 0 {
+			fmt.Fprintln(os.Stderr, "--native requires --outdir")
+			return
+		}
+
+		nativePaths = strings.Split(*native, ",")
+
+		m := printer.ImportMap{}
+		for _, p := range nativePaths {
+			m[p] = printer.ImportMapping{Include: `"walkngo_native.hpp"`}
+		}
+
+		importMap = importMap.With(m)
+	}
+
+	var typeMap printer.TypeMap
+
+	if len(*typemap) > 0 {
+		m, err := printer.LoadTypeMap(*typemap)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+
+		typeMap = m
+	}
+
+	style := printer.DefaultStyle
+
+	if *indent == "tab" {
+		style.Indent = "\t"
+	} else if n, err := strconv.Atoi(*indent); err == nil && n > 0 {
+		// not 0, since the empty indentation is the default one (see FormatStyle)
+		style.Indent = strings.Repeat(" ", n)
+	} else {
+		fmt.Fprintln(os.Stderr, "invalid indent", *indent, "use a positive number of spaces or 'tab'")
+		return
+	}
+
+	if b, ok := printer.ParseBraceStyle(*braces); ok {
+		style.Braces = b
+	} else {
+		fmt.Fprintln(os.Stderr, "unsupported brace style", *braces, "use kr or allman")
+		return
+	}
+
+	style.MaxWidth = *width
+
+	// newPrinter returns a printer for a target language (and the extension of the converted files)
+	newPrinter := func(lang string) (p printer.Printer, ext string, err error) {
+		switch lang {
+		case "c", "cc":
+			p = &printer.CPrinter{LineDirectives: *linedirs, Imports: importMap, Types: typeMap, Unsafe: *unsafe, ExternC: *externc, Modules: *cxxmodules}
+			ext = "cc"
+
+		case "go":
+			p = &printer.GoPrinter{}
+			ext = "go"
+
+		case "rust", "rs":
+			p = &printer.RustPrinter{}
+			ext = "rs"
+
+		case "swift":
+			p = &printer.SwiftPrinter{}
+			ext = "swift"
+
+		default:
+			return nil, "", fmt.Errorf("unsupported language %s, use c, go, rust or swift", lang)
+		}
+
+		p.SetStyle(style)
+
+		if *pdebug {
+			p = &printer.DebugPrinter{P: p}
+		}
+
+		return
+	}
+
+	p, ext, err := newPrinter(*lang)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+
+	*lang = ext
+
+	norm, ok := walkngo.ParseNormalization(*normalize)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "invalid normalization", *normalize, "use assign, range, switch, if, waitgroup, all or none")
+		return
+	}
+
+	useColor, ok := walkngo.UseColor(*color, os.Stderr)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "invalid color mode", *color, "use auto, always or never")
+		return
+	}
+
+	verbosity, ok := ParseVerbosity(*quiet, *verbose, *veryVerbose)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "-q can't be used with -v or -vv")
 		return
 	}
 
-	if *pdebug {
-		p = &printer.DebugPrinter{p}
+	diag := &walkngo.Diagnostics{W: os.Stderr, Color: useColor, Quiet: verbosity == QUIET}
+
+	walker := Walker{
+		GoWalker:  walkngo.NewWalker(p, os.Stdout, *debug),
+		outdir:    *outd,
+		ext:       *lang,
+		used:      ImportSet{},
+		overrides: Overrides{},
+		summary:   &walkngo.Summary{},
+		diag:      diag,
+		protobuf:  *protobuf,
+		verbosity: verbosity,
+		written:   map[string]bool{},
+		keeps:     map[string]*KeepRegions{},
+		newline:   "\n",
+		bom:       *bom,
+	}
+	provenance := &walkngo.Provenance{Version: version, Command: commandLine()}
+	if *timestamp {
+		provenance.Time = generationTime()
+	}
+
+	// configure applies the conversion options to a walker for the target language lang
+	configure := func(w *walkngo.GoWalker, lang string) {
+		w.SetNormalization(norm)
+		w.SetSourceComments(*srccomments)
+		w.SetAnnotate(*annotate)
+		w.SetCheckSyntax(*checkSyntax && lang == "cc")
+		w.SetGroupDecls(*groupdecls)
+		w.SetDeclsFallback(*declsFallback)
+		w.SetRAII(*raii)
+		w.SetComments(*comments)
+	}
+
+	configure(walker.GoWalker, *lang)
+	walker.SetProvenance(provenance)
+	walker.SetSourceMap(*sourcemap)
+	walker.SetNative(nativePaths...)
+	walker.SetModule(mod)
+
+	if *bazel {
+		if len(*outd) == 0 || *lang != "cc" {
+			fmt.Fprintln(os.Stderr, "--bazel requires --outdir and --lang=c")
+			return
+		}
+
+		walker.bazel = NewBazelBuild(*outd, *bazelRuntime, mod)
+	}
+
+	if *cxxmodules {
+		if *lang != "cc" {
+			fmt.Fprintln(os.Stderr, "--cxx-modules requires --lang=c")
+			return
+		}
+
+		walker.modules = ModuleUnits{}
 	}
 
-	walker := Walker{walkngo.NewWalker(p, os.Stdout, *debug), *outd, "", *lang}
+	if *pybind {
+		if len(*outd) == 0 || *lang != "cc" || *cxxmodules {
+			fmt.Fprintln(os.Stderr, "--pybind11 requires --outdir and --lang=c (and can't be used with --cxx-modules)")
+			return
+		}
+
+		walker.pybind = PybindModules{}
+	}
+
+	if len(*namemap) > 0 {
+		walker.names = NameMap{}
+	}
+
+	if len(*externals) > 0 {
+		walker.externals = Externals{}
+	}
+
+	if len(*coverage) > 0 {
+		walker.coverage = &Coverage{}
+	}
+
+	if len(*outd) > 0 && *lang == "cc" && !*cxxmodules {
+		// the examples are declared in the namespace of the package, not imported from a module
+		walker.examples = ExampleMains{}
+	}
+
+	newline, err := newlines(*lineEndings)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+
+	if walker.newline = newline; newline != "\n" {
+		walker.SetWriter(walker.encode(os.Stdout))
+	}
+
+	if *cache {
+		if len(*outd) == 0 || len(*coverage) > 0 || *sourcemap || len(*native) > 0 {
+			fmt.Fprintln(os.Stderr, "--cache requires --outdir (and can't be used with --coverage, --source-map or --native)")
+			return
+		}
+
+		walker.cache = NewCache(*outd, cacheOptions(mod))
+	}
+
+	switch *layout {
+	case "file":
+	case "package":
+		if len(*outname) > 0 || *cxxmodules || *sourcemap {
+			fmt.Fprintln(os.Stderr, "--layout=package can't be used with --output-name, --cxx-modules or --source-map")
+			return
+		}
+
+		*outname = PACKAGE_LAYOUT
+	default:
+		fmt.Fprintln(os.Stderr, "unsupported layout", *layout, "use file or package")
+		return
+	}
+
+	if len(*outname) > 0 {
+		if len(*outd) == 0 {
+			fmt.Fprintln(os.Stderr, "--output-name and --layout=package require --outdir")
+			return
+		}
+
+		names, err := ParseOutputNames(*outname)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "invalid output name:", err)
+			return
+		}
+
+		walker.outnames = names
+	}
+
+	if _, ok := runtimePackages[*manifest]; len(*manifest) > 0 && (!ok || *lang != "cc") {
+		fmt.Fprintln(os.Stderr, "--manifest requires --lang=c and one of conan or vcpkg")
+		return
+	}
+
+	if style.MaxWidth > 0 {
+		walker.AddTransformer(printer.WrapLines(style))
+	}
+
+	if *logdebug {
+		walker.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	} else if verbosity >= VERY_VERBOSE {
+		walker.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	}
+
+	if flag.Arg(0) == "expr" {
+		if err := exprCommand(walker.GoWalker, diag, flag.Args()[1:]); err != nil {
+			diag.PrintError(err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	// newWalker returns a walker for the commands converting code in memory (serve, daemon)
+	newWalker := func(target string) (*walkngo.GoWalker, error) {
+		p, ext, err := newPrinter(target)
+		if err != nil {
+			return nil, err
+		}
+
+		w := walkngo.NewWalker(p, io.Discard, false)
+		configure(w, ext)
+		return w, nil
+	}
+
+	if flag.Arg(0) == "serve" {
+		if err := serveCommand(newWalker, *lang, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if flag.Arg(0) == "daemon" {
+		if err := daemonCommand(newWalker, *lang, flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
 
 	for _, f := range flag.Args() {
 		walker.prefix = f
+		if info, err := os.Stat(f); err == nil && !info.IsDir() {
+			walker.prefix = filepath.Dir(f)
+		}
 
 		filepath.Walk(f, walker.Walk)
 	}
+
+	if mod != nil {
+		walker.walkDependencies(mod, flag.Args())
+	}
+
+	walker.mergeKeepRegions()
+
+	if len(nativePaths) > 0 {
+		writeNative(walker.GoWalker, *outd)
+	}
+
+	if walker.examples != nil {
+		if err := walker.examples.Write(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+
+		if walker.bazel != nil {
+			for dir := range walker.examples {
+				walker.bazel.AddExamples(dir, walker.examples.Funcs(dir))
+			}
+		}
+	}
+
+	if walker.bazel != nil {
+		if err := walker.bazel.Write(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	if walker.modules != nil {
+		if err := walker.modules.Write(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	if walker.pybind != nil {
+		if err := walker.pybind.Write(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	if walker.names != nil {
+		if err := walker.names.WriteFile(*namemap); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	if walker.externals != nil {
+		if err := walker.externals.WriteFile(*externals); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	if walker.coverage != nil {
+		if err := walker.coverage.WriteFile(*coverage); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	if verbosity > QUIET {
+		walker.summary.Write(os.Stderr)
+	}
+
+	if walker.used["testing"] && len(*outd) > 0 && *lang == "cc" {
+		writeTestMain(*outd)
+	}
+
+	if len(*manifest) > 0 {
+		if err := writeManifest(*manifest, *outd, walker.used, importMap, walker.overrides); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+//
+// walkDependencies converts the packages of the module imported (directly or indirectly)
+// by the converted files, if not already converted.
+// The output folders mirror the module layout.
+//
+func (w Walker) walkDependencies(m *walkngo.Module, inputs []string) {
+	done := map[string]bool{}
+	var trees []string // input folders, converted recursively
+
+	for _, f := range inputs {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+
+		if !info.IsDir() {
+			f = filepath.Dir(f)
+		}
+
+		if abs, err := filepath.Abs(f); err == nil {
+			done[abs] = true
+
+			if info.IsDir() {
+				trees = append(trees, abs+string(filepath.Separator))
+			}
+		}
+	}
+
+	converted := func(dir string) bool {
+		for _, t := range trees {
+			if strings.HasPrefix(dir, t) {
+				return true
+			}
+		}
+
+		return done[dir]
+	}
+
+	w.prefix = m.Dir
+
+	for {
+		var pending []string
+
+		for _, dir := range w.Dependencies() {
+			if !converted(dir) {
+				pending = append(pending, dir)
+			}
+		}
+
+		if len(pending) == 0 {
+			return
+		}
+
+		for _, dir := range pending {
+			done[dir] = true
+
+			info, err := os.Stat(dir)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				continue
+			}
+
+			w.Walk(dir, info, nil)
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				continue
+			}
+
+			for _, e := range entries {
+				if e.IsDir() || strings.HasSuffix(e.Name(), "_test.go") {
+					continue
+				}
+
+				if info, err := e.Info(); err == nil {
+					w.Walk(filepath.Join(dir, e.Name()), info, nil)
+				}
+			}
+		}
+	}
+}
+
+//
+// commandLine returns the command line of the run, with the arguments quoted if needed
+//
+func commandLine() string {
+	args := []string{filepath.Base(os.Args[0])}
+
+	for _, arg := range os.Args[1:] {
+		if len(arg) == 0 || strings.ContainsAny(arg, " \t\n\"'\\$") {
+			arg = strconv.Quote(arg)
+		}
+
+		args = append(args, arg)
+	}
+
+	return strings.Join(args, " ")
+}
+
+//
+// generationTime returns the time of the run for the header of the output files,
+// or SOURCE_DATE_EPOCH if set (see https://reproducible-builds.org/specs/source-date-epoch/)
+//
+func generationTime() time.Time {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0)
+	}
+
+	return time.Now()
+}
+
+//
+// appendUnique appends s to list, if not already there (more sources can be converted to the same output file)
+//
+func appendUnique(list []string, s string) []string {
+	for _, e := range list {
+		if e == s {
+			return list
+		}
+	}
+
+	return append(list, s)
+}
+
+//
+// splitList splits a comma separated list, returning nil for an empty string
+//
+func splitList(s string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+
+	return strings.Split(s, ",")
+}
+
+//
+// writeTestMain writes the main for the converted tests (see go_testing.h)
+//
+func writeTestMain(outdir string) {
+	main := "// generated by walkngo: main for the converted tests\n#include <go.h>\n#define WALKNGO_TEST_MAIN\n#include <go_testing.h>\n"
+
+	if err := os.WriteFile(filepath.Join(outdir, "walkngo_test_main.cc"), []byte(main), 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+//
+// writeNative writes the cgo shim (walkngo_native.go) and the C++ adapter (walkngo_native.hpp)
+// for the native functions used by the converted files
+//
+func writeNative(w *walkngo.GoWalker, outdir string) {
+	funcs, errs := w.NativeFuncs()
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, "native:", err)
+	}
+
+	if len(funcs) == 0 {
+		return
+	}
+
+	files := map[string]string{
+		"walkngo_native.go":  walkngo.NativeShim(funcs),
+		"walkngo_native.hpp": printer.NativeAdapter(funcs, "libwalkngo_native.h"),
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(outdir, name), []byte(content), 0644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
 }
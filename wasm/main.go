@@ -0,0 +1,63 @@
+//go:build js && wasm
+
+//
+// The converter compiled to WebAssembly, for a client side playground (see walkngo.js):
+//
+//	GOOS=js GOARCH=wasm go build -o wasm/walkngo.wasm ./wasm
+//
+// It defines the global function walkngoConvert(source, target), that returns the JSON
+// {"output": "...", "diagnostics": [...]} as the /convert API of the serve command.
+//
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"syscall/js"
+
+	"github.com/raff/walkngo/printer"
+	"github.com/raff/walkngo/walker"
+)
+
+//
+// ConvertResponse is the result of walkngoConvert: the converted code and the problems found
+//
+type ConvertResponse struct {
+	Output      string               `json:"output"`
+	Diagnostics []walkngo.Diagnostic `json:"diagnostics"`
+}
+
+//
+// convert converts a Go file or snippet to the target language
+//
+func convert(source, target string) ConvertResponse {
+	p, err := printer.New(target)
+	if err != nil {
+		return ConvertResponse{Diagnostics: walkngo.Problems(err)}
+	}
+
+	w := walkngo.NewWalker(p, io.Discard, false)
+	w.SetCheckSyntax(target == "c" || target == "cc")
+
+	output, err := w.Convert(source)
+	return ConvertResponse{Output: output, Diagnostics: walkngo.Problems(err)}
+}
+
+func main() {
+	js.Global().Set("walkngoConvert", js.FuncOf(func(this js.Value, args []js.Value) any {
+		if len(args) < 1 {
+			return js.ValueOf(`{"output": "", "diagnostics": [{"severity": "error", "message": "usage: walkngoConvert(source, target)"}]}`)
+		}
+
+		target := "c"
+		if len(args) > 1 && args[1].Type() == js.TypeString {
+			target = args[1].String()
+		}
+
+		b, _ := json.Marshal(convert(args[0].String(), target))
+		return js.ValueOf(string(b))
+	}))
+
+	// keep the functions available
+	select {}
+}
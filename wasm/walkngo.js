@@ -0,0 +1,33 @@
+//
+// walkngo.js: converts Go code in the browser with walkngo.wasm (see wasm/main.go).
+// wasm_exec.js, from the Go distribution ($(go env GOROOT)/lib/wasm or misc/wasm), must be loaded first:
+//
+//   <script src="wasm_exec.js"></script>
+//   <script type="module">
+//   import { load } from "./walkngo.js";
+//
+//   const walkngo = await load("walkngo.wasm");
+//   const { output, diagnostics } = walkngo.convert("x := []int{1, 2}", "rust");
+//   </script>
+//
+
+//
+// load loads and starts the converter, and returns an object with
+// convert(source, target) -> {output, diagnostics} (target is c, go, rust or swift, default c)
+//
+export async function load(url = "walkngo.wasm") {
+	const go = new Go();
+	const resp = fetch(url);
+
+	const { instance } = WebAssembly.instantiateStreaming
+		? await WebAssembly.instantiateStreaming(resp, go.importObject)
+		: await WebAssembly.instantiate(await (await resp).arrayBuffer(), go.importObject);
+
+	go.run(instance); // doesn't return until the program exits: walkngoConvert is defined when main blocks
+
+	return {
+		convert(source, target = "c") {
+			return JSON.parse(globalThis.walkngoConvert(source, target));
+		},
+	};
+}